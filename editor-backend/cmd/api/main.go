@@ -9,12 +9,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"editor-backend/internal/auth"
+	"editor-backend/internal/events"
 	"editor-backend/internal/handler"
+	"editor-backend/internal/models"
 	"editor-backend/internal/service"
 	"editor-backend/internal/storage"
+	"editor-backend/internal/validation"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -22,6 +28,19 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// Streaming routes — the only ones that accept ?access_token=
+const (
+	routeSessionEvents = "session-events"
+	routeSessionLive   = "session-live"
+)
+
+// Routes Repurposer and Content Hub call — the only ones that accept signed
+// service requests
+const (
+	routeSessionFromClip = "session-from-clip"
+	routeHighlightCreate = "highlight-create"
+)
+
 func main() {
 	// Load .env in dev only — production injects env vars through infra (K8s secrets, etc.)
 	if os.Getenv("APP_ENV") != "production" {
@@ -56,30 +75,130 @@ func main() {
 	db.QueryRow("SELECT current_database()").Scan(&dbName)
 	fmt.Println("Connected to database:", dbName)
 
-	// ── Storage (swappable: LocalStorage today → S3 tomorrow) ─────────────────
-	// Infra team sets STORAGE_TYPE=s3 and AWS_* vars when ready.
+	// ── Storage (swappable: LocalStorage or S3) ───────────────────────────────
+	// STORAGE_TYPE=s3 with the S3_* / AWS_* vars (see storage.S3ConfigFromEnv).
 	// Your handler/service code never changes — only this wiring changes.
 	var fileStorage storage.Storage
 	if os.Getenv("STORAGE_TYPE") == "s3" {
-		fileStorage = storage.NewS3Storage(
-			os.Getenv("AWS_BUCKET"),
-			os.Getenv("AWS_REGION"),
-		)
-		log.Println("Using S3 storage")
+		s3Storage, err := storage.NewS3Storage(storage.S3ConfigFromEnv())
+		if err != nil {
+			log.Fatal("S3 storage misconfigured: ", err)
+		}
+		fileStorage = s3Storage
+		validation.AllowMediaHosts(s3Storage.FilesURL())
+		log.Println("Using S3 storage, bucket", s3Storage.Bucket)
 	} else {
 		uploadDir := os.Getenv("UPLOAD_DIR")
 		if uploadDir == "" {
 			uploadDir = "./uploads"
 		}
-		fileStorage = storage.NewLocalStorage(uploadDir, os.Getenv("BASE_URL"))
+		localStorage := storage.NewLocalStorage(uploadDir, os.Getenv("BASE_URL"))
+		// Shared key → direct upload URLs verify on every pod and survive a restart
+		signingKey, err := storage.LocalSigningKeyFromEnv()
+		if err != nil {
+			log.Fatal("Local storage misconfigured: ", err)
+		}
+		if signingKey != nil {
+			localStorage.SigningKey = signingKey
+		}
+		fileStorage = localStorage
 		log.Println("Using local storage at", uploadDir)
 	}
 
+	// Clip sources may only point at our own media — see validation.ValidateMediaSrc
+	validation.AllowMediaHosts(os.Getenv("BASE_URL"), os.Getenv("CLOUDFRONT_DOMAIN"))
+	validation.AllowMediaHosts(strings.Split(os.Getenv("MEDIA_ALLOWED_HOSTS"), ",")...)
+
+	// ── Live events (Postgres LISTEN/NOTIFY → SSE) ────────────────────────────
+	// One LISTEN connection per pod; without it the API still works, only
+	// /sessions/{id}/events answers 503.
+	eventPublisher := &events.Publisher{DB: db}
+	broker, err := events.NewBroker(dbURL)
+	if err != nil {
+		log.Println("WARNING: live events disabled — LISTEN failed:", err)
+	}
+
 	// ── Services & Handlers ───────────────────────────────────────────────────
-	sessionService := &service.SessionService{DB: db}
+	sessionService := &service.SessionService{DB: db, Events: eventPublisher}
+	// How many unlabelled timeline versions to keep per session (default 100)
+	if n, err := strconv.Atoi(os.Getenv("EDITOR_VERSION_RETENTION")); err == nil && n > 0 {
+		sessionService.VersionRetention = n
+	}
+	// How many collaborative ops to keep for reconnecting clients (default 1000)
+	if n, err := strconv.Atoi(os.Getenv("EDITOR_OPERATION_RETENTION")); err == nil && n > 0 {
+		sessionService.OperationRetention = n
+	}
+	// How many undo / redo steps to keep per session (default 50)
+	if n, err := strconv.Atoi(os.Getenv("EDITOR_UNDO_DEPTH")); err == nil && n > 0 {
+		sessionService.UndoDepth = n
+	}
+	// Wrong share link passwords before the link locks (default 10), and for how long (default 15)
+	if n, err := strconv.Atoi(os.Getenv("EDITOR_SHARE_MAX_FAILURES")); err == nil && n > 0 {
+		sessionService.ShareMaxFailures = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("EDITOR_SHARE_LOCKOUT_MINUTES")); err == nil && n > 0 {
+		sessionService.ShareLockout = time.Duration(n) * time.Minute
+	}
+	// Who may approve sessions / request changes: owner (default), editor or commenter
+	switch role := os.Getenv("EDITOR_APPROVAL_ROLE"); role {
+	case "", models.RoleOwner, models.RoleEditor, models.RoleCommenter:
+		sessionService.ApprovalRole = role
+	default:
+		log.Fatal("EDITOR_APPROVAL_ROLE must be owner, editor or commenter")
+	}
+
+	// Export jobs are only enqueued here — cmd/worker does the rendering
+	exportJobService := &service.ExportJobService{DB: db, Events: eventPublisher}
+	if n, err := strconv.Atoi(os.Getenv("EXPORT_MAX_ATTEMPTS")); err == nil && n > 0 {
+		exportJobService.MaxAttempts = n
+	}
+	// Sign-off before anything goes out: only approved sessions can be exported
+	exportJobService.RequireApproval = os.Getenv("EXPORT_REQUIRE_APPROVAL") == "true"
+
+	// Workspaces, their members and uploaded assets
+	workspaceService := &service.WorkspaceService{DB: db}
+
+	// Direct uploads — presigned URLs, the API never sees the media bytes
+	uploadService := &service.UploadService{DB: db, Storage: fileStorage}
+	if n, err := strconv.Atoi(os.Getenv("UPLOAD_URL_EXPIRY_MINUTES")); err == nil && n > 0 {
+		uploadService.URLExpiry = time.Duration(n) * time.Minute
+	}
+	// How long a resumable (tus) upload may take before it is discarded (default 24)
+	if n, err := strconv.Atoi(os.Getenv("TUS_UPLOAD_EXPIRY_HOURS")); err == nil && n > 0 {
+		uploadService.TusExpiry = time.Duration(n) * time.Hour
+	}
+
 	editorHandler := &handler.EditorHandler{
-		Service: sessionService,
-		Storage: fileStorage,
+		Service:    sessionService,
+		Storage:    fileStorage,
+		Exports:    exportJobService,
+		Workspaces: workspaceService,
+		Uploads:    uploadService,
+		BaseURL:    os.Getenv("BASE_URL"),
+		Broker:     broker,
+		Events:     eventPublisher,
+	}
+	// Requests per minute per IP on the public /share routes (default 60)
+	shareRateLimit := 60
+	if n, err := strconv.Atoi(os.Getenv("SHARE_RATE_LIMIT_PER_MINUTE")); err == nil && n > 0 {
+		shareRateLimit = n
+	}
+	editorHandler.ShareLimiter = handler.NewRateLimiter(shareRateLimit, time.Minute)
+
+	// ── Authentication ────────────────────────────────────────────────────────
+	authMiddleware, err := newAuthMiddleware(db)
+	if err != nil {
+		log.Fatal("Auth config:", err)
+	}
+	// EventSource and WebSocket can't send headers — only those routes take
+	// ?access_token=. The auth middleware runs after routing, so the route is known.
+	authMiddleware.QueryToken = func(r *http.Request) bool {
+		route := mux.CurrentRoute(r)
+		return route != nil && (route.GetName() == routeSessionEvents || route.GetName() == routeSessionLive)
+	}
+	authMiddleware.ServiceRoute = func(r *http.Request) bool {
+		route := mux.CurrentRoute(r)
+		return route != nil && (route.GetName() == routeSessionFromClip || route.GetName() == routeHighlightCreate)
 	}
 
 	// ── Router ────────────────────────────────────────────────────────────────
@@ -95,25 +214,101 @@ func main() {
 		w.Write([]byte(`{"status":"ok","service":"unified-editor","port":"8087"}`))
 	}).Methods("GET")
 
+	// Public review links — registered before the /api/v1 subrouter so they
+	// match without going through authentication. They never save; "comment"
+	// links may post comments.
+	r.HandleFunc("/api/v1/share/{token}", editorHandler.OpenShareLink).Methods("GET")
+	r.HandleFunc("/api/v1/share/{token}/comments", editorHandler.ListSharedComments).Methods("GET")
+	r.HandleFunc("/api/v1/share/{token}/comments", editorHandler.CreateSharedComment).Methods("POST")
+	// Direct uploads to local storage — the signed URL is the credential
+	r.HandleFunc(storage.LocalUploadPath+"{key}", editorHandler.LocalUpload).Methods("PUT")
+
 	// API routes — versioned so parent product can call /api/v1/* without conflicts
 	api := r.PathPrefix("/api/v1").Subrouter()
+	// Every API route needs an identity — /health and /uploads stay public
+	api.Use(authMiddleware.Handler)
 
 	// Session management (existing)
 	api.HandleFunc("/sessions", editorHandler.CreateSession).Methods("POST")
+	api.HandleFunc("/sessions", editorHandler.ListSessions).Methods("GET")
 	api.HandleFunc("/sessions/{id}", editorHandler.GetSession).Methods("GET")
 	api.HandleFunc("/sessions/{id}", editorHandler.SaveSession).Methods("PUT")
+	api.HandleFunc("/sessions/{id}", editorHandler.PatchSession).Methods("PATCH")
 	api.HandleFunc("/sessions/{id}", editorHandler.DeleteSession).Methods("DELETE")
 
-	// File upload (existing)
+	// Typed edit commands (split, trim, move, ripple delete, ...) — atomic
+	api.HandleFunc("/sessions/{id}/operations", editorHandler.ApplyOperations).Methods("POST")
+
+	// Members & ownership
+	api.HandleFunc("/sessions/{id}/members", editorHandler.ListMembers).Methods("GET")
+	api.HandleFunc("/sessions/{id}/members", editorHandler.AddMember).Methods("POST")
+	api.HandleFunc("/sessions/{id}/members/{user_id}", editorHandler.UpdateMember).Methods("PUT")
+	api.HandleFunc("/sessions/{id}/members/{user_id}", editorHandler.RemoveMember).Methods("DELETE")
+	api.HandleFunc("/sessions/{id}/transfer", editorHandler.TransferOwnership).Methods("POST")
+
+	// Share links — create / list / revoke; opening one is public (above)
+	api.HandleFunc("/sessions/{id}/shares", editorHandler.CreateShareLink).Methods("POST")
+	api.HandleFunc("/sessions/{id}/shares", editorHandler.ListShareLinks).Methods("GET")
+	api.HandleFunc("/sessions/{id}/shares/{share_id}", editorHandler.RevokeShareLink).Methods("DELETE")
+
+	// Timecoded comments & review threads
+	api.HandleFunc("/sessions/{id}/comments", editorHandler.ListComments).Methods("GET")
+	api.HandleFunc("/sessions/{id}/comments", editorHandler.CreateComment).Methods("POST")
+	api.HandleFunc("/sessions/{id}/comments/{comment_id}", editorHandler.GetComment).Methods("GET")
+	api.HandleFunc("/sessions/{id}/comments/{comment_id}", editorHandler.UpdateComment).Methods("PUT")
+	api.HandleFunc("/sessions/{id}/comments/{comment_id}", editorHandler.DeleteComment).Methods("DELETE")
+	api.HandleFunc("/sessions/{id}/comments/{comment_id}/resolve", editorHandler.ResolveComment).Methods("POST")
+	api.HandleFunc("/sessions/{id}/comments/{comment_id}/reopen", editorHandler.ReopenComment).Methods("POST")
+
+	// Approval workflow & its audit trail
+	api.HandleFunc("/sessions/{id}/transitions", editorHandler.TransitionSession).Methods("POST")
+	api.HandleFunc("/sessions/{id}/transitions", editorHandler.ListTransitions).Methods("GET")
+
+	// Workspaces — shared project spaces for agencies and teams
+	api.HandleFunc("/workspaces", editorHandler.CreateWorkspace).Methods("POST")
+	api.HandleFunc("/workspaces", editorHandler.ListWorkspaces).Methods("GET")
+	api.HandleFunc("/workspaces/{id}/members", editorHandler.ListWorkspaceMembers).Methods("GET")
+	api.HandleFunc("/workspaces/{id}/members/{user_id}", editorHandler.SetWorkspaceMember).Methods("PUT")
+	api.HandleFunc("/workspaces/{id}/members/{user_id}", editorHandler.RemoveWorkspaceMember).Methods("DELETE")
+	api.HandleFunc("/workspaces/{id}/sessions", editorHandler.ListWorkspaceSessions).Methods("GET")
+	api.HandleFunc("/workspaces/{id}/assets", editorHandler.ListWorkspaceAssets).Methods("GET")
+
+	// Version history — diff is registered before {version} so it isn't parsed as one
+	api.HandleFunc("/sessions/{id}/versions", editorHandler.ListVersions).Methods("GET")
+	api.HandleFunc("/sessions/{id}/versions/diff", editorHandler.DiffVersions).Methods("GET")
+	api.HandleFunc("/sessions/{id}/versions/{version:[0-9]+}", editorHandler.GetVersion).Methods("GET")
+	api.HandleFunc("/sessions/{id}/versions/{version:[0-9]+}/restore", editorHandler.RestoreVersion).Methods("POST")
+
+	// Undo / redo — server-side stacks, survive reloads
+	api.HandleFunc("/sessions/{id}/undo", editorHandler.GetUndoState).Methods("GET")
+	api.HandleFunc("/sessions/{id}/undo", editorHandler.Undo).Methods("POST")
+	api.HandleFunc("/sessions/{id}/redo", editorHandler.Redo).Methods("POST")
+
+	// Live session events — SSE stream (save, export progress, Repurposer updates)
+	api.HandleFunc("/sessions/{id}/events", editorHandler.SessionEvents).Methods("GET").Name(routeSessionEvents)
+	// Live collaborative editing — WebSocket (ops + presence)
+	api.HandleFunc("/sessions/{id}/live", editorHandler.SessionLive).Methods("GET").Name(routeSessionLive)
+
+	// File upload (existing) — streams through the API; prefer direct uploads
 	api.HandleFunc("/upload", editorHandler.UploadFile).Methods("POST")
+	// Direct uploads — presigned PUT straight to storage, then complete
+	api.HandleFunc("/uploads", editorHandler.CreateUpload).Methods("POST")
+	api.HandleFunc("/uploads/{upload_id}/complete", editorHandler.CompleteUpload).Methods("POST")
+	// Resumable uploads — tus 1.0 (creation, termination, expiration)
+	api.HandleFunc("/tus", editorHandler.TusCreate).Methods("POST")
+	api.HandleFunc("/tus/{upload_id}", editorHandler.TusHead).Methods("HEAD")
+	api.HandleFunc("/tus/{upload_id}", editorHandler.TusPatch).Methods("PATCH")
+	api.HandleFunc("/tus/{upload_id}", editorHandler.TusDelete).Methods("DELETE")
 
 	// Clip-to-editor session (existing endpoint, enhanced with source context)
-	api.HandleFunc("/sessions/from-clip", editorHandler.CreateSessionFromClip).Methods("POST")
+	api.HandleFunc("/sessions/from-clip", editorHandler.CreateSessionFromClip).Methods("POST").Name(routeSessionFromClip)
 
-	// Export to Content Hub (Phase 2 — stub for now)
+	// Export — enqueues a render job for cmd/worker (202 + job_id)
 	api.HandleFunc("/sessions/{id}/export", editorHandler.ExportSession).Methods("POST")
+	api.HandleFunc("/exports/{job_id}", editorHandler.GetExport).Methods("GET")
+	api.HandleFunc("/exports/{job_id}", editorHandler.CancelExport).Methods("DELETE")
 	// Highlight reel creation (Phase 2)
-	api.HandleFunc("/highlight/create", editorHandler.CreateHighlightSession).Methods("POST")
+	api.HandleFunc("/highlight/create", editorHandler.CreateHighlightSession).Methods("POST").Name(routeHighlightCreate)
 
 	// Serve local uploads — in production, S3 serves files directly (this route unused)
 	r.PathPrefix("/uploads/").Handler(
@@ -128,12 +323,19 @@ func main() {
 	if allowedOrigins == "" {
 		allowedOrigins = "http://localhost:5173"
 	}
+	// CORS doesn't cover WebSockets — the live socket checks Origin itself
+	editorHandler.AllowedOrigins = []string{allowedOrigins}
 
 	cors := handlers.CORS(
 		handlers.AllowedOrigins([]string{allowedOrigins}),
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
 		// X-User-ID: will be injected by API gateway in production
-		handlers.AllowedHeaders([]string{"Content-Type", "X-User-ID", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "X-User-ID", "Authorization", "If-Match", "X-Workspace-ID", "X-Share-Password",
+			"Tus-Resumable", "Upload-Length", "Upload-Metadata", "Upload-Offset"}),
+		// ETag carries the session version for optimistic concurrency; the
+		// rest is what tus clients read
+		handlers.ExposedHeaders([]string{"ETag",
+			"Location", "Tus-Resumable", "Tus-Version", "Upload-Offset", "Upload-Length", "Upload-Expires"}),
 	)
 
 	// ── HTTP Server with timeouts ──────────────────────────────────────────────
@@ -155,6 +357,14 @@ func main() {
 	// ── Graceful Shutdown ──────────────────────────────────────────────────────
 	// When the parent product's infra sends SIGTERM (e.g., during deploy/scale-down),
 	// we finish in-flight requests before exiting — no requests dropped mid-save.
+	// SSE streams never finish on their own: closing the broker ends them so
+	// Shutdown isn't stuck waiting the full 30s. Live WebSockets are hijacked
+	// (Shutdown doesn't wait for them) — the same close sends them "going away".
+	if broker != nil {
+		go broker.Run(context.Background())
+		srv.RegisterOnShutdown(broker.Close)
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -176,3 +386,65 @@ func main() {
 	}
 	log.Println("Server stopped cleanly")
 }
+
+// newAuthMiddleware builds request authentication from env:
+//
+//	JWT_SECRET             HS256 shared secret
+//	JWT_JWKS               RS256 key set — file path or https:// URL
+//	JWT_ISSUER / JWT_AUDIENCE  required iss / aud when set
+//	JWT_ALLOW_MISSING_EXP  "true" → accept tokens without exp (default: rejected)
+//	JWT_USER_CLAIM         claim holding the user UUID (default "sub")
+//	JWT_WORKSPACE_CLAIM    claim holding the workspace UUID (default "workspace_id")
+//	AUTH_TRUSTED_GATEWAY   "true" → also accept X-User-ID from the gateway
+//	SERVICE_SECRET_REPURPOSER / SERVICE_SECRET_CONTENT_HUB
+//	                       HMAC secrets for signed service-to-service calls
+//
+// At least one user method must be configured; there is no anonymous mode.
+func newAuthMiddleware(db *sql.DB) (*auth.Middleware, error) {
+	m := &auth.Middleware{
+		TrustGateway: os.Getenv("AUTH_TRUSTED_GATEWAY") == "true",
+		UserClaim:    os.Getenv("JWT_USER_CLAIM"),
+
+		WorkspaceClaim: os.Getenv("JWT_WORKSPACE_CLAIM"),
+	}
+
+	verifier := &auth.Verifier{
+		HMACSecret: []byte(os.Getenv("JWT_SECRET")),
+		Issuer:     os.Getenv("JWT_ISSUER"),
+		Audience:   os.Getenv("JWT_AUDIENCE"),
+		Leeway:     30 * time.Second,
+
+		AllowMissingExp: os.Getenv("JWT_ALLOW_MISSING_EXP") == "true",
+	}
+	if source := os.Getenv("JWT_JWKS"); source != "" {
+		jwks, err := auth.NewJWKS(source)
+		if err != nil {
+			return nil, err
+		}
+		verifier.Keys = jwks
+	}
+	if len(verifier.HMACSecret) > 0 || verifier.Keys != nil {
+		m.Verifier = verifier
+	}
+
+	secrets := map[string][]byte{}
+	for _, service := range auth.KnownServices {
+		if secret := os.Getenv("SERVICE_SECRET_" + strings.ToUpper(service)); secret != "" {
+			secrets[service] = []byte(secret)
+		}
+	}
+	if len(secrets) > 0 {
+		m.Services = &auth.ServiceAuth{
+			Secrets: secrets,
+			Nonces:  &auth.PostgresNonceStore{DB: db},
+		}
+	}
+
+	if m.Verifier == nil && !m.TrustGateway {
+		return nil, fmt.Errorf("set JWT_SECRET and/or JWT_JWKS, or AUTH_TRUSTED_GATEWAY=true behind a gateway")
+	}
+	if m.TrustGateway {
+		log.Println("Auth: trusting X-User-ID — the gateway MUST strip it from client requests")
+	}
+	return m, nil
+}
@@ -0,0 +1,153 @@
+// cmd/worker/main.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/render"
+	"editor-backend/internal/service"
+	"editor-backend/internal/storage"
+	"editor-backend/internal/validation"
+	"editor-backend/internal/worker"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+// The render worker — runs next to the API, shares its database and storage.
+// Scale it independently: API pods enqueue, worker pods render.
+func main() {
+	// Load .env in dev only — production injects env vars through infra (K8s secrets, etc.)
+	if os.Getenv("APP_ENV") != "production" {
+		godotenv.Load()
+	}
+
+	// ── Database ──────────────────────────────────────────────────────────────
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		log.Fatal("Failed to open DB:", err)
+	}
+	defer db.Close()
+
+	// Small pool — each render only needs a handful of short queries
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	pingCtx, pingCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer pingCancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		log.Fatal("Database ping failed:", err)
+	}
+
+	// ── Storage — same selection logic as the API ─────────────────────────────
+	var fileStorage storage.Storage
+	if os.Getenv("STORAGE_TYPE") == "s3" {
+		s3Storage, err := storage.NewS3Storage(storage.S3ConfigFromEnv())
+		if err != nil {
+			log.Fatal("S3 storage misconfigured: ", err)
+		}
+		fileStorage = s3Storage
+		validation.AllowMediaHosts(s3Storage.FilesURL())
+		log.Println("Using S3 storage, bucket", s3Storage.Bucket)
+	} else {
+		uploadDir := os.Getenv("UPLOAD_DIR")
+		if uploadDir == "" {
+			uploadDir = "./uploads"
+		}
+		localStorage := storage.NewLocalStorage(uploadDir, os.Getenv("BASE_URL"))
+		signingKey, err := storage.LocalSigningKeyFromEnv()
+		if err != nil {
+			log.Fatal("Local storage misconfigured: ", err)
+		}
+		if signingKey != nil {
+			localStorage.SigningKey = signingKey
+		}
+		fileStorage = localStorage
+		log.Println("Using local storage at", uploadDir)
+	}
+
+	// Clip sources may only point at our own media — see validation.ValidateMediaSrc
+	validation.AllowMediaHosts(os.Getenv("BASE_URL"), os.Getenv("CLOUDFRONT_DOMAIN"))
+	validation.AllowMediaHosts(strings.Split(os.Getenv("MEDIA_ALLOWED_HOSTS"), ",")...)
+
+	// ── Services ──────────────────────────────────────────────────────────────
+	// Progress and status changes reach browsers through the API pods' SSE streams
+	eventPublisher := &events.Publisher{DB: db}
+
+	sessionService := &service.SessionService{DB: db, Events: eventPublisher, ApprovalRole: os.Getenv("EDITOR_APPROVAL_ROLE")}
+	jobService := &service.ExportJobService{
+		DB:           db,
+		Events:       eventPublisher,
+		MaxAttempts:  envInt("EXPORT_MAX_ATTEMPTS", 3),
+		RetryBackoff: envDuration("EXPORT_RETRY_BACKOFF", 30*time.Second),
+		// Must match the API's setting
+		RequireApproval: os.Getenv("EXPORT_REQUIRE_APPROVAL") == "true",
+	}
+	renderer := render.NewRenderer(os.Getenv("FFMPEG_PATH"), os.Getenv("FFPROBE_PATH"))
+	renderer.BaseURL = os.Getenv("BASE_URL")
+	exportService := &service.ExportService{
+		Sessions: sessionService,
+		Renderer: renderer,
+		Storage:  fileStorage,
+	}
+
+	// Worker ID shows up in export_jobs.leased_by — hostname is the pod name in K8s
+	workerID := os.Getenv("WORKER_ID")
+	if workerID == "" {
+		host, _ := os.Hostname()
+		workerID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	w := &worker.Worker{
+		ID:            workerID,
+		Jobs:          jobService,
+		Sessions:      sessionService,
+		Exporter:      exportService,
+		Concurrency:   envInt("WORKER_CONCURRENCY", 1),
+		Lease:         envDuration("EXPORT_LEASE", 60*time.Second),
+		PollInterval:  envDuration("WORKER_POLL_INTERVAL", 2*time.Second),
+		RenderTimeout: envDuration("EXPORT_TIMEOUT", 10*time.Minute),
+		// Abandoned direct / resumable uploads and their stored chunks
+		Uploads: &service.UploadService{DB: db, Storage: fileStorage},
+	}
+
+	// ── Graceful Shutdown ──────────────────────────────────────────────────────
+	// SIGTERM stops claiming, cancels in-flight renders and releases their jobs
+	// back to the queue so another worker picks them up immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Export worker %s running (concurrency=%d)", w.ID, w.Concurrency)
+	w.Run(ctx)
+	log.Println("Worker stopped cleanly")
+}
+
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
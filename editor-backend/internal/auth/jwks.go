@@ -0,0 +1,162 @@
+// internal/auth/jwks.go
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKS serves RS256 public keys from a JSON Web Key Set at a file path or an
+// https:// URL. Keys are cached for TTL; an unknown kid (the IdP rotated
+// keys) triggers an early refetch, at most once per MinRefresh.
+type JWKS struct {
+	Source     string // file path or http(s) URL
+	TTL        time.Duration
+	MinRefresh time.Duration
+	Client     *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKS loads the key set once so a bad path / URL fails at startup.
+func NewJWKS(source string) (*JWKS, error) {
+	j := &JWKS{
+		Source:     source,
+		TTL:        time.Hour,
+		MinRefresh: time.Minute,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := j.refresh(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// PublicKey returns the RSA key for kid. An empty kid matches when the set
+// holds exactly one key.
+func (j *JWKS) PublicKey(kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if time.Since(j.fetchedAt) > j.TTL {
+		// Stale — keep serving the old keys if the refresh fails
+		j.refreshLocked()
+	}
+
+	if key := j.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	if time.Since(j.fetchedAt) >= j.MinRefresh {
+		if err := j.refreshLocked(); err != nil {
+			return nil, err
+		}
+		if key := j.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+func (j *JWKS) lookup(kid string) *rsa.PublicKey {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key
+		}
+	}
+	return j.keys[kid]
+}
+
+func (j *JWKS) refresh() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.refreshLocked()
+}
+
+func (j *JWKS) refreshLocked() error {
+	raw, err := j.read()
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS from %s: %w", j.Source, err)
+	}
+
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return fmt.Errorf("invalid JWKS from %s: %w", j.Source, err)
+	}
+
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+func (j *JWKS) read() ([]byte, error) {
+	if !strings.HasPrefix(j.Source, "http://") && !strings.HasPrefix(j.Source, "https://") {
+		return os.ReadFile(j.Source)
+	}
+
+	resp, err := j.Client.Get(j.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// parseJWKS keeps RSA signing keys and skips everything else (EC keys,
+// encryption keys) rather than failing the whole set.
+func parseJWKS(raw []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: bad modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q: bad exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RS256 signing keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,183 @@
+// internal/auth/jwt.go
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrBadSignature     = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrMissingExpiry    = errors.New("token has no exp claim")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrBadIssuer        = errors.New("unexpected token issuer")
+	ErrBadAudience      = errors.New("unexpected token audience")
+	ErrUnknownKey       = errors.New("no key for token kid")
+)
+
+// Claims is the decoded JWT payload. Registered claims are read through the
+// helpers; anything else (roles, org, email) stays available to handlers.
+type Claims map[string]interface{}
+
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	n, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// audiences handles "aud" as either a string or an array of strings.
+func (c Claims) audiences() []string {
+	switch v := c["aud"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// KeySource resolves RS256 public keys by "kid" — see JWKS.
+type KeySource interface {
+	PublicKey(kid string) (*rsa.PublicKey, error)
+}
+
+// Verifier checks HS256 tokens against a shared secret and RS256 tokens
+// against a KeySource. Either may be left unset to disable that algorithm.
+type Verifier struct {
+	HMACSecret []byte
+	Keys       KeySource
+
+	Issuer   string        // required "iss" when set
+	Audience string        // must appear in "aud" when set
+	Leeway   time.Duration // clock skew allowed on exp / nbf
+
+	// AllowMissingExp accepts tokens without "exp". Off by default — a
+	// token that never expires can't be taken back once leaked.
+	AllowMissingExp bool
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify parses a compact JWS, checks the signature and the time / issuer /
+// audience claims, and returns the payload.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	// The algorithm is chosen by what the server has configured, never by
+	// the token alone — "none" and HS/RS confusion are rejected here
+	switch h.Alg {
+	case "HS256":
+		if len(v.HMACSecret) == 0 {
+			return nil, ErrUnsupportedAlg
+		}
+		mac := hmac.New(sha256.New, v.HMACSecret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, ErrBadSignature
+		}
+	case "RS256":
+		if v.Keys == nil {
+			return nil, ErrUnsupportedAlg
+		}
+		key, err := v.Keys.PublicKey(h.Kid)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, ErrBadSignature
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, h.Alg)
+	}
+
+	claims := Claims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	now := time.Now()
+	exp, ok := claims.time("exp")
+	if !ok && !v.AllowMissingExp {
+		return nil, ErrMissingExpiry
+	}
+	if ok && now.After(exp.Add(v.Leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(v.Leeway).Before(nbf) {
+		return nil, ErrTokenNotYetValid
+	}
+	if v.Issuer != "" && claims.String("iss") != v.Issuer {
+		return nil, ErrBadIssuer
+	}
+	if v.Audience != "" && !contains(claims.audiences(), v.Audience) {
+		return nil, ErrBadAudience
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(seg string, dst interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	return dec.Decode(dst)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
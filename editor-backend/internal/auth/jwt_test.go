@@ -0,0 +1,111 @@
+// internal/auth/jwt_test.go
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testSecret = []byte("test-secret")
+
+// hs256 signs claims with testSecret.
+func hs256(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	enc := base64.RawURLEncoding
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyExpiry(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name       string
+		claims     map[string]interface{}
+		allowNoExp bool
+		want       error
+	}{
+		{"valid", map[string]interface{}{"sub": "u", "exp": now.Add(time.Hour).Unix()}, false, nil},
+		{"expired", map[string]interface{}{"sub": "u", "exp": now.Add(-time.Hour).Unix()}, false, ErrTokenExpired},
+		{"no exp", map[string]interface{}{"sub": "u"}, false, ErrMissingExpiry},
+		{"exp not a number", map[string]interface{}{"sub": "u", "exp": "tomorrow"}, false, ErrMissingExpiry},
+		{"no exp, allowed", map[string]interface{}{"sub": "u"}, true, nil},
+		{"expired, missing allowed", map[string]interface{}{"sub": "u", "exp": now.Add(-time.Hour).Unix()}, true, ErrTokenExpired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &Verifier{HMACSecret: testSecret, AllowMissingExp: tc.allowNoExp}
+			_, err := v.Verify(hs256(t, tc.claims))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// ?access_token= only works where QueryToken says so — the streaming routes
+func TestMiddlewareQueryToken(t *testing.T) {
+	userID := uuid.New()
+	token := hs256(t, map[string]interface{}{"sub": userID.String(), "exp": time.Now().Add(time.Hour).Unix()})
+
+	m := &Middleware{
+		Verifier: &Verifier{HMACSecret: testSecret},
+		QueryToken: func(r *http.Request) bool {
+			return r.URL.Path == "/sessions/x/events"
+		},
+	}
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := FromContext(r.Context()); !ok || id.UserID != userID {
+			t.Error("identity not attached")
+		}
+	}))
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/sessions/x/events", http.StatusOK},
+		{http.MethodGet, "/sessions/x", http.StatusUnauthorized},
+		{http.MethodPost, "/sessions/x/events", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(tc.method, tc.path+"?access_token="+token, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: status = %d, want %d", tc.method, tc.path, w.Code, tc.want)
+		}
+	}
+
+	// Without QueryToken the header is the only way
+	m.QueryToken = nil
+	r := httptest.NewRequest(http.MethodGet, "/sessions/x/events?access_token="+token, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("nil QueryToken: status = %d, want 401", w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/sessions/x", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Authorization header: status = %d, want 200", w.Code)
+	}
+}
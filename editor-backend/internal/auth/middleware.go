@@ -0,0 +1,235 @@
+// internal/auth/middleware.go
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoIdentity   = errors.New("request is not authenticated")
+	ErrServiceRoute = errors.New("service credentials are not accepted on this route")
+)
+
+// Identity sources
+const (
+	MethodJWT     = "jwt"
+	MethodGateway = "gateway" // X-User-ID from a trusted gateway
+	MethodService = "service" // signed request from Repurposer / Content Hub
+)
+
+// Identity is who the request acts as. Handlers read it with FromContext.
+type Identity struct {
+	UserID  uuid.UUID
+	Subject string
+	Claims  Claims // nil for gateway / service identities
+	Method  string
+
+	// Service is the calling module for MethodService ("repurposer",
+	// "content_hub"); UserID is then the user it acts on behalf of.
+	Service string
+
+	// WorkspaceID is the active workspace (uuid.Nil = personal space). It is
+	// only a claim — membership is checked by the service layer.
+	WorkspaceID uuid.UUID
+}
+
+type contextKey struct{}
+
+// WithIdentity stores id in ctx.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the identity the middleware attached, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// Middleware authenticates every request it wraps.
+//
+//   - Authorization: Bearer <jwt> is always accepted when Verifier is set.
+//   - X-User-ID is honored ONLY when TrustGateway is true — i.e. the service
+//     sits behind a gateway that strips client-supplied X-User-ID and injects
+//     its own. Otherwise the header is ignored.
+//   - X-Service-ID + signature headers identify another module (see
+//     ServiceAuth) acting on behalf of X-On-Behalf-Of — only on requests
+//     ServiceRoute accepts.
+//
+// Requests with none of these get 401. There is no anonymous fallback.
+//
+// Browsers cannot set headers on EventSource / WebSocket, so requests that
+// QueryToken accepts may pass the JWT as ?access_token= instead. Anywhere
+// else a token in the URL would end up in proxy logs and Referer headers.
+//
+// The active workspace comes from the WorkspaceClaim token claim, or the
+// X-Workspace-ID header when the token has none. A header contradicting the
+// claim is rejected.
+type Middleware struct {
+	Verifier     *Verifier
+	TrustGateway bool
+	Services     *ServiceAuth
+
+	// UserClaim names the claim carrying the user UUID (default "sub").
+	UserClaim string
+
+	// WorkspaceClaim names the claim carrying the workspace UUID
+	// (default "workspace_id").
+	WorkspaceClaim string
+
+	// QueryToken reports whether r may carry ?access_token= (the streaming
+	// routes). nil → never.
+	QueryToken func(r *http.Request) bool
+
+	// ServiceRoute reports whether r may be a signed service request (the
+	// routes Repurposer and Content Hub call). nil → never.
+	ServiceRoute func(r *http.Request) bool
+}
+
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := m.authenticate(r)
+		if err == nil {
+			err = m.resolveWorkspace(r, id)
+		}
+		if err != nil {
+			unauthorized(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), id)))
+	})
+}
+
+func (m *Middleware) authenticate(r *http.Request) (*Identity, error) {
+	if r.Header.Get("X-Service-ID") != "" {
+		return m.authenticateService(r)
+	}
+
+	if token := m.bearerToken(r); token != "" {
+		if m.Verifier == nil {
+			return nil, errors.New("bearer tokens are not accepted — JWT verification is not configured")
+		}
+		claims, err := m.Verifier.Verify(token)
+		if err != nil {
+			return nil, err
+		}
+		return m.identityFromClaims(claims)
+	}
+
+	if m.TrustGateway {
+		if header := r.Header.Get("X-User-ID"); header != "" {
+			userID, err := uuid.Parse(header)
+			if err != nil {
+				return nil, errors.New("invalid X-User-ID header")
+			}
+			return &Identity{UserID: userID, Subject: header, Method: MethodGateway}, nil
+		}
+	}
+
+	return nil, ErrNoIdentity
+}
+
+func (m *Middleware) authenticateService(r *http.Request) (*Identity, error) {
+	if m.Services == nil {
+		return nil, errors.New("service credentials are not accepted — no service secrets configured")
+	}
+	if m.ServiceRoute == nil || !m.ServiceRoute(r) {
+		return nil, ErrServiceRoute
+	}
+
+	service, err := m.Services.Verify(r)
+	if err != nil {
+		return nil, err
+	}
+
+	onBehalfOf, err := uuid.Parse(r.Header.Get("X-On-Behalf-Of"))
+	if err != nil {
+		return nil, ErrMissingOnBehalf
+	}
+
+	return &Identity{
+		UserID:  onBehalfOf,
+		Subject: "service:" + service,
+		Method:  MethodService,
+		Service: service,
+	}, nil
+}
+
+func (m *Middleware) identityFromClaims(claims Claims) (*Identity, error) {
+	claim := m.UserClaim
+	if claim == "" {
+		claim = "sub"
+	}
+
+	raw := claims.String(claim)
+	userID, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, errors.New("token claim " + claim + " is not a user UUID")
+	}
+
+	return &Identity{
+		UserID:  userID,
+		Subject: claims.String("sub"),
+		Claims:  claims,
+		Method:  MethodJWT,
+	}, nil
+}
+
+// resolveWorkspace sets id.WorkspaceID from the token claim or header.
+func (m *Middleware) resolveWorkspace(r *http.Request, id *Identity) error {
+	claim := m.WorkspaceClaim
+	if claim == "" {
+		claim = "workspace_id"
+	}
+
+	fromClaim := id.Claims.String(claim)
+	header := r.Header.Get("X-Workspace-ID")
+
+	raw := fromClaim
+	if raw == "" {
+		raw = header
+	} else if header != "" && !strings.EqualFold(header, fromClaim) {
+		return errors.New("X-Workspace-ID does not match the token's workspace")
+	}
+	if raw == "" {
+		return nil
+	}
+
+	workspaceID, err := uuid.Parse(raw)
+	if err != nil {
+		return errors.New("workspace must be a UUID")
+	}
+	id.WorkspaceID = workspaceID
+	return nil
+}
+
+// bearerToken reads "Authorization: Bearer <token>", or ?access_token= on
+// the GETs QueryToken allows.
+func (m *Middleware) bearerToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		scheme, token, ok := strings.Cut(h, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if r.Method == http.MethodGet && m.QueryToken != nil && m.QueryToken(r) {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	log.Println("auth:", err)
+	w.Header().Set("WWW-Authenticate", `Bearer realm="unified-editor"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+}
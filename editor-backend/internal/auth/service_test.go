@@ -0,0 +1,200 @@
+// internal/auth/service_test.go
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var repurposerSecret = []byte("repurposer-secret-0123456789abcdef")
+
+// memoryNonces is an in-process NonceStore.
+type memoryNonces struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (m *memoryNonces) Remember(_ context.Context, service, nonce string, _ time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.seen == nil {
+		m.seen = map[string]bool{}
+	}
+	key := service + "/" + nonce
+	if m.seen[key] {
+		return false, nil
+	}
+	m.seen[key] = true
+	return true, nil
+}
+
+func newServiceAuth() *ServiceAuth {
+	return &ServiceAuth{
+		Secrets: map[string][]byte{ServiceRepurposer: repurposerSecret},
+		Nonces:  &memoryNonces{},
+	}
+}
+
+// signedRequest builds a POST signed by repurposer with SignRequest.
+func signedRequest(t *testing.T, path, onBehalfOf string, body []byte) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	if err := SignRequest(r, ServiceRepurposer, repurposerSecret, onBehalfOf, body); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// resign replaces the timestamp and signs the request again.
+func resign(r *http.Request, sent time.Time, body []byte) {
+	ts := strconv.FormatInt(sent.Unix(), 10)
+	r.Header.Set("X-Service-Timestamp", ts)
+	r.Header.Set("X-Service-Signature", hex.EncodeToString(signature(
+		repurposerSecret, r.Method, r.URL.RequestURI(), ts,
+		r.Header.Get("X-Service-Nonce"), r.Header.Get("X-On-Behalf-Of"), body,
+	)))
+}
+
+func TestServiceAuthVerify(t *testing.T) {
+	user := uuid.New().String()
+	body := []byte(`{"clip_url":"/uploads/a.mp4","duration":10}`)
+
+	cases := []struct {
+		name   string
+		tamper func(r *http.Request)
+		want   error
+	}{
+		{"good signature", func(r *http.Request) {}, nil},
+		{"bad signature", func(r *http.Request) {
+			r.Header.Set("X-Service-Signature", hex.EncodeToString(signature(
+				[]byte("wrong-secret"), r.Method, r.URL.RequestURI(), r.Header.Get("X-Service-Timestamp"),
+				r.Header.Get("X-Service-Nonce"), user, body,
+			)))
+		}, ErrBadServiceSig},
+		{"signature not hex", func(r *http.Request) {
+			r.Header.Set("X-Service-Signature", "not-hex")
+		}, ErrBadServiceSig},
+		{"unknown service", func(r *http.Request) {
+			r.Header.Set("X-Service-ID", "stv")
+		}, ErrUnknownService},
+		{"timestamp too old", func(r *http.Request) {
+			resign(r, time.Now().Add(-6*time.Minute), body)
+		}, ErrStaleRequest},
+		{"timestamp in the future", func(r *http.Request) {
+			resign(r, time.Now().Add(6*time.Minute), body)
+		}, ErrStaleRequest},
+		{"timestamp within skew", func(r *http.Request) {
+			resign(r, time.Now().Add(-4*time.Minute), body)
+		}, nil},
+		{"timestamp missing", func(r *http.Request) {
+			r.Header.Del("X-Service-Timestamp")
+		}, ErrStaleRequest},
+		{"body tampered", func(r *http.Request) {
+			r.Body = io.NopCloser(bytes.NewReader([]byte(`{"clip_url":"/uploads/b.mp4","duration":10}`)))
+		}, ErrBadServiceSig},
+		{"on-behalf-of tampered", func(r *http.Request) {
+			r.Header.Set("X-On-Behalf-Of", uuid.New().String())
+		}, ErrBadServiceSig},
+		{"path tampered", func(r *http.Request) {
+			r.URL.Path = "/api/v1/highlight/create"
+		}, ErrBadServiceSig},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := signedRequest(t, "/api/v1/sessions/from-clip", user, body)
+			tc.tamper(r)
+
+			service, err := newServiceAuth().Verify(r)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+			if err == nil {
+				if service != ServiceRepurposer {
+					t.Errorf("service = %q", service)
+				}
+				// Handlers can still read the body
+				if got, _ := io.ReadAll(r.Body); !bytes.Equal(got, body) {
+					t.Errorf("body after Verify = %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceAuthNonceReplay(t *testing.T) {
+	s := newServiceAuth()
+	user := uuid.New().String()
+	body := []byte(`{}`)
+
+	r := signedRequest(t, "/api/v1/sessions/from-clip", user, body)
+	nonce := r.Header.Get("X-Service-Nonce")
+
+	// A forged request carrying the nonce must not burn it
+	forged := signedRequest(t, "/api/v1/sessions/from-clip", user, body)
+	forged.Header.Set("X-Service-Nonce", nonce)
+	if _, err := s.Verify(forged); !errors.Is(err, ErrBadServiceSig) {
+		t.Fatalf("forged: err = %v, want ErrBadServiceSig", err)
+	}
+
+	if _, err := s.Verify(r); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+
+	replay := signedRequest(t, "/api/v1/sessions/from-clip", user, body)
+	replay.Header = r.Header.Clone()
+	if _, err := s.Verify(replay); !errors.Is(err, ErrReplayedRequest) {
+		t.Fatalf("replay: err = %v, want ErrReplayedRequest", err)
+	}
+}
+
+// Signed service requests only authenticate on the routes ServiceRoute allows.
+func TestMiddlewareServiceRoute(t *testing.T) {
+	user := uuid.New()
+	m := &Middleware{
+		Services: newServiceAuth(),
+		ServiceRoute: func(r *http.Request) bool {
+			return r.URL.Path == "/api/v1/sessions/from-clip"
+		},
+	}
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := FromContext(r.Context())
+		if !ok || id.UserID != user || id.Method != MethodService || id.Service != ServiceRepurposer {
+			t.Errorf("identity = %+v", id)
+		}
+	}))
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/sessions/from-clip", http.StatusOK},
+		{"/api/v1/sessions/" + uuid.NewString(), http.StatusUnauthorized},
+		{"/api/v1/sessions/" + uuid.NewString() + "/transfer", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, signedRequest(t, tc.path, user.String(), []byte(`{}`)))
+		if w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.path, w.Code, tc.want)
+		}
+	}
+
+	// Without ServiceRoute no route takes service credentials
+	m.ServiceRoute = nil
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, signedRequest(t, "/api/v1/sessions/from-clip", user.String(), []byte(`{}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("nil ServiceRoute: status = %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,296 @@
+// internal/events/events.go
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Channel is the single Postgres NOTIFY channel all editor events travel on.
+// Every API pod LISTENs on it and fans events out to its own SSE clients, so
+// a save on pod A or a progress tick from a worker reaches a browser on pod B.
+const Channel = "editor_events"
+
+// Event types pushed to GET /sessions/{id}/events
+const (
+	TypeSessionSaved     = "session.saved"     // timeline saved — data: { version }
+	TypeRepurposerUpdate = "repurposer.update" // Repurposer / Content Hub rewrote the timeline
+	TypeExportStatus     = "export.status"     // export_status changed — data: { status, export_url }
+	TypeExportProgress   = "export.progress"   // render tick — data: { job_id, percent, eta_seconds }
+	TypeMembersChanged   = "members.changed"   // someone was added, removed, re-roled or made owner
+	TypeCommentsChanged  = "comments.changed"  // data: { comment_id, action, mentions }
+	TypeStatusChanged    = "status.changed"    // approval workflow — data: { from, to, version }
+	TypeOperation        = "op.applied"        // collaborative edit — data: { version, user_id, client_op_id, op }
+	TypePresence         = "presence"          // data: { conn_id, user_id, action, playhead, selected_clip_id }
+	TypeResync           = "resync"            // events may have been missed — refetch the session
+	TypeAccessRevoked    = "access.revoked"    // the caller lost access — last event before the stream closes
+)
+
+// Event is one message for the clients watching a session.
+// Data must stay small: NOTIFY payloads are capped at 8000 bytes, so events
+// carry versions and IDs — never whole timelines.
+type Event struct {
+	Type      string          `json:"type"`
+	SessionID uuid.UUID       `json:"session_id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	At        time.Time       `json:"at"`
+}
+
+// ── Publisher ─────────────────────────────────────────────────────────────────
+
+// Publisher sends events through pg_notify. A nil *Publisher is valid and
+// drops everything — services work unchanged in tools that don't need events.
+type Publisher struct {
+	DB *sql.DB
+}
+
+// Publish is best-effort: a lost notification must never fail the save or
+// render that triggered it, so errors are logged, not returned.
+func (p *Publisher) Publish(sessionID uuid.UUID, eventType string, data interface{}) {
+	if p == nil || p.DB == nil {
+		return
+	}
+
+	payload, err := encode(sessionID, eventType, data)
+	if err != nil {
+		log.Println("events: marshal failed:", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := p.DB.ExecContext(ctx, `SELECT pg_notify($1, $2)`, Channel, payload); err != nil {
+		log.Printf("events: publish %s for session %s failed: %v", eventType, sessionID, err)
+	}
+}
+
+// PublishTx sends the event as part of tx: it is delivered only if tx
+// commits, and events from different transactions arrive in commit order.
+// Unlike Publish, failures are returned — the event is part of the write.
+func (p *Publisher) PublishTx(ctx context.Context, tx *sql.Tx, sessionID uuid.UUID, eventType string, data interface{}) error {
+	if p == nil {
+		return nil
+	}
+
+	payload, err := encode(sessionID, eventType, data)
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxPayload {
+		return ErrPayloadTooLarge
+	}
+
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, Channel, payload)
+	return err
+}
+
+// MaxPayload is the largest event Postgres will carry (NOTIFY caps at 8000 bytes).
+const MaxPayload = 7999
+
+var ErrPayloadTooLarge = errors.New("events: payload over the 8000-byte NOTIFY limit")
+
+func encode(sessionID uuid.UUID, eventType string, data interface{}) (string, error) {
+	ev := Event{Type: eventType, SessionID: sessionID, At: time.Now().UTC()}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+		ev.Data = raw
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+// ── Broker ────────────────────────────────────────────────────────────────────
+
+// Broker holds one LISTEN connection per pod and routes notifications to the
+// in-process subscribers of each session.
+type Broker struct {
+	listener *pq.Listener
+
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[chan Event]struct{}
+	closed bool
+}
+
+// Subscriber buffer — a client this far behind has its backlog replaced by
+// a single resync rather than stalling every other session.
+const subscriberBuffer = 32
+
+func NewBroker(dbURL string) (*Broker, error) {
+	listener := pq.NewListener(dbURL, 2*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("events: listener:", err)
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &Broker{
+		listener: listener,
+		subs:     make(map[uuid.UUID]map[chan Event]struct{}),
+	}, nil
+}
+
+// NewLocalBroker returns a broker with no LISTEN connection: events reach its
+// subscribers only through Deliver. For a single process without Postgres
+// notifications — tests and tools.
+func NewLocalBroker() *Broker {
+	return &Broker{subs: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// Deliver routes ev to the session's subscribers as if it had been notified.
+func (b *Broker) Deliver(ev Event) {
+	b.dispatch(ev)
+}
+
+// Run dispatches notifications until ctx is cancelled or Close is called.
+func (b *Broker) Run(ctx context.Context) {
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.Close()
+			return
+
+		case n, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			if n == nil {
+				// pq sends nil after re-establishing a dropped connection —
+				// anything published meanwhile is gone, tell clients to refetch
+				b.broadcastResync()
+				continue
+			}
+
+			var ev Event
+			if err := json.Unmarshal([]byte(n.Extra), &ev); err != nil {
+				log.Println("events: bad payload:", err)
+				continue
+			}
+			b.dispatch(ev)
+
+		case <-ping.C:
+			// Detects half-open connections the kernel hasn't noticed yet
+			go b.listener.Ping()
+		}
+	}
+}
+
+// Subscribe registers for one session's events. The returned channel is closed
+// when the broker shuts down; call unsubscribe when the client goes away.
+func (b *Broker) Subscribe(sessionID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if b.subs[sessionID] == nil {
+		b.subs[sessionID] = make(map[chan Event]struct{})
+	}
+	b.subs[sessionID][ch] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subs[sessionID][ch]; !ok {
+				return // already closed by Close
+			}
+			delete(b.subs[sessionID], ch)
+			if len(b.subs[sessionID]) == 0 {
+				delete(b.subs, sessionID)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Close stops listening and ends every open subscription — SSE handlers see
+// their channel close and return, which lets graceful shutdown complete.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	if b.listener != nil {
+		b.listener.Close()
+	}
+
+	for sessionID, chans := range b.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(b.subs, sessionID)
+	}
+}
+
+func (b *Broker) dispatch(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[ev.SessionID] {
+		select {
+		case ch <- ev:
+		default:
+			log.Printf("events: subscriber for session %s is full, sending resync instead of %s", ev.SessionID, ev.Type)
+			resync(ch, ev.SessionID)
+		}
+	}
+}
+
+func (b *Broker) broadcastResync() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sessionID, chans := range b.subs {
+		for ch := range chans {
+			resync(ch, sessionID)
+		}
+	}
+}
+
+// resync replaces whatever ch still holds with one resync event: the client
+// refetches the session anyway, so the backlog is worthless and a dropped
+// event never goes unnoticed. Callers hold b.mu — every send happens under
+// it, so the drained buffer has room.
+func resync(ch chan Event, sessionID uuid.UUID) {
+drain:
+	for {
+		select {
+		case <-ch:
+		default:
+			break drain
+		}
+	}
+	ch <- Event{Type: TypeResync, SessionID: sessionID, At: time.Now().UTC()}
+}
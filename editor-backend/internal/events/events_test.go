@@ -0,0 +1,64 @@
+// internal/events/events_test.go
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newTestBroker() *Broker {
+	return NewLocalBroker()
+}
+
+// A subscriber that falls behind gets a resync in place of its backlog, and
+// the next events after it — nothing is lost without the client knowing.
+func TestDispatchFullSubscriberGetsResync(t *testing.T) {
+	b := newTestBroker()
+	sessionID := uuid.New()
+	slow, unsubscribe := b.Subscribe(sessionID)
+	defer unsubscribe()
+	fast, unsubscribeFast := b.Subscribe(sessionID)
+	defer unsubscribeFast()
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.dispatch(Event{Type: TypeSessionSaved, SessionID: sessionID})
+		if i < subscriberBuffer {
+			<-fast // keeps up
+		}
+	}
+	if ev := <-fast; ev.Type != TypeSessionSaved {
+		t.Fatalf("subscriber with room got %s, want %s", ev.Type, TypeSessionSaved)
+	}
+
+	if len(slow) != 1 {
+		t.Fatalf("slow subscriber holds %d events, want only the resync", len(slow))
+	}
+	if ev := <-slow; ev.Type != TypeResync || ev.SessionID != sessionID {
+		t.Fatalf("slow subscriber got %+v, want a resync for the session", ev)
+	}
+
+	b.dispatch(Event{Type: TypeExportStatus, SessionID: sessionID})
+	if ev := <-slow; ev.Type != TypeExportStatus {
+		t.Fatalf("after the resync got %s, want %s", ev.Type, TypeExportStatus)
+	}
+}
+
+func TestBroadcastResyncReachesFullSubscribers(t *testing.T) {
+	b := newTestBroker()
+	sessionID := uuid.New()
+	ch, unsubscribe := b.Subscribe(sessionID)
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer; i++ {
+		b.dispatch(Event{Type: TypePresence, SessionID: sessionID})
+	}
+	b.broadcastResync()
+
+	if len(ch) != 1 {
+		t.Fatalf("subscriber holds %d events, want only the resync", len(ch))
+	}
+	if ev := <-ch; ev.Type != TypeResync {
+		t.Fatalf("got %s, want resync", ev.Type)
+	}
+}
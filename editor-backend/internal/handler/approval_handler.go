@@ -0,0 +1,98 @@
+// internal/handler/approval_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"editor-backend/internal/service"
+)
+
+// TransitionSession moves a session through the approval workflow
+// (draft → in_review → changes_requested / approved → exported).
+//
+// POST /api/v1/sessions/{id}/transitions
+//
+//	{
+//	    "status":  "approved",
+//	    "note":    "good to go",   // optional, kept in the audit trail
+//	    "version": 7               // optional — reject if the timeline moved on
+//	}
+func (h *EditorHandler) TransitionSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Status  string `json:"status"`
+		Note    string `json:"note"`
+		Version int    `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	transition, err := h.Service.TransitionStatus(sessionID, userID, getWorkspaceID(r), req.Status, req.Note, req.Version)
+	if err != nil {
+		var conflict *service.VersionConflictError
+		if errors.As(err, &conflict) {
+			respondVersionConflict(w, conflict)
+			return
+		}
+		respondApprovalError(w, err, "failed to change session status")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, transition)
+}
+
+// ListTransitions returns the session's approval audit trail, newest first.
+//
+// GET /api/v1/sessions/{id}/transitions
+func (h *EditorHandler) ListTransitions(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	transitions, err := h.Service.ListTransitions(sessionID, userID, getWorkspaceID(r))
+	if err != nil {
+		respondApprovalError(w, err, "failed to list transitions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"transitions": transitions})
+}
+
+func respondApprovalError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrInvalidStatus:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrInvalidTransition, service.ErrApprovalRequired:
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Approval error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
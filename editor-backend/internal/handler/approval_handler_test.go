@@ -0,0 +1,226 @@
+// internal/handler/approval_handler_test.go
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+)
+
+func (s *roleSession) transition(user, body string) *httptest.ResponseRecorder {
+	return serve(s.h.TransitionSession, http.MethodPost, body, s.users[user], map[string]string{"id": s.sessionID.String()})
+}
+
+func (s *roleSession) export(user string) *httptest.ResponseRecorder {
+	return serve(s.h.ExportSession, http.MethodPost, "", s.users[user], map[string]string{"id": s.sessionID.String()})
+}
+
+// transitions reads the audit trail, newest first, as
+// "from>to by <user> @<version> <note>".
+func (s *roleSession) transitions(t *testing.T) []string {
+	t.Helper()
+	w := serve(s.h.ListTransitions, http.MethodGet, "", s.users[models.RoleViewer], map[string]string{"id": s.sessionID.String()})
+	if w.Code != http.StatusOK {
+		t.Fatalf("list transitions: status = %d (%s)", w.Code, w.Body)
+	}
+	var body struct {
+		Transitions []models.SessionTransition `json:"transitions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	names := map[uuid.UUID]string{}
+	for name, id := range s.users {
+		names[id] = name
+	}
+	lines := []string{}
+	for _, tr := range body.Transitions {
+		by := "system"
+		if tr.UserID != nil {
+			by = names[*tr.UserID]
+		}
+		lines = append(lines, fmt.Sprintf("%s>%s by %s @%d %s", tr.FromStatus, tr.ToStatus, by, tr.Version, tr.Note))
+	}
+	return lines
+}
+
+// Every move in the workflow, by every role: editors submit, withdraw and
+// reopen; only the approver (the owner unless ApprovalRole says otherwise)
+// approves or requests changes; nobody exports by hand.
+func TestTransitionRoles(t *testing.T) {
+	const (
+		owner, editor, commenter, viewer = models.RoleOwner, models.RoleEditor, models.RoleCommenter, models.RoleViewer
+		draft, inReview, changes         = models.SessionStatusDraft, models.SessionStatusInReview, models.SessionStatusChangesRequested
+		approved, exported               = models.SessionStatusApproved, models.SessionStatusExported
+	)
+	ok, bad, forbidden, conflict := http.StatusOK, http.StatusBadRequest, http.StatusForbidden, http.StatusConflict
+
+	cases := []struct {
+		from, to     string
+		approvalRole string         // SessionService.ApprovalRole
+		want         map[string]int // by role; the stranger gets 403 unless listed
+	}{
+		{draft, inReview, "", map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{inReview, draft, "", map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{inReview, approved, "", map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{inReview, changes, "", map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{changes, inReview, "", map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{approved, changes, "", map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{exported, draft, "", map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+
+		// ApprovalRole moves the approver's rung, not the editor's
+		{inReview, approved, editor, map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{approved, changes, commenter, map[string]int{owner: ok, editor: ok, commenter: ok, viewer: forbidden}},
+		{draft, inReview, commenter, map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+
+		// Moves the workflow doesn't have, whoever asks
+		{draft, approved, "", map[string]int{owner: conflict, editor: conflict, commenter: conflict, viewer: conflict}},
+		{approved, exported, "", map[string]int{owner: conflict, editor: conflict, commenter: conflict, viewer: conflict}},
+		{changes, approved, "", map[string]int{owner: conflict, editor: conflict, commenter: conflict, viewer: conflict}},
+		{draft, draft, "", map[string]int{owner: conflict, editor: conflict, commenter: conflict, viewer: conflict}},
+		// An unknown status is refused before anyone's access is looked at
+		{draft, "published", "", map[string]int{owner: bad, editor: bad, commenter: bad, viewer: bad, "stranger": bad}},
+	}
+	for _, tc := range cases {
+		want := map[string]int{"stranger": forbidden}
+		for user, code := range tc.want {
+			want[user] = code
+		}
+		for user, code := range want {
+			name := fmt.Sprintf("%s to %s as %s", tc.from, tc.to, user)
+			if tc.approvalRole != "" {
+				name += " (approver " + tc.approvalRole + ")"
+			}
+			t.Run(name, func(t *testing.T) {
+				s := newRoleSession()
+				s.h.Service.ApprovalRole = tc.approvalRole
+				s.db.setStatus(s.sessionID, tc.from)
+
+				w := s.transition(user, `{"status":"`+tc.to+`","note":"n"}`)
+				if w.Code != code {
+					t.Fatalf("status = %d (%s), want %d", w.Code, w.Body, code)
+				}
+				after := tc.from
+				if code == ok {
+					after = tc.to
+					if got := jsonField(t, w, "to_status"); got != tc.to {
+						t.Errorf("to_status = %q, want %q", got, tc.to)
+					}
+				}
+				if got := s.db.status(s.sessionID); got != after {
+					t.Errorf("session status = %q, want %q", got, after)
+				}
+			})
+		}
+	}
+}
+
+// A full review round, read back from the audit trail: the approval is
+// pinned to a version, editing an approved cut sends it back to review, and
+// a stale approval is refused with the current session.
+func TestTransitionAuditTrail(t *testing.T) {
+	s := newRoleSession()
+	owner, editor := models.RoleOwner, models.RoleEditor
+
+	for _, step := range []struct{ user, body string }{
+		{editor, `{"status":"in_review","note":"first cut"}`},
+		{owner, `{"status":"changes_requested","note":"tighten the intro"}`},
+		{editor, `{"status":"in_review"}`},
+		{owner, `{"status":"approved","version":1}`},
+	} {
+		if w := s.transition(step.user, step.body); w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status = %d (%s)", step.user, step.body, w.Code, w.Body)
+		}
+	}
+
+	// Saving the approved cut is a new version nobody signed off on
+	if w := serve(s.h.SaveSession, http.MethodPut, `{"timeline":`+testTimeline+`}`, s.users[editor],
+		map[string]string{"id": s.sessionID.String()}); w.Code != http.StatusOK {
+		t.Fatalf("save: status = %d (%s)", w.Code, w.Body)
+	}
+	if got := s.db.status(s.sessionID); got != models.SessionStatusInReview {
+		t.Fatalf("status after saving an approved session = %q, want in_review", got)
+	}
+
+	// The owner's approval of version 1 no longer matches what's there
+	w := s.transition(owner, `{"status":"approved","version":1}`)
+	if w.Code != http.StatusConflict || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("stale approval: status = %d, ETag %q (%s), want 409 with the current version", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+	if got := s.db.status(s.sessionID); got != models.SessionStatusInReview {
+		t.Fatalf("a refused approval moved the session to %q", got)
+	}
+
+	want := []string{
+		"approved>in_review by editor @2 edited after approval",
+		"in_review>approved by owner @1 ",
+		"changes_requested>in_review by editor @1 ",
+		"in_review>changes_requested by owner @1 tighten the intro",
+		"draft>in_review by editor @1 first cut",
+	}
+	if got := s.transitions(t); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("audit trail:\n%q\nwant\n%q", got, want)
+	}
+	if w := serve(s.h.ListTransitions, http.MethodGet, "", s.users["stranger"], map[string]string{"id": s.sessionID.String()}); w.Code != http.StatusForbidden {
+		t.Fatalf("stranger listing transitions: status = %d, want 403", w.Code)
+	}
+}
+
+// With approval required, ExportSession refuses anything short of approved
+// (after the role check — a viewer learns nothing about the status), and a
+// completed export moves the session on to exported.
+func TestExportApprovalGate(t *testing.T) {
+	s := newRoleSession()
+	s.h.Exports.RequireApproval = true
+
+	if w := s.export(models.RoleViewer); w.Code != http.StatusForbidden {
+		t.Fatalf("viewer: status = %d (%s), want 403", w.Code, w.Body)
+	}
+	for _, status := range []string{
+		models.SessionStatusDraft, models.SessionStatusInReview, models.SessionStatusChangesRequested,
+	} {
+		s.db.setStatus(s.sessionID, status)
+		if w := s.export(models.RoleEditor); w.Code != http.StatusConflict {
+			t.Fatalf("%s: status = %d (%s), want 409", status, w.Code, w.Body)
+		}
+	}
+	if len(s.db.jobs) != 0 {
+		t.Fatalf("refused exports queued %d jobs", len(s.db.jobs))
+	}
+
+	s.db.setStatus(s.sessionID, models.SessionStatusInReview)
+	if w := s.transition(models.RoleOwner, `{"status":"approved"}`); w.Code != http.StatusOK {
+		t.Fatalf("approve: status = %d (%s)", w.Code, w.Body)
+	}
+	if w := s.export(models.RoleEditor); w.Code != http.StatusAccepted {
+		t.Fatalf("approved: status = %d (%s), want 202", w.Code, w.Body)
+	}
+
+	// The worker reports the render done: approved → exported, by the system
+	if err := s.h.Service.UpdateExportStatus(s.sessionID, s.users[models.RoleEditor], uuid.Nil, service.ExportStatusCompleted, "https://cdn.example.com/out.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.db.status(s.sessionID); got != models.SessionStatusExported {
+		t.Fatalf("status after export = %q, want exported", got)
+	}
+	if got := s.transitions(t)[0]; got != "approved>exported by system @1 export completed" {
+		t.Fatalf("latest transition = %q", got)
+	}
+	// An exported session can go out again
+	if w := s.export(models.RoleEditor); w.Code != http.StatusAccepted {
+		t.Fatalf("exported: status = %d (%s), want 202", w.Code, w.Body)
+	}
+
+	// Without the option, drafts export as before
+	d := newRoleSession()
+	if w := d.export(models.RoleEditor); w.Code != http.StatusAccepted {
+		t.Fatalf("draft without approval required: status = %d (%s), want 202", w.Code, w.Body)
+	}
+}
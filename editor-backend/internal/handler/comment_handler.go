@@ -0,0 +1,361 @@
+// internal/handler/comment_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// commentRequest is the body of POST .../comments (account holders and share
+// link reviewers alike).
+type commentRequest struct {
+	ParentID   *uuid.UUID  `json:"parent_id"`
+	Start      float64     `json:"start"`
+	End        float64     `json:"end"`
+	ClipID     string      `json:"clip_id"`
+	Body       string      `json:"body"`
+	Mentions   []uuid.UUID `json:"mentions"`
+	AuthorName string      `json:"author_name"`
+}
+
+func (req commentRequest) input() service.CommentInput {
+	return service.CommentInput{
+		ParentID:   req.ParentID,
+		Start:      req.Start,
+		End:        req.End,
+		ClipID:     req.ClipID,
+		Body:       req.Body,
+		Mentions:   req.Mentions,
+		AuthorName: req.AuthorName,
+	}
+}
+
+// CreateComment adds a timecoded comment, or a reply with parent_id. Needs
+// the commenter role.
+//
+// POST /api/v1/sessions/{id}/comments
+//
+//	{
+//	    "start": 12.0, "end": 14.5,      // seconds; omit end for a single moment
+//	    "clip_id": "clip-3",             // optional
+//	    "body": "caption overlaps the face",
+//	    "mentions": ["<user uuid>"],     // optional
+//	    "parent_id": "<comment uuid>"    // replies only — anchor comes from the thread
+//	}
+func (h *EditorHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	comment, err := h.Service.CreateComment(sessionID, userID, getWorkspaceID(r), req.input())
+	if err != nil {
+		respondCommentError(w, err, "failed to create comment")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, comment)
+}
+
+// ListComments returns the session's comment threads in timeline order.
+//
+// GET /api/v1/sessions/{id}/comments
+//
+//	?resolved=true|false
+//	&clip_id=clip-3
+//	&clip_deleted=true|false
+//	&author=<uuid>|me
+//	&mentioned=<uuid>|me
+//	&from=10&to=20                   (seconds — threads overlapping the range)
+func (h *EditorHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	filter, ok := parseCommentFilter(w, r, userID)
+	if !ok {
+		return
+	}
+
+	comments, err := h.Service.ListComments(sessionID, userID, getWorkspaceID(r), filter)
+	if err != nil {
+		respondCommentError(w, err, "failed to list comments")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"comments": comments})
+}
+
+// GetComment returns the thread a comment belongs to.
+//
+// GET /api/v1/sessions/{id}/comments/{comment_id}
+func (h *EditorHandler) GetComment(w http.ResponseWriter, r *http.Request) {
+	sessionID, commentID, userID, ok := commentRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.Service.GetComment(sessionID, userID, getWorkspaceID(r), commentID)
+	if err != nil {
+		respondCommentError(w, err, "failed to get comment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, comment)
+}
+
+// UpdateComment edits a comment's body and mentions. Author only.
+//
+// PUT /api/v1/sessions/{id}/comments/{comment_id}   { "body": "...", "mentions": [...] }
+func (h *EditorHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	sessionID, commentID, userID, ok := commentRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Body     string      `json:"body"`
+		Mentions []uuid.UUID `json:"mentions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	comment, err := h.Service.UpdateComment(sessionID, userID, getWorkspaceID(r), commentID, req.Body, req.Mentions)
+	if err != nil {
+		respondCommentError(w, err, "failed to update comment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, comment)
+}
+
+// ResolveComment marks a thread resolved.
+//
+// POST /api/v1/sessions/{id}/comments/{comment_id}/resolve
+func (h *EditorHandler) ResolveComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentResolved(w, r, true)
+}
+
+// ReopenComment marks a resolved thread open again.
+//
+// POST /api/v1/sessions/{id}/comments/{comment_id}/reopen
+func (h *EditorHandler) ReopenComment(w http.ResponseWriter, r *http.Request) {
+	h.setCommentResolved(w, r, false)
+}
+
+func (h *EditorHandler) setCommentResolved(w http.ResponseWriter, r *http.Request, resolved bool) {
+	sessionID, commentID, userID, ok := commentRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.Service.ResolveComment(sessionID, userID, getWorkspaceID(r), commentID, resolved)
+	if err != nil {
+		respondCommentError(w, err, "failed to update comment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, comment)
+}
+
+// DeleteComment removes a comment (a top-level comment takes its replies
+// with it). Authors delete their own; editors delete anyone's.
+//
+// DELETE /api/v1/sessions/{id}/comments/{comment_id}
+func (h *EditorHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	sessionID, commentID, userID, ok := commentRequestIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteComment(sessionID, userID, getWorkspaceID(r), commentID); err != nil {
+		respondCommentError(w, err, "failed to delete comment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ListSharedComments is the PUBLIC thread list for "comment" share links.
+//
+// GET /api/v1/share/{token}/comments   (same filters as ListComments, minus "me")
+func (h *EditorHandler) ListSharedComments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !h.allowShareRequest(w, r) {
+		return
+	}
+
+	filter, ok := parseCommentFilter(w, r, uuid.Nil)
+	if !ok {
+		return
+	}
+
+	access := service.ShareAccess{IP: clientIP(r), UserAgent: r.UserAgent()}
+	comments, err := h.Service.ListSharedComments(mux.Vars(r)["token"], r.Header.Get("X-Share-Password"), access, filter)
+	if err != nil {
+		respondCommentError(w, err, "failed to list comments")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"comments": comments})
+}
+
+// CreateSharedComment lets a reviewer without an account comment through a
+// "comment" share link. author_name is required; mentions are ignored.
+//
+// POST /api/v1/share/{token}/comments
+func (h *EditorHandler) CreateSharedComment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !h.allowShareRequest(w, r) {
+		return
+	}
+
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	access := service.ShareAccess{IP: clientIP(r), UserAgent: r.UserAgent()}
+	comment, err := h.Service.CreateSharedComment(mux.Vars(r)["token"], r.Header.Get("X-Share-Password"), access, req.input())
+	if err != nil {
+		respondCommentError(w, err, "failed to create comment")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, comment)
+}
+
+// commentRequestIDs parses {id}, {comment_id} and the caller.
+func commentRequestIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	commentID, err := parseUUIDParam(r, "comment_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid comment id — must be a UUID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return sessionID, commentID, userID, true
+}
+
+// parseCommentFilter reads the ListComments query. "me" in author / mentioned
+// means userID (uuid.Nil → "me" is rejected).
+func parseCommentFilter(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (service.CommentFilter, bool) {
+	q := r.URL.Query()
+	f := service.CommentFilter{ClipID: q.Get("clip_id")}
+
+	for _, p := range []struct {
+		name string
+		dst  **bool
+	}{{"resolved", &f.Resolved}, {"clip_deleted", &f.ClipDeleted}} {
+		if v := q.Get(p.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, p.name+" must be true or false")
+				return f, false
+			}
+			*p.dst = &b
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				respondError(w, http.StatusBadRequest, p.name+" must be a non-negative number of seconds")
+				return f, false
+			}
+			*p.dst = &n
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **uuid.UUID
+	}{{"author", &f.AuthorID}, {"mentioned", &f.Mentioned}} {
+		v := q.Get(p.name)
+		switch {
+		case v == "":
+			continue
+		case v == "me" && userID != uuid.Nil:
+			id := userID
+			*p.dst = &id
+		default:
+			id, err := uuid.Parse(v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, p.name+" must be a UUID or \"me\"")
+				return f, false
+			}
+			*p.dst = &id
+		}
+	}
+
+	return f, true
+}
+
+func respondCommentError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrCommentNotFound:
+		respondError(w, http.StatusNotFound, "comment not found")
+	case service.ErrInvalidComment, service.ErrInvalidCommentRange, service.ErrCommentClipNotFound,
+		service.ErrCommentIsReply, service.ErrInvalidMention, service.ErrInvalidAuthorName:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrNotCommentAuthor, service.ErrShareNoComments:
+		respondError(w, http.StatusForbidden, err.Error())
+	case service.ErrShareNotFound, service.ErrShareExpired, service.ErrSharePasswordRequired, service.ErrSharePasswordWrong,
+		service.ErrShareThrottled:
+		respondShareError(w, err, fallback)
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Comment error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
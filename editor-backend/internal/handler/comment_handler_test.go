@@ -0,0 +1,177 @@
+// internal/handler/comment_handler_test.go
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// commentSession is a roleSession with a thread started by the commenter,
+// a second commenter, and that second commenter's reply on the thread.
+type commentSession struct {
+	*roleSession
+	thread, reply uuid.UUID
+}
+
+func newCommentSession(t *testing.T) *commentSession {
+	t.Helper()
+	s := &commentSession{roleSession: newRoleSession()}
+	s.users["other commenter"] = uuid.New()
+	s.db.addMember(s.sessionID, s.users["other commenter"], models.RoleCommenter)
+
+	s.thread = s.post(t, models.RoleCommenter, `{"start":1,"end":2,"clip_id":"title","body":"caption overlaps the face"}`)
+	s.reply = s.post(t, "other commenter", `{"parent_id":"`+s.thread.String()+`","body":"agreed"}`)
+	return s
+}
+
+func (s *commentSession) post(t *testing.T, user, body string) uuid.UUID {
+	t.Helper()
+	w := serve(s.h.CreateComment, http.MethodPost, body, s.users[user], map[string]string{"id": s.sessionID.String()})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create comment as %s: status = %d (%s)", user, w.Code, w.Body)
+	}
+	return uuid.MustParse(jsonField(t, w, "comment_id"))
+}
+
+func (s *commentSession) vars(commentID uuid.UUID) map[string]string {
+	return map[string]string{"id": s.sessionID.String(), "comment_id": commentID.String()}
+}
+
+// Editing is the author's alone; resolving and reopening are open to any
+// commenter; deleting someone else's comment takes an editor. Viewers and
+// strangers can do none of it.
+func TestCommentPermissions(t *testing.T) {
+	const (
+		owner, editor, author, viewer = models.RoleOwner, models.RoleEditor, models.RoleCommenter, models.RoleViewer
+		other, stranger               = "other commenter", "stranger"
+	)
+	ok, forbidden := http.StatusOK, http.StatusForbidden
+
+	cases := []struct {
+		name    string
+		method  string
+		body    string
+		handler func(h *EditorHandler) http.HandlerFunc
+		want    map[string]int
+		before  bool // start from a resolved thread
+	}{
+		{"update", http.MethodPut, `{"body":"caption overlaps the logo"}`,
+			func(h *EditorHandler) http.HandlerFunc { return h.UpdateComment },
+			map[string]int{owner: forbidden, editor: forbidden, author: ok, other: forbidden, viewer: forbidden, stranger: forbidden}, false},
+		{"resolve", http.MethodPost, "",
+			func(h *EditorHandler) http.HandlerFunc { return h.ResolveComment },
+			map[string]int{owner: ok, editor: ok, author: ok, other: ok, viewer: forbidden, stranger: forbidden}, false},
+		{"reopen", http.MethodPost, "",
+			func(h *EditorHandler) http.HandlerFunc { return h.ReopenComment },
+			map[string]int{owner: ok, editor: ok, author: ok, other: ok, viewer: forbidden, stranger: forbidden}, true},
+		{"delete", http.MethodDelete, "",
+			func(h *EditorHandler) http.HandlerFunc { return h.DeleteComment },
+			map[string]int{owner: ok, editor: ok, author: ok, other: forbidden, viewer: forbidden, stranger: forbidden}, false},
+	}
+	for _, tc := range cases {
+		for user, want := range tc.want {
+			t.Run(tc.name+" as "+user, func(t *testing.T) {
+				s := newCommentSession(t)
+				if tc.before {
+					serve(s.h.ResolveComment, http.MethodPost, "", s.users[owner], s.vars(s.thread))
+				}
+				w := serve(tc.handler(s.h), tc.method, tc.body, s.users[user], s.vars(s.thread))
+				if w.Code != want {
+					t.Fatalf("status = %d (%s), want %d", w.Code, w.Body, want)
+				}
+
+				// A refused request leaves the thread as it was
+				c := s.db.comment(s.thread)
+				if want != ok {
+					if c == nil || c.Body != "caption overlaps the face" || (c.ResolvedAt != nil) != tc.before {
+						t.Fatalf("refused %s changed the thread: %+v", tc.name, c)
+					}
+					return
+				}
+				switch tc.name {
+				case "update":
+					if c.Body != "caption overlaps the logo" {
+						t.Errorf("body = %q", c.Body)
+					}
+				case "resolve":
+					if c.ResolvedBy == nil || *c.ResolvedBy != s.users[user] {
+						t.Errorf("resolved_by = %v, want %s", c.ResolvedBy, s.users[user])
+					}
+				case "reopen":
+					if c.ResolvedAt != nil {
+						t.Errorf("resolved_at = %v, want open", c.ResolvedAt)
+					}
+				case "delete":
+					// The thread takes its replies with it
+					if c != nil || s.db.comment(s.reply) != nil {
+						t.Errorf("thread or reply survived the delete")
+					}
+				}
+			})
+		}
+	}
+}
+
+// A non-author commenter resolves a thread someone else opened, and a
+// different commenter can reopen it; replies can't be resolved on their own.
+func TestCommentResolveByNonAuthor(t *testing.T) {
+	s := newCommentSession(t)
+
+	w := serve(s.h.ResolveComment, http.MethodPost, "", s.users["other commenter"], s.vars(s.thread))
+	if w.Code != http.StatusOK {
+		t.Fatalf("resolve: status = %d (%s)", w.Code, w.Body)
+	}
+	if jsonField(t, w, "resolved_by") != s.users["other commenter"].String() {
+		t.Fatalf("resolve response: %s", w.Body)
+	}
+	// Resolving again keeps the first resolver
+	serve(s.h.ResolveComment, http.MethodPost, "", s.users[models.RoleOwner], s.vars(s.thread))
+	if c := s.db.comment(s.thread); *c.ResolvedBy != s.users["other commenter"] {
+		t.Fatalf("resolved_by = %s after a second resolve", c.ResolvedBy)
+	}
+
+	if w := serve(s.h.ReopenComment, http.MethodPost, "", s.users[models.RoleCommenter], s.vars(s.thread)); w.Code != http.StatusOK {
+		t.Fatalf("reopen: status = %d (%s)", w.Code, w.Body)
+	}
+	if c := s.db.comment(s.thread); c.ResolvedAt != nil || c.ResolvedBy != nil {
+		t.Fatalf("reopened thread: %+v", c)
+	}
+
+	if w := serve(s.h.ResolveComment, http.MethodPost, "", s.users[models.RoleCommenter], s.vars(s.reply)); w.Code != http.StatusBadRequest {
+		t.Fatalf("resolving a reply: status = %d (%s), want 400", w.Code, w.Body)
+	}
+}
+
+// Reply authors delete their own replies, but not the thread they replied
+// to; the thread's author can't delete someone else's reply either.
+func TestCommentDeleteByNonAuthor(t *testing.T) {
+	s := newCommentSession(t)
+
+	if w := serve(s.h.DeleteComment, http.MethodDelete, "", s.users["other commenter"], s.vars(s.thread)); w.Code != http.StatusForbidden {
+		t.Fatalf("reply author deleting the thread: status = %d (%s), want 403", w.Code, w.Body)
+	}
+	if w := serve(s.h.DeleteComment, http.MethodDelete, "", s.users[models.RoleCommenter], s.vars(s.reply)); w.Code != http.StatusForbidden {
+		t.Fatalf("thread author deleting a reply: status = %d (%s), want 403", w.Code, w.Body)
+	}
+	if s.db.comment(s.thread) == nil || s.db.comment(s.reply) == nil {
+		t.Fatal("a refused delete removed a comment")
+	}
+
+	if w := serve(s.h.DeleteComment, http.MethodDelete, "", s.users["other commenter"], s.vars(s.reply)); w.Code != http.StatusOK {
+		t.Fatalf("deleting one's own reply: status = %d (%s)", w.Code, w.Body)
+	}
+	if s.db.comment(s.reply) != nil || s.db.comment(s.thread) == nil {
+		t.Fatal("deleting the reply should leave the thread")
+	}
+
+	// Another session's comment isn't reachable through this one
+	otherSession := s.db.addSession(s.users[models.RoleOwner])
+	vars := map[string]string{"id": otherSession.String(), "comment_id": s.thread.String()}
+	if w := serve(s.h.DeleteComment, http.MethodDelete, "", s.users[models.RoleOwner], vars); w.Code != http.StatusNotFound {
+		t.Fatalf("comment through another session: status = %d (%s), want 404", w.Code, w.Body)
+	}
+}
@@ -3,14 +3,22 @@ package handler
 
 import (
 	"context"
+	"editor-backend/internal/auth"
+	"editor-backend/internal/events"
+	"editor-backend/internal/jsonpatch"
 	"editor-backend/internal/models"
 	"editor-backend/internal/service"
 	"editor-backend/internal/storage"
+	"editor-backend/internal/timeline"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"editor-backend/internal/validation"
@@ -22,33 +30,64 @@ import (
 type EditorHandler struct {
 	Service *service.SessionService
 	Storage storage.Storage
+	Exports *service.ExportJobService
+
+	// Workspaces — membership and the asset library
+	Workspaces *service.WorkspaceService
+
+	// Uploads — presigned direct-to-storage uploads
+	Uploads *service.UploadService
+
+	// BaseURL is the public origin (BASE_URL) — share links and relative
+	// media URLs are built on it
+	BaseURL string
+
+	// Live events — Broker feeds SSE streams, Events publishes to every pod
+	Broker *events.Broker
+	Events *events.Publisher
+
+	// ShareLimiter caps requests per IP on the public /share routes;
+	// nil = unlimited
+	ShareLimiter *RateLimiter
+
+	// AllowedOrigins (ALLOWED_ORIGINS) are the browser origins that may
+	// open the live socket
+	AllowedOrigins []string
 }
 
-// getUserID reads the user identity injected by the API gateway.
-//
-// Production flow:
-//
-//	Browser → API Gateway (validates JWT) → injects X-User-ID header → Editor Service
-//
-// The frontend never sends user_id. The gateway is the single authority on identity.
-// This means your editor module works correctly whether accessed directly by a user
-// or called programmatically by another service — identity always comes from the gateway.
-//
-// Development flow (no gateway):
-//
-//	You can send X-User-ID manually in Postman/curl until the gateway is wired up.
+// getUserID returns the authenticated user that auth.Middleware attached to
+// the request — from a verified JWT, or from X-User-ID when the service runs
+// in trusted-gateway mode. Handlers never read identity headers directly.
 func getUserID(r *http.Request) (uuid.UUID, error) {
-	userIDStr := r.Header.Get("X-User-ID")
-	if userIDStr == "" {
-		// Dev fallback — remove this block when gateway is wired up
-		log.Println("WARNING: No X-User-ID header — using dev placeholder. Do NOT ship this to prod without a gateway.")
-		return uuid.MustParse("11111111-1111-1111-1111-111111111111"), nil
+	id, ok := auth.FromContext(r.Context())
+	if !ok {
+		return uuid.Nil, auth.ErrNoIdentity
 	}
-	parsed, err := uuid.Parse(userIDStr)
-	if err != nil {
-		return uuid.Nil, err
+	return id.UserID, nil
+}
+
+// getWorkspaceID returns the caller's active workspace — uuid.Nil for the
+// personal space. The service layer checks membership.
+func getWorkspaceID(r *http.Request) uuid.UUID {
+	id, ok := auth.FromContext(r.Context())
+	if !ok {
+		return uuid.Nil
 	}
-	return parsed, nil
+	return id.WorkspaceID
+}
+
+// resolveSourceModule checks a claimed source_module against a signed service
+// caller: a service may only speak for itself, and an empty claim is filled
+// in with its name. User (JWT / gateway) callers pass through unchanged.
+func resolveSourceModule(r *http.Request, claimed string) (string, bool) {
+	id, ok := auth.FromContext(r.Context())
+	if !ok || id.Method != auth.MethodService {
+		return claimed, true
+	}
+	if claimed == "" {
+		return id.Service, true
+	}
+	return claimed, claimed == id.Service
 }
 
 // CreateSession creates or retrieves an editing session for a given content_id.
@@ -83,13 +122,16 @@ func (h *EditorHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := getUserID(r)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid X-User-ID header")
+		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
 	// FindOrCreate: reuse existing session instead of spawning new orphans
-	session, err := h.Service.FindOrCreateSession(userID, contentID)
+	session, err := h.Service.FindOrCreateSession(userID, getWorkspaceID(r), contentID)
 	if err != nil {
+		if respondAccessError(w, err) {
+			return
+		}
 		log.Println("CreateSession error:", err)
 		respondError(w, http.StatusInternalServerError, "failed to create session")
 		return
@@ -100,6 +142,7 @@ func (h *EditorHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
 
 // GetSession fetches a session by ID.
 // Validates the session belongs to the requesting user (ownership check).
+// The ETag is the session version — send it back as If-Match when saving.
 func (h *EditorHandler) GetSession(w http.ResponseWriter, r *http.Request) {
 	sessionID, err := parseUUIDParam(r, "id")
 	if err != nil {
@@ -109,28 +152,34 @@ func (h *EditorHandler) GetSession(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := getUserID(r)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid X-User-ID header")
+		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
-	session, err := h.Service.GetSession(sessionID, userID)
+	session, err := h.Service.GetSession(sessionID, userID, getWorkspaceID(r))
 	if err != nil {
-		if err == service.ErrSessionNotFound {
-			respondError(w, http.StatusNotFound, "session not found")
-			return
-		}
-		if err == service.ErrUnauthorized {
-			respondError(w, http.StatusForbidden, "you do not own this session")
+		if respondAccessError(w, err) {
 			return
 		}
 		respondError(w, http.StatusInternalServerError, "failed to get session")
 		return
 	}
 
+	w.Header().Set("ETag", versionETag(session.Version))
 	respondJSON(w, http.StatusOK, session)
 }
 
 // SaveSession persists the timeline state for a session.
+//
+// Optimistic concurrency: the client states which version it edited, either as
+// "version" in the body or as an If-Match ETag from GetSession. If the session
+// has moved on since, nothing is written and 409 returns the server's current
+// version + timeline so the client can merge or reload instead of clobbering.
+// Omitting both keeps the old last-write-wins behaviour.
+//
+// Every save is kept in the version history (see version_handler.go).
+//
+// Request body: { "timeline": {...}, "version": 7, "label": "optional" }
 func (h *EditorHandler) SaveSession(w http.ResponseWriter, r *http.Request) {
 	sessionID, err := parseUUIDParam(r, "id")
 	if err != nil {
@@ -138,8 +187,16 @@ func (h *EditorHandler) SaveSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
 	var body struct {
-		Timeline map[string]interface{} `json:"timeline"`
+		Timeline json.RawMessage `json:"timeline"`
+		Version  int             `json:"version"`
+		Label    string          `json:"label"` // optional — names this point in version history
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -147,22 +204,142 @@ func (h *EditorHandler) SaveSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if body.Timeline == nil {
+	if len(body.Timeline) == 0 || string(body.Timeline) == "null" {
 		respondError(w, http.StatusBadRequest, "timeline is required")
 		return
 	}
 
-	if err := h.Service.SaveSession(sessionID, body.Timeline); err != nil {
+	// Clients still sending an older shape are upgraded like stored rows
+	tl, err := timeline.Decode(body.Timeline)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := validation.ValidateVersionLabel(body.Label); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	expectedVersion, ok := requestVersion(w, r, body.Version)
+	if !ok {
+		return
+	}
+
+	version, err := h.Service.SaveSession(sessionID, userID, getWorkspaceID(r), tl, expectedVersion, body.Label)
+	if err != nil {
+		var conflict *service.VersionConflictError
+		if errors.As(err, &conflict) {
+			respondVersionConflict(w, conflict)
+			return
+		}
+		var invalid *validation.TimelineError
+		if errors.As(err, &invalid) {
+			respondInvalidTimeline(w, invalid)
+			return
+		}
 		log.Println("SaveSession error:", err)
-		if err == service.ErrSessionNotFound {
-			respondError(w, http.StatusNotFound, "session not found")
+		if respondAccessError(w, err) {
 			return
 		}
 		respondError(w, http.StatusInternalServerError, "failed to save session")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"status": "saved"})
+	w.Header().Set("ETag", versionETag(version))
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "saved",
+		"version": version,
+	})
+}
+
+// PatchSession is a partial save: the body is an RFC 6902 JSON Patch or an
+// RFC 7396 Merge Patch against the timeline as GetSession returns it, picked
+// by Content-Type. The patch is applied under a row lock and the result is
+// validated and saved like a full save (version history, undo, session.saved).
+// Send If-Match to make sure the patch applies to the version it was computed
+// against — otherwise it applies to whatever is current.
+//
+// PATCH /api/v1/sessions/{id}
+//
+//	Content-Type: application/json-patch+json
+//	[ { "op": "replace", "path": "/tracks/2/clips/0/text", "value": "New title" } ]
+//
+//	Content-Type: application/merge-patch+json
+//	{ "zoom_level": 80, "selectedClipId": null }
+func (h *EditorHandler) PatchSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var format string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json-patch+json":
+		format = service.PatchJSON
+	case "application/merge-patch+json":
+		format = service.PatchMerge
+	default:
+		w.Header().Set("Accept-Patch", "application/json-patch+json, application/merge-patch+json")
+		respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json-patch+json or application/merge-patch+json")
+		return
+	}
+
+	var patch json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	expectedVersion, ok := requestVersion(w, r, 0)
+	if !ok {
+		return
+	}
+
+	version, err := h.Service.PatchSession(sessionID, userID, getWorkspaceID(r), format, patch, expectedVersion)
+	if err != nil {
+		var conflict *service.VersionConflictError
+		if errors.As(err, &conflict) {
+			respondVersionConflict(w, conflict)
+			return
+		}
+		var invalid *validation.TimelineError
+		if errors.As(err, &invalid) {
+			respondInvalidTimeline(w, invalid)
+			return
+		}
+		switch {
+		case errors.Is(err, jsonpatch.ErrInvalidPatch):
+			respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			respondError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, jsonpatch.ErrPathNotFound), errors.Is(err, service.ErrPatchedTimeline):
+			respondError(w, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, service.ErrTimelineUnreadable):
+			respondTimelineUnreadable(w, err)
+		default:
+			log.Println("PatchSession error:", err)
+			if respondAccessError(w, err) {
+				return
+			}
+			respondError(w, http.StatusInternalServerError, "failed to save session")
+		}
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(version))
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "saved",
+		"version": version,
+	})
 }
 
 // DeleteSession permanently removes a session.
@@ -173,7 +350,16 @@ func (h *EditorHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.Service.DeleteSession(sessionID); err != nil {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.Service.DeleteSession(sessionID, userID, getWorkspaceID(r)); err != nil {
+		if respondAccessError(w, err) {
+			return
+		}
 		log.Println("DeleteSession error:", err)
 		respondError(w, http.StatusInternalServerError, "failed to delete session")
 		return
@@ -183,7 +369,23 @@ func (h *EditorHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 }
 
 // UploadFile handles media uploads with type validation and safe filenames.
+// The upload is recorded as an asset in the caller's active workspace.
 func (h *EditorHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	workspaceID := getWorkspaceID(r)
+
+	// Check before storing anything — a rejected upload must not leave a file
+	if err := h.Workspaces.RequireRole(workspaceID, userID, models.WorkspaceRoleMember); err != nil {
+		if respondAccessError(w, err) {
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to check workspace access")
+		return
+	}
 
 	if err := r.ParseMultipartForm(validation.MaxFileSize); err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
@@ -205,14 +407,22 @@ func (h *EditorHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 
 	contentType := fileHeader.Header.Get("Content-Type")
 
-	fileURL, err := h.Storage.Upload(file, fileHeader.Filename, contentType)
+	object, err := h.Storage.Upload(file, fileHeader.Size, fileHeader.Filename, contentType)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	asset, err := h.Workspaces.RecordAsset(userID, workspaceID, object.URL, fileHeader.Filename, contentType, fileHeader.Size)
+	if err != nil {
+		log.Println("UploadFile asset error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to record upload")
+		return
+	}
+
 	respondJSON(w, http.StatusOK, map[string]string{
-		"file_url": fileURL,
+		"file_url": object.URL,
+		"asset_id": asset.AssetID.String(),
 	})
 }
 
@@ -276,9 +486,16 @@ func (h *EditorHandler) CreateSessionFromClip(w http.ResponseWriter, r *http.Req
 	// Get authenticated user
 	userID, err := getUserID(r)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid user")
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	sourceModule, ok := resolveSourceModule(r, req.SourceModule)
+	if !ok {
+		respondError(w, http.StatusForbidden, "source_module does not match the calling service")
 		return
 	}
+	req.SourceModule = sourceModule
 
 	// Determine content_id:
 	// - If source_asset_id provided → use it (enables session reuse for same clip)
@@ -303,18 +520,24 @@ func (h *EditorHandler) CreateSessionFromClip(w http.ResponseWriter, r *http.Req
 
 	if hasSourceContext {
 		s, e := h.Service.FindOrCreateSessionWithSource(
-			userID, contentID,
+			userID, getWorkspaceID(r), contentID,
 			req.SourceAssetID, req.SourceJobID, req.SourceModule, req.Platform,
 		)
 		if e != nil {
+			if respondAccessError(w, e) {
+				return
+			}
 			log.Println("CreateSessionFromClip error:", e)
 			respondError(w, http.StatusInternalServerError, "failed to create session")
 			return
 		}
 		editorSession = s
 	} else {
-		s, e := h.Service.FindOrCreateSession(userID, contentID)
+		s, e := h.Service.FindOrCreateSession(userID, getWorkspaceID(r), contentID)
 		if e != nil {
+			if respondAccessError(w, e) {
+				return
+			}
 			log.Println("CreateSessionFromClip error:", e)
 			respondError(w, http.StatusInternalServerError, "failed to create session")
 			return
@@ -323,20 +546,26 @@ func (h *EditorHandler) CreateSessionFromClip(w http.ResponseWriter, r *http.Req
 	}
 
 	// Create timeline with clip preloaded
-	timeline := map[string]interface{}{
-		"duration": req.Duration,
-		"tracks": []interface{}{
-			map[string]interface{}{
-				"type":    "video",
-				"visible": true,
-				"muted":   false,
-				"clips": []interface{}{
-					map[string]interface{}{
-						"id":       req.ClipID,
-						"src":      req.ClipURL,
-						"start":    0,
-						"end":      req.Duration,
-						"duration": req.Duration,
+	clipID := req.ClipID
+	if clipID == "" {
+		clipID = "clip_" + uuid.NewString()[:8]
+	}
+	tl := &models.Timeline{
+		Duration: req.Duration,
+		Tracks: []models.Track{
+			{
+				TrackID: "track_video_0",
+				Type:    models.TrackTypeVideo,
+				Clips: []models.Clip{
+					{
+						ClipID:         clipID,
+						OriginalClipID: clipID,
+						Src:            req.ClipURL,
+						Start:          0,
+						End:            req.Duration,
+						Duration:       req.Duration,
+						TrimStart:      0,
+						TrimEnd:        req.Duration,
 					},
 				},
 			},
@@ -344,15 +573,30 @@ func (h *EditorHandler) CreateSessionFromClip(w http.ResponseWriter, r *http.Req
 	}
 
 	// Save timeline
-	err = h.Service.SaveSession(editorSession.SessionID, timeline)
+	_, err = h.Service.SaveSession(editorSession.SessionID, userID, getWorkspaceID(r), tl, 0, "")
 	if err != nil {
+		var invalid *validation.TimelineError
+		if errors.As(err, &invalid) {
+			respondInvalidTimeline(w, invalid)
+			return
+		}
+		if respondAccessError(w, err) {
+			return
+		}
 		log.Println("SaveSession error:", err)
 		respondError(w, http.StatusInternalServerError, "failed to save session timeline")
 		return
 	}
 
+	// An editor tab already open on this session must reload the new clip
+	h.Events.Publish(editorSession.SessionID, events.TypeRepurposerUpdate, map[string]interface{}{
+		"source_module": req.SourceModule,
+		"source_job_id": req.SourceJobID,
+		"clip_id":       req.ClipID,
+	})
+
 	// Fetch updated session with timeline
-	updatedSession, err := h.Service.GetSession(editorSession.SessionID, userID)
+	updatedSession, err := h.Service.GetSession(editorSession.SessionID, userID, getWorkspaceID(r))
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to fetch updated session")
 		return
@@ -363,18 +607,19 @@ func (h *EditorHandler) CreateSessionFromClip(w http.ResponseWriter, r *http.Req
 }
 
 // ============================================================================
-// ExportSession — Phase 2 stub
+// ExportSession — enqueue a render job
 // ============================================================================
 //
-// When implemented, this will:
-//   1. Take the edited timeline from the session
-//   2. Render the final video via FFmpeg
-//   3. Upload to S3
-//   4. Create an asset in Content Hub (assets table)
-//   5. Record lineage: source_asset → exported_asset (relationship_type: "edited_from")
-//   6. Update session.exported_asset_id and session.export_status
+// Rendering takes minutes, far beyond the API's WriteTimeout, so the handler only
+// enqueues. cmd/worker claims the job and drives export_status:
+//
+//	"queued" → "rendering" → "uploading" → "completed" | "failed"
 //
-// POST /api/v1/sessions/{id}/export
+// The job renders the timeline as of this call (its "version"); clicking
+// Export while a job is active returns that same job.
+// Still to come: Content Hub asset creation + lineage (exported_asset_id).
+//
+// POST /api/v1/sessions/{id}/export → 202 { "job_id": "...", "status": "queued", "version": 7 }
 func (h *EditorHandler) ExportSession(w http.ResponseWriter, r *http.Request) {
 	sessionID, err := parseUUIDParam(r, "id")
 	if err != nil {
@@ -384,34 +629,148 @@ func (h *EditorHandler) ExportSession(w http.ResponseWriter, r *http.Request) {
 
 	userID, err := getUserID(r)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid X-User-ID header")
+		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
-	// Verify session exists and belongs to user
-	session, err := h.Service.GetSession(sessionID, userID)
+	// Enqueue verifies the session exists and the user may edit it
+	job, err := h.Exports.Enqueue(sessionID, userID, getWorkspaceID(r))
 	if err != nil {
-		if err == service.ErrSessionNotFound {
-			respondError(w, http.StatusNotFound, "session not found")
+		if err == service.ErrApprovalRequired {
+			respondError(w, http.StatusConflict, err.Error())
 			return
 		}
-		if err == service.ErrUnauthorized {
-			respondError(w, http.StatusForbidden, "you do not own this session")
+		if respondAccessError(w, err) {
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "failed to get session")
+		log.Println("ExportSession error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to enqueue export")
 		return
 	}
 
-	_ = session // Will be used in Phase 2
+	if job.Status == service.JobStatusQueued {
+		if err := h.Service.MarkExportQueued(sessionID, userID, service.WorkspaceOf(job.WorkspaceID), job.JobID); err != nil {
+			log.Println("ExportSession status error:", err)
+		}
+	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"status":     "coming_soon",
-		"message":    "Export to Content Hub will be available in Phase 2",
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"job_id":     job.JobID.String(),
+		"status":     job.Status,
 		"session_id": sessionID.String(),
+		"version":    job.Version,
 	})
 }
 
+// exportJobResponse is the public view of an export job — no lease internals.
+type exportJobResponse struct {
+	JobID           string     `json:"job_id"`
+	SessionID       string     `json:"session_id"`
+	Status          string     `json:"status"`
+	Version         int        `json:"version,omitempty"`
+	ProgressPercent float64    `json:"progress_percent"`
+	ETASeconds      *int       `json:"eta_seconds,omitempty"`
+	CancelRequested bool       `json:"cancel_requested,omitempty"`
+	Attempts        int        `json:"attempts"`
+	Error           string     `json:"error,omitempty"`
+	OutputURL       string     `json:"output_url,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+}
+
+func newExportJobResponse(job *models.ExportJob) exportJobResponse {
+	return exportJobResponse{
+		JobID:           job.JobID.String(),
+		SessionID:       job.SessionID.String(),
+		Status:          job.Status,
+		Version:         job.Version,
+		ProgressPercent: job.ProgressPercent,
+		ETASeconds:      job.ETASeconds,
+		CancelRequested: job.CancelRequested,
+		Attempts:        job.Attempts,
+		Error:           job.LastError,
+		OutputURL:       job.OutputURL,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+}
+
+// GetExport reports status, progress, ETA, error and output URL of an export job.
+//
+// GET /api/v1/exports/{job_id}
+func (h *EditorHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	jobID, err := parseUUIDParam(r, "job_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid job id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	job, err := h.Exports.GetJob(jobID, userID, getWorkspaceID(r))
+	if err != nil {
+		respondExportJobError(w, err, "failed to get export job")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newExportJobResponse(job))
+}
+
+// CancelExport cancels a queued or running export. A running render is
+// stopped by the worker (ffmpeg is killed) within a second or two, so the
+// returned status may still read "running" with cancel_requested set.
+//
+// DELETE /api/v1/exports/{job_id}
+func (h *EditorHandler) CancelExport(w http.ResponseWriter, r *http.Request) {
+	jobID, err := parseUUIDParam(r, "job_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid job id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	job, err := h.Exports.Cancel(jobID, userID, getWorkspaceID(r))
+	if err != nil {
+		respondExportJobError(w, err, "failed to cancel export job")
+		return
+	}
+
+	// Queued jobs never reach a worker, so the session is settled here
+	if job.Status == service.JobStatusCancelled {
+		if err := h.Service.UpdateExportStatus(job.SessionID, userID, service.WorkspaceOf(job.WorkspaceID), service.ExportStatusCancelled, ""); err != nil {
+			log.Println("CancelExport status error:", err)
+		}
+	}
+
+	respondJSON(w, http.StatusAccepted, newExportJobResponse(job))
+}
+
+func respondExportJobError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrJobNotFound:
+		respondError(w, http.StatusNotFound, "export job not found")
+	case service.ErrJobFinished:
+		respondError(w, http.StatusConflict, "export job already finished")
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Export job error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
 // ============================================================================
 // Phase 2: Highlight Reel Creation + Repurposer Integration
 // ============================================================================
@@ -501,9 +860,16 @@ func (h *EditorHandler) CreateHighlightSession(w http.ResponseWriter, r *http.Re
 
 	userID, err := getUserID(r)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid user")
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	sourceModule, ok := resolveSourceModule(r, req.SourceModule)
+	if !ok {
+		respondError(w, http.StatusForbidden, "source_module does not match the calling service")
 		return
 	}
+	req.SourceModule = sourceModule
 
 	contentID, err := uuid.Parse(req.ContentID)
 	if err != nil {
@@ -523,7 +889,10 @@ func (h *EditorHandler) CreateHighlightSession(w http.ResponseWriter, r *http.Re
 		clipMap[clip.ClipID] = clip
 	}
 
-	timelineClips := make([]interface{}, 0, len(req.ClipIDs))
+	// Clips are cut from the source video (trim_start/trim_end) and laid
+	// back-to-back on the timeline in the order they were selected
+	timelineClips := make([]models.Clip, 0, len(req.ClipIDs))
+	var cursor float64
 	for _, clipID := range req.ClipIDs {
 		clip, found := clipMap[clipID]
 		if !found {
@@ -531,18 +900,23 @@ func (h *EditorHandler) CreateHighlightSession(w http.ResponseWriter, r *http.Re
 			continue
 		}
 
-		timelineClips = append(timelineClips, map[string]interface{}{
-			"id":            clip.ClipID,
-			"src":           clip.SourceVideo,
-			"start":         clip.StartTime,
-			"end":           clip.EndTime,
-			"duration":      clip.Duration,
-			"platform":      clip.Platform,
-			"score":         clip.Score,
-			"topic":         clip.Topic,
-			"ai_generated":  true,
-			"source_module": "repurposer",
+		length := clip.EndTime - clip.StartTime
+		timelineClips = append(timelineClips, models.Clip{
+			ClipID:         clip.ClipID,
+			OriginalClipID: clip.ClipID,
+			Src:            clip.SourceVideo,
+			Start:          cursor,
+			End:            cursor + length,
+			Duration:       clip.Duration,
+			TrimStart:      clip.StartTime,
+			TrimEnd:        clip.EndTime,
+			Platform:       clip.Platform,
+			Score:          clip.Score,
+			Topic:          clip.Topic,
+			AIGenerated:    true,
+			SourceModule:   "repurposer",
 		})
+		cursor += length
 	}
 
 	if len(timelineClips) == 0 {
@@ -551,34 +925,53 @@ func (h *EditorHandler) CreateHighlightSession(w http.ResponseWriter, r *http.Re
 	}
 
 	session, err := h.Service.FindOrCreateSessionWithSource(
-		userID, contentID,
+		userID, getWorkspaceID(r), contentID,
 		req.SourceAssetID, req.SourceJobID, req.SourceModule, req.Platform,
 	)
 	if err != nil {
+		if respondAccessError(w, err) {
+			return
+		}
 		log.Println("CreateHighlightSession error:", err)
 		respondError(w, http.StatusInternalServerError, "failed to create session")
 		return
 	}
 
-	timeline := map[string]interface{}{
-		"session_type":    "highlight_reel",
-		"target_duration": req.TargetDuration,
-		"tracks": []interface{}{
-			map[string]interface{}{
-				"type":  "video",
-				"clips": timelineClips,
+	tl := &models.Timeline{
+		Duration:       cursor,
+		SessionType:    "highlight_reel",
+		TargetDuration: float64(req.TargetDuration),
+		Tracks: []models.Track{
+			{
+				TrackID: "track_video_0",
+				Type:    models.TrackTypeVideo,
+				Clips:   timelineClips,
 			},
 		},
 	}
 
-	err = h.Service.SaveSession(session.SessionID, timeline)
+	_, err = h.Service.SaveSession(session.SessionID, userID, getWorkspaceID(r), tl, 0, "")
 	if err != nil {
+		var invalid *validation.TimelineError
+		if errors.As(err, &invalid) {
+			respondInvalidTimeline(w, invalid)
+			return
+		}
+		if respondAccessError(w, err) {
+			return
+		}
 		log.Println("SaveSession error:", err)
 		respondError(w, http.StatusInternalServerError, "failed to save session timeline")
 		return
 	}
 
-	updatedSession, err := h.Service.GetSession(session.SessionID, userID)
+	h.Events.Publish(session.SessionID, events.TypeRepurposerUpdate, map[string]interface{}{
+		"source_module": "repurposer",
+		"source_job_id": req.SourceJobID,
+		"session_type":  "highlight_reel",
+	})
+
+	updatedSession, err := h.Service.GetSession(session.SessionID, userID, getWorkspaceID(r))
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to fetch updated session")
 		return
@@ -594,6 +987,98 @@ func parseUUIDParam(r *http.Request, param string) (uuid.UUID, error) {
 	return uuid.Parse(mux.Vars(r)[param])
 }
 
+// versionETag renders a session version as a strong ETag: 7 → "7"
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseVersionETag accepts "7", W/"7" or * (any version → 0, no check).
+func parseVersionETag(header string) (int, bool) {
+	tag := strings.TrimSpace(header)
+	if tag == "*" {
+		return 0, true
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.Atoi(unquoted)
+	if err != nil || v < 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// requestVersion combines a body "version" with an If-Match header into the
+// version a write expects (0 = no check). Writes 400 and reports false when
+// they are malformed or disagree.
+func requestVersion(w http.ResponseWriter, r *http.Request, bodyVersion int) (int, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return bodyVersion, true
+	}
+	v, ok := parseVersionETag(ifMatch)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "invalid If-Match header — expected an ETag from GET /sessions/{id}")
+		return 0, false
+	}
+	if bodyVersion != 0 && v != 0 && v != bodyVersion {
+		respondError(w, http.StatusBadRequest, "If-Match and body version disagree")
+		return 0, false
+	}
+	if v != 0 {
+		return v, true
+	}
+	return bodyVersion, true
+}
+
+// respondAccessError answers the session / workspace access errors shared by
+// every session endpoint. It reports false for any other error.
+func respondAccessError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case service.ErrSessionNotFound:
+		respondError(w, http.StatusNotFound, "session not found")
+	case service.ErrUnauthorized:
+		respondError(w, http.StatusForbidden, "you do not have access to this session")
+	case service.ErrInsufficientRole:
+		respondError(w, http.StatusForbidden, "your role on this session does not allow this")
+	case service.ErrWorkspaceAccess:
+		respondError(w, http.StatusForbidden, "you are not a member of this workspace")
+	case service.ErrWorkspaceRole:
+		respondError(w, http.StatusForbidden, "your workspace role does not allow this")
+	default:
+		return false
+	}
+	return true
+}
+
+// respondVersionConflict → 409 with what the server has now.
+func respondVersionConflict(w http.ResponseWriter, conflict *service.VersionConflictError) {
+	w.Header().Set("ETag", versionETag(conflict.Current.Version))
+	respondJSON(w, http.StatusConflict, map[string]interface{}{
+		"error":           "session was modified by another save — reload or merge",
+		"current_version": conflict.Current.Version,
+		"timeline":        conflict.Current.Timeline,
+		"updated_at":      conflict.Current.UpdatedAt,
+	})
+}
+
+// respondInvalidTimeline → 422 with every offending field, so the UI can
+// highlight them all at once.
+func respondInvalidTimeline(w http.ResponseWriter, invalid *validation.TimelineError) {
+	respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":  "invalid timeline",
+		"fields": invalid.Fields,
+	})
+}
+
+// respondTimelineUnreadable → 409: the stored timeline can't be read, so it
+// can't be edited — only replaced by a full save.
+func respondTimelineUnreadable(w http.ResponseWriter, err error) {
+	respondError(w, http.StatusConflict, err.Error()+" — save a full timeline to replace it")
+}
+
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,376 @@
+// internal/handler/editor_handler_test.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"editor-backend/internal/auth"
+	"editor-backend/internal/models"
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Access checks on the session and export endpoints: the owner gets through,
+// anyone else is refused with 403, and a session or job that doesn't exist
+// is 404.
+
+func newTestHandler(db *fakeDB) *EditorHandler {
+	sqlDB := db.open()
+	return &EditorHandler{
+		Service: &service.SessionService{DB: sqlDB},
+		Exports: &service.ExportJobService{DB: sqlDB},
+	}
+}
+
+// serve calls handler as userID in the personal space, with the route
+// variables mux would have set.
+func serve(handler http.HandlerFunc, method, body string, userID uuid.UUID, vars map[string]string) *httptest.ResponseRecorder {
+	return serveRequest(handler, httptest.NewRequest(method, "/", strings.NewReader(body)), userID, vars)
+}
+
+// serveRequest is serve for a request the test built itself (headers, query).
+func serveRequest(handler http.HandlerFunc, r *http.Request, userID uuid.UUID, vars map[string]string) *httptest.ResponseRecorder {
+	return serveInWorkspace(handler, r, userID, uuid.Nil, vars)
+}
+
+// serveInWorkspace is serveRequest with workspaceID as the active workspace.
+func serveInWorkspace(handler http.HandlerFunc, r *http.Request, userID, workspaceID uuid.UUID, vars map[string]string) *httptest.ResponseRecorder {
+	r = r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{UserID: userID, WorkspaceID: workspaceID}))
+	r = mux.SetURLVars(r, vars)
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// testTimeline is a valid timeline with one text clip, "title".
+const testTimeline = `{"schema_version":1,"duration":10,"tracks":[{"track_id":"t1","type":"text","clips":[
+	{"clip_id":"title","start":0,"end":4,"text":"Hello"}]}]}`
+
+type accessCase struct {
+	name string
+	user func(owner, stranger, editor, viewer uuid.UUID) uuid.UUID
+	// missing targets an id that doesn't exist
+	missing bool
+	want    int
+}
+
+var (
+	asOwner    = func(owner, _, _, _ uuid.UUID) uuid.UUID { return owner }
+	asStranger = func(_, stranger, _, _ uuid.UUID) uuid.UUID { return stranger }
+	asEditor   = func(_, _, editor, _ uuid.UUID) uuid.UUID { return editor }
+	asViewer   = func(_, _, _, viewer uuid.UUID) uuid.UUID { return viewer }
+)
+
+// runAccessCases sets up a session owned by one user with an editor and a
+// viewer member, and calls the endpoint for every case. target returns the
+// request's route variables for that session (missing → unknown id).
+func runAccessCases(t *testing.T, cases []accessCase, method, body string,
+	handler func(h *EditorHandler) http.HandlerFunc,
+	target func(db *fakeDB, sessionID, owner uuid.UUID, missing bool) map[string]string,
+) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newFakeDB()
+			owner, stranger, editor, viewer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+			sessionID := db.addSession(owner)
+			db.addMember(sessionID, editor, models.RoleEditor)
+			db.addMember(sessionID, viewer, models.RoleViewer)
+
+			h := newTestHandler(db)
+			w := serve(handler(h), method, body, tc.user(owner, stranger, editor, viewer),
+				target(db, sessionID, owner, tc.missing))
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tc.want, w.Body)
+			}
+		})
+	}
+}
+
+func sessionVars(_ *fakeDB, sessionID, _ uuid.UUID, missing bool) map[string]string {
+	if missing {
+		return map[string]string{"id": uuid.NewString()}
+	}
+	return map[string]string{"id": sessionID.String()}
+}
+
+func TestSaveSessionAccess(t *testing.T) {
+	cases := []accessCase{
+		{name: "owner", user: asOwner, want: http.StatusOK},
+		{name: "editor member", user: asEditor, want: http.StatusOK},
+		{name: "viewer member", user: asViewer, want: http.StatusForbidden},
+		{name: "non-member", user: asStranger, want: http.StatusForbidden},
+		{name: "missing session", user: asOwner, missing: true, want: http.StatusNotFound},
+	}
+	body := `{"timeline": {"schema_version": 1, "tracks": []}}`
+
+	runAccessCases(t, cases, http.MethodPut, body,
+		func(h *EditorHandler) http.HandlerFunc { return h.SaveSession }, sessionVars)
+}
+
+func TestDeleteSessionAccess(t *testing.T) {
+	cases := []accessCase{
+		{name: "owner", user: asOwner, want: http.StatusOK},
+		{name: "editor member", user: asEditor, want: http.StatusForbidden},
+		{name: "non-member", user: asStranger, want: http.StatusForbidden},
+		{name: "missing session", user: asOwner, missing: true, want: http.StatusNotFound},
+	}
+
+	runAccessCases(t, cases, http.MethodDelete, "",
+		func(h *EditorHandler) http.HandlerFunc { return h.DeleteSession }, sessionVars)
+}
+
+func TestExportSessionAccess(t *testing.T) {
+	cases := []accessCase{
+		{name: "owner", user: asOwner, want: http.StatusAccepted},
+		{name: "editor member", user: asEditor, want: http.StatusAccepted},
+		{name: "viewer member", user: asViewer, want: http.StatusForbidden},
+		{name: "non-member", user: asStranger, want: http.StatusForbidden},
+		{name: "missing session", user: asOwner, missing: true, want: http.StatusNotFound},
+	}
+
+	runAccessCases(t, cases, http.MethodPost, "",
+		func(h *EditorHandler) http.HandlerFunc { return h.ExportSession }, sessionVars)
+}
+
+func TestCancelExportAccess(t *testing.T) {
+	cases := []accessCase{
+		{name: "owner", user: asOwner, want: http.StatusAccepted},
+		// Any editor can cancel the session's export, not only who started it
+		{name: "editor member", user: asEditor, want: http.StatusAccepted},
+		{name: "viewer member", user: asViewer, want: http.StatusForbidden},
+		{name: "non-member", user: asStranger, want: http.StatusForbidden},
+		{name: "missing job", user: asOwner, missing: true, want: http.StatusNotFound},
+	}
+
+	runAccessCases(t, cases, http.MethodDelete, "",
+		func(h *EditorHandler) http.HandlerFunc { return h.CancelExport },
+		func(db *fakeDB, sessionID, owner uuid.UUID, missing bool) map[string]string {
+			jobID := db.addJob(sessionID, owner, service.JobStatusQueued)
+			if missing {
+				return map[string]string{"job_id": uuid.NewString()}
+			}
+			return map[string]string{"job_id": jobID.String()}
+		})
+}
+
+func TestGetExportAccess(t *testing.T) {
+	cases := []accessCase{
+		{name: "owner", user: asOwner, want: http.StatusOK},
+		{name: "viewer member", user: asViewer, want: http.StatusOK},
+		{name: "non-member", user: asStranger, want: http.StatusForbidden},
+		{name: "missing job", user: asOwner, missing: true, want: http.StatusNotFound},
+	}
+
+	runAccessCases(t, cases, http.MethodGet, "",
+		func(h *EditorHandler) http.HandlerFunc { return h.GetExport },
+		func(db *fakeDB, sessionID, owner uuid.UUID, missing bool) map[string]string {
+			jobID := db.addJob(sessionID, owner, service.JobStatusRunning)
+			if missing {
+				return map[string]string{"job_id": uuid.NewString()}
+			}
+			return map[string]string{"job_id": jobID.String()}
+		})
+}
+
+// A second click while the first export is queued returns the same job —
+// and the editor who clicked can poll it.
+func TestExportSessionReturnsActiveJob(t *testing.T) {
+	db := newFakeDB()
+	owner, editor := uuid.New(), uuid.New()
+	sessionID := db.addSession(owner)
+	db.addMember(sessionID, editor, models.RoleEditor)
+	h := newTestHandler(db)
+	vars := map[string]string{"id": sessionID.String()}
+
+	first := serve(h.ExportSession, http.MethodPost, "", owner, vars)
+	second := serve(h.ExportSession, http.MethodPost, "", editor, vars)
+	if first.Code != http.StatusAccepted || second.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, %d, want 202, 202", first.Code, second.Code)
+	}
+	jobID := jsonField(t, first, "job_id")
+	if got := jsonField(t, second, "job_id"); got != jobID {
+		t.Fatalf("second export job_id = %s, want the active job %s", got, jobID)
+	}
+
+	poll := serve(h.GetExport, http.MethodGet, "", editor, map[string]string{"job_id": jobID})
+	if poll.Code != http.StatusOK {
+		t.Fatalf("editor polling the shared job: status = %d, want 200", poll.Code)
+	}
+}
+
+// An export renders the session as it was when Export was clicked: saves
+// after that neither change the queued job nor show up in it.
+func TestExportSnapshotsTimeline(t *testing.T) {
+	db := newFakeDB()
+	owner := uuid.New()
+	sessionID := db.addSession(owner)
+	db.setTimeline(sessionID, testTimeline)
+	h := newTestHandler(db)
+	vars := map[string]string{"id": sessionID.String()}
+	version := func(w *httptest.ResponseRecorder) float64 {
+		t.Helper()
+		var body struct{ Version float64 }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Version
+	}
+
+	first := serve(h.ExportSession, http.MethodPost, "", owner, vars)
+	if first.Code != http.StatusAccepted || version(first) != 1 {
+		t.Fatalf("export: status = %d (%s), want 202 at version 1", first.Code, first.Body)
+	}
+	jobID := jsonField(t, first, "job_id")
+
+	edited := strings.Replace(testTimeline, `"Hello"`, `"Edited"`, 1)
+	if w := serve(h.SaveSession, http.MethodPut, `{"timeline":`+edited+`}`, owner, vars); w.Code != http.StatusOK {
+		t.Fatalf("save: status = %d (%s)", w.Code, w.Body)
+	}
+
+	// The queued job keeps its snapshot, and says which version it is
+	again := serve(h.ExportSession, http.MethodPost, "", owner, vars)
+	if jsonField(t, again, "job_id") != jobID || version(again) != 1 {
+		t.Fatalf("export while queued: %s, want job %s at version 1", again.Body, jobID)
+	}
+	if poll := serve(h.GetExport, http.MethodGet, "", owner, map[string]string{"job_id": jobID}); version(poll) != 1 {
+		t.Fatalf("poll: %s, want version 1", poll.Body)
+	}
+	job := db.jobs[uuid.MustParse(jobID)]
+	if string(job.timeline) != testTimeline {
+		t.Fatalf("job timeline = %s, want the timeline at enqueue", job.timeline)
+	}
+
+	// Once it's done, the next export picks up the edit
+	job.status = service.JobStatusCompleted
+	next := serve(h.ExportSession, http.MethodPost, "", owner, vars)
+	if next.Code != http.StatusAccepted || jsonField(t, next, "job_id") == jobID || version(next) != 2 {
+		t.Fatalf("export after the edit: %s, want a new job at version 2", next.Body)
+	}
+	if got := db.jobs[uuid.MustParse(jsonField(t, next, "job_id"))].timeline; !strings.Contains(string(got), `"Edited"`) {
+		t.Fatalf("new job timeline = %s, want the edited one", got)
+	}
+}
+
+func jsonField(t *testing.T, w *httptest.ResponseRecorder, field string) string {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response is not JSON: %v (%s)", err, w.Body)
+	}
+	s, _ := body[field].(string)
+	return s
+}
+
+type nonceSet map[string]bool
+
+func (n nonceSet) Remember(_ context.Context, service, nonce string, _ time.Time) (bool, error) {
+	if n[service+"/"+nonce] {
+		return false, nil
+	}
+	n[service+"/"+nonce] = true
+	return true, nil
+}
+
+// A signed service request may only name itself as source_module.
+func TestServiceCallerSourceModule(t *testing.T) {
+	secret := []byte("content-hub-secret-0123456789abcdef")
+	m := &auth.Middleware{
+		Services:     &auth.ServiceAuth{Secrets: map[string][]byte{auth.ServiceContentHub: secret}, Nonces: nonceSet{}},
+		ServiceRoute: func(*http.Request) bool { return true },
+	}
+	h := newTestHandler(newFakeDB())
+	user := uuid.NewString()
+
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+	}{
+		{"from-clip", h.CreateSessionFromClip, `{"clip_url":"/uploads/a.mp4","duration":10,"source_module":"repurposer"}`},
+		{"highlight", h.CreateHighlightSession, `{"content_id":"` + uuid.NewString() + `","clip_ids":["c1"],"target_duration":30,"source_module":"repurposer"}`},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/"+tc.name, strings.NewReader(tc.body))
+		if err := auth.SignRequest(r, auth.ServiceContentHub, secret, user, []byte(tc.body)); err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		m.Handler(tc.handler).ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s as content_hub claiming repurposer: status = %d, want 403", tc.name, w.Code)
+		}
+	}
+
+	resolve := func(id *auth.Identity, claimed string) (string, bool) {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		return resolveSourceModule(r.WithContext(auth.WithIdentity(r.Context(), id)), claimed)
+	}
+	hub := &auth.Identity{Method: auth.MethodService, Service: auth.ServiceContentHub}
+	if got, ok := resolve(hub, ""); !ok || got != auth.ServiceContentHub {
+		t.Errorf("service, empty claim = %q, %v; want content_hub, true", got, ok)
+	}
+	if got, ok := resolve(hub, auth.ServiceContentHub); !ok || got != auth.ServiceContentHub {
+		t.Errorf("service, own name = %q, %v; want content_hub, true", got, ok)
+	}
+	// A user caller's claim is only a label on the session
+	if got, ok := resolve(&auth.Identity{Method: auth.MethodJWT}, "repurposer"); !ok || got != "repurposer" {
+		t.Errorf("user claim = %q, %v; want repurposer, true", got, ok)
+	}
+}
+
+func jsonPatch(body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json-patch+json")
+	return r
+}
+
+// A stored timeline that can't be read is replaced by a full save, never
+// patched — the patch would apply to the empty timeline it loads as.
+func TestPatchSessionUnreadableTimeline(t *testing.T) {
+	db := newFakeDB()
+	owner := uuid.New()
+	sessionID := db.addSession(owner)
+	db.setTimeline(sessionID, testTimeline)
+	h := newTestHandler(db)
+	vars := map[string]string{"id": sessionID.String()}
+	patch := `[{"op":"replace","path":"/tracks/0/clips/0/text","value":"Hi"}]`
+
+	if w := serveRequest(h.PatchSession, jsonPatch(patch), owner, vars); w.Code != http.StatusOK {
+		t.Fatalf("readable timeline: status = %d (%s), want 200", w.Code, w.Body)
+	}
+
+	db.setTimeline(sessionID, `{"tracks":`)
+	w := serveRequest(h.PatchSession, jsonPatch(patch), owner, vars)
+	if w.Code != http.StatusConflict || !strings.Contains(jsonField(t, w, "error"), "could not be read") {
+		t.Fatalf("unreadable timeline: status = %d (%s), want 409", w.Code, w.Body)
+	}
+}
+
+// Operations on an unreadable timeline are refused the same way.
+func TestApplyOperationsUnreadableTimeline(t *testing.T) {
+	db := newFakeDB()
+	owner := uuid.New()
+	sessionID := db.addSession(owner)
+	db.setTimeline(sessionID, testTimeline)
+	h := newTestHandler(db)
+	vars := map[string]string{"id": sessionID.String()}
+	body := `{"operations":[{"type":"move_clip","clip_id":"title","start":2}]}`
+
+	if w := serve(h.ApplyOperations, http.MethodPost, body, owner, vars); w.Code != http.StatusOK {
+		t.Fatalf("readable timeline: status = %d (%s), want 200", w.Code, w.Body)
+	}
+
+	db.setTimeline(sessionID, `{"tracks":`)
+	w := serve(h.ApplyOperations, http.MethodPost, body, owner, vars)
+	if w.Code != http.StatusConflict || !strings.Contains(jsonField(t, w, "error"), "could not be read") {
+		t.Fatalf("unreadable timeline: status = %d (%s), want 409", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,176 @@
+// internal/handler/events_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// sseKeepAlive is short enough to beat typical 60s proxy idle timeouts.
+const sseKeepAlive = 15 * time.Second
+
+// streamAccessRecheck is how often an open stream re-reads the caller's
+// role. members.changed triggers a check right away; the timer catches what
+// publishes no event on the session (leaving the workspace, deletion).
+const streamAccessRecheck = time.Minute
+
+// streamAccessLost re-checks the caller's role for an open stream: true
+// when the session is gone or the caller no longer has any role on it. A
+// failed lookup (database hiccup) keeps the stream — the next check retries.
+func (h *EditorHandler) streamAccessLost(sessionID, userID, workspaceID uuid.UUID) bool {
+	_, err := h.Service.SessionRole(sessionID, userID, workspaceID)
+	switch err {
+	case nil:
+		return false
+	case service.ErrSessionNotFound, service.ErrUnauthorized:
+		return true
+	default:
+		log.Printf("stream access check for session %s failed: %v", sessionID, err)
+		return false
+	}
+}
+
+// SessionEvents streams live events for one session as Server-Sent Events.
+//
+// Pushed events (see internal/events for payloads):
+//   - session.saved      → another tab/device saved; compare "version" to yours
+//   - repurposer.update  → Repurposer / Content Hub replaced the timeline
+//   - export.status      → export_status changed
+//   - export.progress    → render percent + ETA from the worker
+//   - members.changed    → a member was added, removed, or changed role
+//   - comments.changed   → a comment was created, edited, resolved or deleted
+//   - status.changed     → the approval status moved
+//   - op.applied         → a collaborator's edit over /live (version, op)
+//   - presence           → a collaborator's playhead / selection over /live
+//   - resync             → events may have been missed; refetch the session
+//   - access.revoked     → you lost access; the stream closes after it
+//
+// The first event is "ready" with the current version and export status, so a
+// client never misses a change between its GET and opening the stream.
+//
+// Access is re-checked on members.changed and every streamAccessRecheck; a
+// caller who lost it gets access.revoked and the stream ends (reconnecting
+// then answers 403 / 404, which stops EventSource).
+//
+// GET /api/v1/sessions/{id}/events
+func (h *EditorHandler) SessionEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if h.Broker == nil {
+		respondError(w, http.StatusServiceUnavailable, "live events are not enabled")
+		return
+	}
+
+	// Subscribe BEFORE reading the session — an event landing in between is
+	// then delivered after "ready" instead of being lost
+	ch, unsubscribe := h.Broker.Subscribe(sessionID)
+	defer unsubscribe()
+
+	workspaceID := getWorkspaceID(r)
+	session, err := h.Service.GetSession(sessionID, userID, workspaceID)
+	if err != nil {
+		if respondAccessError(w, err) {
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to get session")
+		return
+	}
+
+	// The server-wide WriteTimeout would cut the stream after 30s
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Println("SessionEvents: cannot clear write deadline:", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: don't buffer the stream
+	w.WriteHeader(http.StatusOK)
+
+	ready := events.Event{
+		Type:      "ready",
+		SessionID: sessionID,
+		At:        time.Now().UTC(),
+	}
+	ready.Data, _ = json.Marshal(map[string]interface{}{
+		"version":       session.Version,
+		"export_status": session.ExportStatus,
+		"export_job_id": session.ExportJobID,
+	})
+	if err := writeSSE(w, rc, ready); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	recheck := time.NewTicker(streamAccessRecheck)
+	defer recheck.Stop()
+
+	revoked := events.Event{Type: events.TypeAccessRevoked, SessionID: sessionID}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case ev, ok := <-ch:
+			if !ok {
+				// Broker shutting down — client's EventSource will reconnect to another pod
+				return
+			}
+			if ev.Type == events.TypeMembersChanged && h.streamAccessLost(sessionID, userID, workspaceID) {
+				revoked.At = time.Now().UTC()
+				writeSSE(w, rc, revoked)
+				return
+			}
+			if err := writeSSE(w, rc, ev); err != nil {
+				return
+			}
+
+		case <-recheck.C:
+			if h.streamAccessLost(sessionID, userID, workspaceID) {
+				revoked.At = time.Now().UTC()
+				writeSSE(w, rc, revoked)
+				return
+			}
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, rc *http.ResponseController, ev events.Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
@@ -0,0 +1,42 @@
+// internal/handler/events_handler_test.go
+package handler
+
+import (
+	"testing"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Open streams re-check access: a member who is removed, or whose session
+// is deleted, loses the stream; a role change that keeps access does not.
+func TestStreamAccessLost(t *testing.T) {
+	db := newFakeDB()
+	owner, member := uuid.New(), uuid.New()
+	sessionID := db.addSession(owner)
+	db.addMember(sessionID, member, models.RoleEditor)
+	h := newTestHandler(db)
+
+	if h.streamAccessLost(sessionID, member, uuid.Nil) {
+		t.Fatal("editor member lost access")
+	}
+
+	db.addMember(sessionID, member, models.RoleViewer)
+	if h.streamAccessLost(sessionID, member, uuid.Nil) {
+		t.Fatal("downgraded to viewer — still allowed to watch")
+	}
+
+	db.removeMember(sessionID, member)
+	if !h.streamAccessLost(sessionID, member, uuid.Nil) {
+		t.Fatal("removed member kept access")
+	}
+	if h.streamAccessLost(sessionID, owner, uuid.Nil) {
+		t.Fatal("owner lost access")
+	}
+
+	db.deleteSession(sessionID)
+	if !h.streamAccessLost(sessionID, owner, uuid.Nil) {
+		t.Fatal("deleted session kept streaming")
+	}
+}
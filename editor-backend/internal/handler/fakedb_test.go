@@ -0,0 +1,980 @@
+// internal/handler/fakedb_test.go
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// fakeDB is an in-memory stand-in for Postgres, just big enough for the
+// session, export, live, undo, comment, approval and tus handlers: it answers the services' statements by
+// shape and keeps the access rules canEditSQL encodes (owner or editor
+// member, scoped to the caller's workspace). Role decisions beyond that —
+// sessionRole, authorize — run for real against what it returns. Anything it doesn't
+// recognise fails the query, so a new statement on these paths shows up as
+// a 500. pg_notify hands the event to broker, when set.
+type fakeDB struct {
+	mu          sync.Mutex
+	sessions    map[uuid.UUID]*fakeSession
+	workspaces  map[uuid.UUID]map[uuid.UUID]string // workspace → user → role
+	jobs        map[uuid.UUID]*fakeJob
+	broker      *events.Broker
+	nextEntryID int64 // editor_session_undo.entry_id
+	nextTransID int64 // editor_session_transitions.transition_id
+	uploads     map[uuid.UUID]*models.Upload
+	parts       map[uuid.UUID][]fakePart // editor_upload_parts by upload
+	assets      []models.Asset
+	comments    []*models.Comment // editor_comments, oldest first
+}
+
+type fakeSession struct {
+	owner     uuid.UUID
+	workspace uuid.UUID            // uuid.Nil = personal space
+	members   map[uuid.UUID]string // user → session role
+	version   int
+	status    string
+	timeline  []byte
+	ops       []fakeOp   // editor_session_ops
+	undo      []fakeStep // editor_session_undo, oldest first
+
+	transitions []models.SessionTransition // editor_session_transitions, oldest first
+}
+
+type fakeOp struct {
+	version    int
+	userID     uuid.UUID
+	clientOpID string
+	op         []byte
+}
+
+type fakeStep struct {
+	entryID  int64
+	stack    string
+	version  int
+	timeline []byte
+}
+
+type fakePart struct {
+	offset int64
+	key    string
+}
+
+type fakeJob struct {
+	jobID, sessionID, userID uuid.UUID
+	status                   string
+	version                  int    // the snapshot taken at enqueue
+	timeline                 []byte // (zero / nil on jobs made with addJob)
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{
+		sessions:   map[uuid.UUID]*fakeSession{},
+		workspaces: map[uuid.UUID]map[uuid.UUID]string{},
+		jobs:       map[uuid.UUID]*fakeJob{},
+		uploads:    map[uuid.UUID]*models.Upload{},
+		parts:      map[uuid.UUID][]fakePart{},
+	}
+}
+
+// open returns a *sql.DB backed by f.
+func (f *fakeDB) open() *sql.DB {
+	return sql.OpenDB(fakeConnector{f})
+}
+
+func (f *fakeDB) addSession(owner uuid.UUID) uuid.UUID {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := uuid.New()
+	f.sessions[id] = &fakeSession{owner: owner, members: map[uuid.UUID]string{}, version: 1, status: "draft"}
+	return id
+}
+
+// addWorkspaceSession adds a session owned by owner in workspace.
+func (f *fakeDB) addWorkspaceSession(owner, workspace uuid.UUID) uuid.UUID {
+	id := f.addSession(owner)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[id].workspace = workspace
+	return id
+}
+
+func (f *fakeDB) addWorkspaceMember(workspace, userID uuid.UUID, role string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.workspaces[workspace] == nil {
+		f.workspaces[workspace] = map[uuid.UUID]string{}
+	}
+	f.workspaces[workspace][userID] = role
+}
+
+func (f *fakeDB) setStatus(sessionID uuid.UUID, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[sessionID].status = status
+}
+
+func (f *fakeDB) status(sessionID uuid.UUID) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sessions[sessionID].status
+}
+
+func (f *fakeDB) setTimeline(sessionID uuid.UUID, timeline string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[sessionID].timeline = []byte(timeline)
+}
+
+func (f *fakeDB) addMember(sessionID, userID uuid.UUID, role string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions[sessionID].members[userID] = role
+}
+
+func (f *fakeDB) removeMember(sessionID, userID uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions[sessionID].members, userID)
+}
+
+func (f *fakeDB) deleteSession(sessionID uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.sessions, sessionID)
+}
+
+func (f *fakeDB) addJob(sessionID, userID uuid.UUID, status string) uuid.UUID {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := uuid.New()
+	f.jobs[id] = &fakeJob{jobID: id, sessionID: sessionID, userID: userID, status: status}
+	return id
+}
+
+// stack returns the versions on one of the session's undo stacks, oldest
+// first.
+func (f *fakeDB) stack(sessionID uuid.UUID, stack string) []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var versions []int
+	for _, step := range f.sessions[sessionID].undo {
+		if step.stack == stack {
+			versions = append(versions, step.version)
+		}
+	}
+	return versions
+}
+
+// upload returns a copy of the editor_uploads row.
+func (f *fakeDB) upload(uploadID uuid.UUID) models.Upload {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.uploads[uploadID]
+}
+
+func (f *fakeDB) expireUpload(uploadID uuid.UUID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads[uploadID].ExpiresAt = time.Now().Add(-time.Minute)
+}
+
+// comment returns a copy of the editor_comments row, nil once deleted.
+func (f *fakeDB) comment(commentID uuid.UUID) *models.Comment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.comments {
+		if c.CommentID == commentID {
+			copied := *c
+			return &copied
+		}
+	}
+	return nil
+}
+
+// canEdit mirrors canEditSQL; workspace is the caller's workspace argument
+// (nil = personal space).
+func (f *fakeDB) canEdit(sessionID, userID uuid.UUID, workspace driver.Value) (*fakeSession, bool) {
+	s, ok := f.sessions[sessionID]
+	if !ok || s.workspace != argUUID(workspace) {
+		return nil, false
+	}
+	direct := s.owner == userID || s.members[userID] == "editor"
+	if s.workspace == uuid.Nil {
+		return s, direct
+	}
+	role, ok := f.workspaces[s.workspace][userID]
+	return s, ok && (role == "admin" || role == "member" || direct)
+}
+
+// uuidPtr reads a nullable UUID argument (a workspace, a parent comment).
+func uuidPtr(v driver.Value) *uuid.UUID {
+	if v == nil {
+		return nil
+	}
+	id := argUUID(v)
+	return &id
+}
+
+// nullable is id as a column value, NULL for uuid.Nil.
+func nullable(id uuid.UUID) driver.Value {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id.String()
+}
+
+func (f *fakeDB) query(q string, args []driver.Value) (driver.Rows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	// sessionRole
+	case strings.Contains(q, "LEFT JOIN editor_session_members m"):
+		s, ok := f.sessions[argUUID(args[0])]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		var role, workspaceRole driver.Value
+		if r, ok := s.members[argUUID(args[1])]; ok {
+			role = r
+		}
+		if r, ok := f.workspaces[s.workspace][argUUID(args[1])]; ok && s.workspace != uuid.Nil {
+			workspaceRole = r
+		}
+		return rowsOf([]driver.Value{s.owner.String(), nullable(s.workspace), role, workspaceRole}), nil
+
+	// listSessions, without filters: the caller's own and shared sessions in
+	// a workspace, or every session in one
+	case strings.Contains(q, "export_job_id") && strings.Contains(q, "ORDER BY updated_at"):
+		workspace := argUUID(args[0])
+		var ids []uuid.UUID
+		for id, s := range f.sessions {
+			if s.workspace != workspace {
+				continue
+			}
+			if strings.Contains(q, "IS NOT DISTINCT FROM") {
+				if _, member := s.members[argUUID(args[1])]; s.owner != argUUID(args[1]) && !member {
+					continue
+				}
+			}
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+		rows := &fakeRows{}
+		for _, id := range ids {
+			rows.rows = append(rows.rows, f.sessions[id].row(id))
+		}
+		return rows, nil
+
+	// SessionService.getSession
+	case strings.Contains(q, "export_job_id") && strings.Contains(q, "FROM editor_sessions"):
+		id := argUUID(args[0])
+		s, ok := f.sessions[id]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf(s.row(id)), nil
+
+	// Row lock before a patch or undo: SELECT timeline, version ... FOR UPDATE
+	case strings.Contains(q, "SELECT timeline, version") && strings.Contains(q, "FOR UPDATE"):
+		s, ok := f.canEdit(argUUID(args[0]), argUUID(args[1]), args[2])
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf([]driver.Value{s.timeline, int64(s.version)}), nil
+
+	// Row lock before applying ops: SELECT timeline, status, version ... FOR UPDATE
+	case strings.Contains(q, "SELECT timeline, status, version") && strings.Contains(q, "FOR UPDATE"):
+		s, ok := f.canEdit(argUUID(args[0]), argUUID(args[1]), args[2])
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf([]driver.Value{s.timeline, s.status, int64(s.version)}), nil
+
+	// ApplyOperations' write
+	case strings.Contains(q, "UPDATE editor_sessions") && strings.Contains(q, "RETURNING version, status, updated_at"):
+		s := f.sessions[argUUID(args[0])]
+		s.write(args[1].([]byte), int(args[2].(int64)))
+		return rowsOf([]driver.Value{int64(s.version), s.status, time.Now()}), nil
+
+	// SessionService.saveTimeline
+	case strings.Contains(q, "UPDATE editor_sessions") && strings.Contains(q, "(SELECT timeline FROM editor_sessions"):
+		s, ok := f.canEdit(argUUID(args[1]), argUUID(args[2]), args[4])
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		if expected := args[3].(int64); expected != 0 && int(expected) != s.version {
+			return &fakeRows{}, nil
+		}
+		previousStatus, previousTimeline := s.status, s.timeline
+		s.write(args[0].([]byte), 1)
+		return rowsOf([]driver.Value{int64(s.version), s.status, previousStatus, previousTimeline}), nil
+
+	// markExported: approved → exported
+	case strings.Contains(q, "UPDATE editor_sessions") && strings.Contains(q, "WHERE session_id = $1 AND status = $3"):
+		s, ok := f.sessions[argUUID(args[0])]
+		if !ok || s.status != args[2].(string) {
+			return &fakeRows{}, nil
+		}
+		s.status = args[1].(string)
+		return rowsOf([]driver.Value{int64(s.version)}), nil
+
+	// popUndo's write, under the row lock it already holds
+	case strings.Contains(q, "UPDATE editor_sessions") && strings.Contains(q, "RETURNING version"):
+		s := f.sessions[argUUID(args[0])]
+		previousStatus := s.status
+		s.write(args[1].([]byte), 1)
+		return rowsOf([]driver.Value{int64(s.version), s.status, previousStatus}), nil
+
+	// popUndo: the top entry of a stack, and both stacks' depths
+	case strings.Contains(q, "SELECT entry_id, timeline") && strings.Contains(q, "FROM editor_session_undo"):
+		s := f.sessions[argUUID(args[0])]
+		for i := len(s.undo) - 1; i >= 0; i-- {
+			if s.undo[i].stack == args[1].(string) {
+				return rowsOf([]driver.Value{s.undo[i].entryID, s.undo[i].timeline}), nil
+			}
+		}
+		return &fakeRows{}, nil
+
+	case strings.Contains(q, "FROM editor_session_undo"):
+		var undo, redo int64
+		for _, step := range f.sessions[argUUID(args[0])].undo {
+			if step.stack == "undo" {
+				undo++
+			} else {
+				redo++
+			}
+		}
+		return rowsOf([]driver.Value{undo, redo}), nil
+
+	// Approval workflow: TransitionStatus' row lock, the audit trail, and
+	// Enqueue's look at a session it refused
+	case strings.Contains(q, "SELECT status, version FROM editor_sessions") && strings.Contains(q, "FOR UPDATE"):
+		s, ok := f.sessions[argUUID(args[0])]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf([]driver.Value{s.status, int64(s.version)}), nil
+
+	case strings.Contains(q, "SELECT status FROM editor_sessions"):
+		s, ok := f.sessions[argUUID(args[0])]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf([]driver.Value{s.status}), nil
+
+	case strings.Contains(q, "INSERT INTO editor_session_transitions"):
+		s := f.sessions[argUUID(args[0])]
+		f.nextTransID++
+		t := models.SessionTransition{
+			TransitionID: f.nextTransID, SessionID: argUUID(args[0]),
+			FromStatus: args[1].(string), ToStatus: args[2].(string), UserID: uuidPtr(args[3]),
+			Version: int(args[4].(int64)), Note: args[5].(string), CreatedAt: time.Now(),
+		}
+		s.transitions = append(s.transitions, t)
+		return rowsOf([]driver.Value{t.TransitionID, t.CreatedAt}), nil
+
+	case strings.Contains(q, "FROM editor_session_transitions"):
+		rows := &fakeRows{}
+		s := f.sessions[argUUID(args[0])]
+		for i := len(s.transitions) - 1; i >= 0; i-- {
+			t := s.transitions[i]
+			var userID driver.Value
+			if t.UserID != nil {
+				userID = t.UserID.String()
+			}
+			rows.rows = append(rows.rows, []driver.Value{
+				t.TransitionID, t.SessionID.String(), t.FromStatus, t.ToStatus, userID, int64(t.Version), t.Note, t.CreatedAt,
+			})
+		}
+		return rows, nil
+
+	// Members: AddMember, UpdateMemberRole, ListMembers
+	case strings.Contains(q, "INSERT INTO editor_session_members"):
+		s := f.sessions[argUUID(args[0])]
+		if _, ok := s.members[argUUID(args[1])]; ok {
+			return nil, &pq.Error{Code: "23505"}
+		}
+		s.members[argUUID(args[1])] = args[2].(string)
+		return rowsOf([]driver.Value{time.Now(), time.Now()}), nil
+
+	case strings.Contains(q, "UPDATE editor_session_members"):
+		s := f.sessions[argUUID(args[0])]
+		if _, ok := s.members[argUUID(args[1])]; !ok {
+			return &fakeRows{}, nil
+		}
+		s.members[argUUID(args[1])] = args[2].(string)
+		return rowsOf([]driver.Value{nil, time.Now(), time.Now()}), nil
+
+	case strings.Contains(q, "UNION ALL") && strings.Contains(q, "FROM editor_session_members"):
+		id := argUUID(args[0])
+		s := f.sessions[id]
+		now := time.Now()
+		rows := rowsOf([]driver.Value{id.String(), s.owner.String(), "owner", nil, now, now})
+		for user, role := range s.members {
+			rows.rows = append(rows.rows, []driver.Value{id.String(), user.String(), role, nil, now, now})
+		}
+		return rows, nil
+
+	// workspaceRole
+	case strings.Contains(q, "SELECT role FROM workspace_members"):
+		role, ok := f.workspaces[argUUID(args[0])][argUUID(args[1])]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf([]driver.Value{role}), nil
+
+	// OperationsSince: the current version, then the logged ops after since
+	case strings.Contains(q, "SELECT version FROM editor_sessions"):
+		s, ok := f.sessions[argUUID(args[0])]
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		return rowsOf([]driver.Value{int64(s.version)}), nil
+
+	case strings.Contains(q, "FROM editor_session_ops"):
+		id := argUUID(args[0])
+		rows := &fakeRows{}
+		for _, op := range f.sessions[id].ops {
+			if op.version > int(args[1].(int64)) {
+				rows.rows = append(rows.rows, []driver.Value{
+					id.String(), int64(op.version), op.userID.String(), op.clientOpID, op.op, time.Now(),
+				})
+			}
+		}
+		return rows, nil
+
+	// ExportJobService.Enqueue
+	case strings.Contains(q, "INSERT INTO export_jobs"):
+		sessionID, userID := argUUID(args[0]), argUUID(args[1])
+		s, ok := f.canEdit(sessionID, userID, args[3])
+		if !ok {
+			return &fakeRows{}, nil
+		}
+		if args[4].(bool) && s.status != "approved" && s.status != "exported" {
+			return &fakeRows{}, nil
+		}
+		for _, j := range f.jobs {
+			if j.sessionID == sessionID && (j.status == "queued" || j.status == "running") {
+				return &fakeRows{}, nil
+			}
+		}
+		id := uuid.New()
+		f.jobs[id] = &fakeJob{
+			jobID: id, sessionID: sessionID, userID: userID, status: "queued",
+			version: s.version, timeline: s.timeline,
+		}
+		return rowsOf(f.jobs[id].row()), nil
+
+	// ExportJobService.Cancel
+	case strings.Contains(q, "UPDATE export_jobs") && strings.Contains(q, "cancel_requested = TRUE"):
+		j, ok := f.jobs[argUUID(args[0])]
+		if !ok || (j.status != "queued" && j.status != "running") {
+			return &fakeRows{}, nil
+		}
+		if j.status == "queued" {
+			j.status = "cancelled"
+		}
+		return rowsOf(j.row()), nil
+
+	// ExportJobService.getJob / activeJobForSession
+	case strings.Contains(q, "FROM export_jobs"):
+		for _, j := range f.jobs {
+			if strings.Contains(q, "WHERE job_id = $1") && j.jobID == argUUID(args[0]) ||
+				strings.Contains(q, "WHERE session_id = $1") && j.sessionID == argUUID(args[0]) &&
+					(j.status == "queued" || j.status == "running") {
+				return rowsOf(j.row()), nil
+			}
+		}
+		return &fakeRows{}, nil
+
+	// Comments: create, load one, load a thread's replies
+	case strings.Contains(q, "INSERT INTO editor_comments"):
+		c := &models.Comment{
+			CommentID: uuid.New(), SessionID: argUUID(args[0]), ParentID: uuidPtr(args[1]),
+			Start: args[2].(float64), End: args[3].(float64), ClipDeleted: args[5].(bool),
+			Body: args[6].(string), Mentions: argUUIDs(args[7]), AuthorID: uuidPtr(args[8]),
+			AuthorName: args[9].(string), ShareID: uuidPtr(args[10]),
+			CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		}
+		if clipID, ok := args[4].(string); ok {
+			c.ClipID = &clipID
+		}
+		f.comments = append(f.comments, c)
+		return rowsOf(commentRow(c)), nil
+
+	case strings.Contains(q, "FROM editor_comments") && strings.Contains(q, "WHERE comment_id = $1 AND session_id = $2"):
+		for _, c := range f.comments {
+			if c.CommentID == argUUID(args[0]) && c.SessionID == argUUID(args[1]) {
+				return rowsOf(commentRow(c)), nil
+			}
+		}
+		return &fakeRows{}, nil
+
+	case strings.Contains(q, "FROM editor_comments") && strings.Contains(q, "parent_id = ANY($1::uuid[])"):
+		rows := &fakeRows{}
+		for _, parent := range argUUIDs(args[0]) {
+			for _, c := range f.comments {
+				if c.ParentID != nil && *c.ParentID == parent {
+					rows.rows = append(rows.rows, commentRow(c))
+				}
+			}
+		}
+		return rows, nil
+
+	// Tus uploads: create, load, complete
+	case strings.Contains(q, "INSERT INTO editor_uploads"):
+		u := &models.Upload{
+			UploadID: uuid.New(), WorkspaceID: uuidPtr(args[0]), UserID: argUUID(args[1]),
+			Filename: args[2].(string), ContentType: args[3].(string), SizeBytes: args[4].(int64),
+			Protocol: args[5].(string), Status: models.UploadStatusPending,
+			ExpiresAt: args[6].(time.Time), CreatedAt: time.Now(),
+		}
+		f.uploads[u.UploadID] = u
+		return rowsOf(uploadRow(u)), nil
+
+	case strings.Contains(q, "FROM editor_uploads WHERE upload_id = $1 AND user_id = $2"):
+		u, ok := f.uploads[argUUID(args[0])]
+		if !ok || u.UserID != argUUID(args[1]) {
+			return &fakeRows{}, nil
+		}
+		return rowsOf(uploadRow(u)), nil
+
+	case strings.Contains(q, "UPDATE editor_uploads") && strings.Contains(q, "RETURNING"):
+		u, ok := f.uploads[argUUID(args[0])]
+		if !ok || u.Status != args[4].(string) {
+			return &fakeRows{}, nil
+		}
+		assetID, now := argUUID(args[3]), time.Now()
+		u.Status, u.StorageKey, u.AssetID, u.CompletedAt = args[1].(string), args[2].(string), &assetID, &now
+		return rowsOf(uploadRow(u)), nil
+
+	case strings.Contains(q, "FROM editor_upload_parts"):
+		rows := &fakeRows{}
+		for _, part := range f.parts[argUUID(args[0])] {
+			rows.rows = append(rows.rows, []driver.Value{part.key})
+		}
+		return rows, nil
+
+	case strings.Contains(q, "INSERT INTO editor_assets"):
+		asset := models.Asset{
+			AssetID: uuid.New(), UserID: argUUID(args[1]), FileURL: args[2].(string),
+			Filename: args[3].(string), ContentType: args[4].(string), SizeBytes: args[5].(int64),
+			CreatedAt: time.Now(),
+		}
+		f.assets = append(f.assets, asset)
+		return rowsOf([]driver.Value{asset.AssetID.String(), asset.CreatedAt}), nil
+	}
+	return nil, fmt.Errorf("fakeDB: unexpected query: %s", q)
+}
+
+func (f *fakeDB) exec(q string, args []driver.Value) (driver.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	// events.Publisher
+	case strings.Contains(q, "pg_notify"):
+		if f.broker != nil {
+			var ev events.Event
+			if err := json.Unmarshal([]byte(args[1].(string)), &ev); err != nil {
+				return nil, err
+			}
+			f.broker.Deliver(ev)
+		}
+		return driver.RowsAffected(1), nil
+
+	// ApplyOperations' op log and its pruning
+	case strings.Contains(q, "INSERT INTO editor_session_ops"):
+		s := f.sessions[argUUID(args[0])]
+		s.ops = append(s.ops, fakeOp{
+			version: int(args[1].(int64)), userID: argUUID(args[2]),
+			clientOpID: args[3].(string), op: args[4].([]byte),
+		})
+		return driver.RowsAffected(1), nil
+
+	case strings.Contains(q, "DELETE FROM editor_session_ops"):
+		s := f.sessions[argUUID(args[0])]
+		kept := s.ops[:0]
+		for _, op := range s.ops {
+			if op.version > int(args[1].(int64)) {
+				kept = append(kept, op)
+			}
+		}
+		s.ops = kept
+		return driver.RowsAffected(0), nil
+
+	// RemoveMember, and TransferOwnership's member swap
+	case strings.Contains(q, "DELETE FROM editor_session_members"):
+		s, user := f.sessions[argUUID(args[0])], argUUID(args[1])
+		if _, ok := s.members[user]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		delete(s.members, user)
+		return driver.RowsAffected(1), nil
+
+	case strings.Contains(q, "INSERT INTO editor_session_members"):
+		f.sessions[argUUID(args[0])].members[argUUID(args[1])] = "editor"
+		return driver.RowsAffected(1), nil
+
+	// TransferOwnership: the caller owns it in their workspace, the new owner
+	// is a member (and, in a workspace, a workspace member)
+	case strings.Contains(q, "SET user_id = $3"):
+		s, ok := f.canEdit(argUUID(args[0]), argUUID(args[1]), args[3])
+		if !ok || s.owner != argUUID(args[1]) {
+			return driver.RowsAffected(0), nil
+		}
+		newOwner := argUUID(args[2])
+		if _, member := s.members[newOwner]; !member {
+			return driver.RowsAffected(0), nil
+		}
+		if _, in := f.workspaces[s.workspace][newOwner]; s.workspace != uuid.Nil && !in {
+			return driver.RowsAffected(0), nil
+		}
+		s.owner = newOwner
+		return driver.RowsAffected(1), nil
+
+	// SessionService.DeleteSession — owner only
+	case strings.Contains(q, "DELETE FROM editor_sessions"):
+		id := argUUID(args[0])
+		if s, ok := f.sessions[id]; ok && s.owner == argUUID(args[1]) {
+			delete(f.sessions, id)
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+
+	// TransitionStatus' write, under the row lock
+	case strings.Contains(q, "UPDATE editor_sessions SET status = $2"):
+		f.sessions[argUUID(args[0])].status = args[1].(string)
+		return driver.RowsAffected(1), nil
+
+	// Export status bookkeeping (MarkExportQueued, UpdateExportStatus)
+	case strings.Contains(q, "SET export_status"):
+		if _, ok := f.canEdit(argUUID(args[2]), argUUID(args[3]), args[4]); ok {
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+
+	// Undo stacks: push, trim to depth, pop, clear redo
+	case strings.Contains(q, "INSERT INTO editor_session_undo"):
+		s := f.sessions[argUUID(args[0])]
+		f.nextEntryID++
+		s.undo = append(s.undo, fakeStep{
+			entryID: f.nextEntryID, stack: args[1].(string),
+			version: int(args[2].(int64)), timeline: args[3].([]byte),
+		})
+		return driver.RowsAffected(1), nil
+
+	case strings.Contains(q, "DELETE FROM editor_session_undo"):
+		var keep func(i int, step fakeStep) bool
+		var s *fakeSession
+		switch {
+		case strings.Contains(q, "WHERE entry_id = $1"):
+			for _, session := range f.sessions {
+				for _, step := range session.undo {
+					if step.entryID == args[0].(int64) {
+						s = session
+					}
+				}
+			}
+			keep = func(_ int, step fakeStep) bool { return step.entryID != args[0].(int64) }
+		case strings.Contains(q, "LIMIT $3"):
+			// Keep the newest depth entries of the stack
+			s = f.sessions[argUUID(args[0])]
+			newer := map[int]int{}
+			for i := range s.undo {
+				for _, later := range s.undo[i+1:] {
+					if later.stack == s.undo[i].stack {
+						newer[i]++
+					}
+				}
+			}
+			keep = func(i int, step fakeStep) bool {
+				return step.stack != args[1].(string) || int64(newer[i]) < args[2].(int64)
+			}
+		default:
+			s = f.sessions[argUUID(args[0])]
+			keep = func(_ int, step fakeStep) bool { return step.stack != args[1].(string) }
+		}
+		if s == nil {
+			return driver.RowsAffected(0), nil
+		}
+		var kept []fakeStep
+		for i, step := range s.undo {
+			if keep(i, step) {
+				kept = append(kept, step)
+			}
+		}
+		affected := int64(len(s.undo) - len(kept))
+		s.undo = kept
+		return driver.RowsAffected(affected), nil
+
+	// Tus uploads: a stored part moves the offset, if nobody moved it first
+	case strings.Contains(q, "SET upload_offset = upload_offset + $3"):
+		u, ok := f.uploads[argUUID(args[0])]
+		if !ok || u.Offset != args[1].(int64) || u.Status != args[3].(string) {
+			return driver.RowsAffected(0), nil
+		}
+		u.Offset += args[2].(int64)
+		return driver.RowsAffected(1), nil
+
+	case strings.Contains(q, "INSERT INTO editor_upload_parts"):
+		id := argUUID(args[0])
+		f.parts[id] = append(f.parts[id], fakePart{offset: args[1].(int64), key: args[3].(string)})
+		sort.Slice(f.parts[id], func(i, j int) bool { return f.parts[id][i].offset < f.parts[id][j].offset })
+		return driver.RowsAffected(1), nil
+
+	case strings.Contains(q, "DELETE FROM editor_upload_parts"):
+		delete(f.parts, argUUID(args[0]))
+		return driver.RowsAffected(0), nil
+
+	case strings.Contains(q, "UPDATE editor_uploads SET status"):
+		u, ok := f.uploads[argUUID(args[0])]
+		if !ok || u.Status != args[2].(string) {
+			return driver.RowsAffected(0), nil
+		}
+		now := time.Now()
+		u.Status, u.CompletedAt = args[1].(string), &now
+		return driver.RowsAffected(1), nil
+
+	case strings.Contains(q, "DELETE FROM editor_uploads"):
+		delete(f.uploads, argUUID(args[0]))
+		return driver.RowsAffected(1), nil
+
+	// Comments: the author's edit, resolve, reopen, delete (with the thread's
+	// replies, as ON DELETE CASCADE does)
+	case strings.Contains(q, "UPDATE editor_comments") && strings.Contains(q, "SET body = $4"):
+		for _, c := range f.comments {
+			if c.CommentID == argUUID(args[0]) && c.SessionID == argUUID(args[1]) &&
+				c.AuthorID != nil && *c.AuthorID == argUUID(args[2]) {
+				c.Body, c.Mentions, c.UpdatedAt = args[3].(string), argUUIDs(args[4]), time.Now()
+				return driver.RowsAffected(1), nil
+			}
+		}
+		return driver.RowsAffected(0), nil
+
+	case strings.Contains(q, "UPDATE editor_comments") && strings.Contains(q, "SET resolved_at"):
+		for _, c := range f.comments {
+			if c.CommentID != argUUID(args[0]) {
+				continue
+			}
+			if strings.Contains(q, "resolved_at = NULL") {
+				c.ResolvedAt, c.ResolvedBy = nil, nil
+			} else if c.ResolvedAt == nil {
+				now, by := time.Now(), argUUID(args[1])
+				c.ResolvedAt, c.ResolvedBy = &now, &by
+			}
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+
+	case strings.Contains(q, "DELETE FROM editor_comments"):
+		id := argUUID(args[0])
+		var kept []*models.Comment
+		for _, c := range f.comments {
+			if c.CommentID != id && (c.ParentID == nil || *c.ParentID != id) {
+				kept = append(kept, c)
+			}
+		}
+		affected := int64(len(f.comments) - len(kept))
+		f.comments = kept
+		return driver.RowsAffected(affected), nil
+
+	// Save side tables: version history, comment flags
+	case strings.Contains(q, "editor_session_versions"),
+		strings.Contains(q, "editor_comments"):
+		return driver.RowsAffected(0), nil
+	}
+	return nil, fmt.Errorf("fakeDB: unexpected statement: %s", q)
+}
+
+// write replaces the timeline and bumps the version the way the services'
+// UPDATEs do — an approved session goes back to in_review.
+func (s *fakeSession) write(timeline []byte, versions int) {
+	s.timeline = timeline
+	s.version += versions
+	if s.status == "approved" {
+		s.status = "in_review"
+	}
+}
+
+// row is the session in sessionSelectColumns order.
+func (s *fakeSession) row(id uuid.UUID) []driver.Value {
+	now := time.Now()
+	return []driver.Value{
+		id.String(), s.owner.String(), uuid.NewString(), nullable(s.workspace), s.timeline, int64(s.version), s.status,
+		nil, nil, nil, nil,
+		nil, nil, nil, nil,
+		now, now,
+	}
+}
+
+// uploadRow is the upload in uploadColumns order.
+func uploadRow(u *models.Upload) []driver.Value {
+	var assetID, completedAt driver.Value
+	if u.AssetID != nil {
+		assetID = u.AssetID.String()
+	}
+	if u.CompletedAt != nil {
+		completedAt = *u.CompletedAt
+	}
+	return []driver.Value{
+		u.UploadID.String(), nullable(service.WorkspaceOf(u.WorkspaceID)), u.UserID.String(), u.StorageKey, u.Filename, u.ContentType,
+		u.SizeBytes, u.Protocol, u.Offset, u.Status, assetID,
+		u.ExpiresAt, u.CreatedAt, completedAt,
+	}
+}
+
+// commentRow is the comment in commentSelectColumns order.
+func commentRow(c *models.Comment) []driver.Value {
+	var parentID, clipID, authorID, shareID, resolvedAt, resolvedBy driver.Value
+	if c.ParentID != nil {
+		parentID = c.ParentID.String()
+	}
+	if c.ClipID != nil {
+		clipID = *c.ClipID
+	}
+	if c.AuthorID != nil {
+		authorID = c.AuthorID.String()
+	}
+	if c.ShareID != nil {
+		shareID = c.ShareID.String()
+	}
+	if c.ResolvedAt != nil {
+		resolvedAt, resolvedBy = *c.ResolvedAt, c.ResolvedBy.String()
+	}
+	mentions := make([]string, len(c.Mentions))
+	for i, m := range c.Mentions {
+		mentions[i] = m.String()
+	}
+	return []driver.Value{
+		c.CommentID.String(), c.SessionID.String(), parentID, c.Start, c.End, clipID, c.ClipDeleted,
+		c.Body, "{" + strings.Join(mentions, ",") + "}", authorID, c.AuthorName, shareID, resolvedAt, resolvedBy,
+		c.CreatedAt, c.UpdatedAt,
+	}
+}
+
+// row is the job in exportJobSelectColumns order.
+func (j *fakeJob) row() []driver.Value {
+	now := time.Now()
+	var version driver.Value
+	if j.timeline != nil {
+		version = int64(j.version)
+	}
+	return []driver.Value{
+		j.jobID.String(), j.sessionID.String(), j.userID.String(), nil, version, j.status,
+		int64(0), int64(3), now,
+		nil, nil,
+		float64(0), nil, j.status == "cancelled",
+		"", "",
+		now, now, nil, nil,
+	}
+}
+
+func argUUID(v driver.Value) uuid.UUID {
+	switch v := v.(type) {
+	case string:
+		return uuid.MustParse(v)
+	case []byte:
+		return uuid.MustParse(string(v))
+	}
+	return uuid.Nil
+}
+
+// argUUIDs reads a pq.Array of UUIDs ("{a,b}").
+func argUUIDs(v driver.Value) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, s := range strings.Split(strings.Trim(v.(string), "{}"), ",") {
+		if s = strings.Trim(s, `"`); s != "" {
+			ids = append(ids, uuid.MustParse(s))
+		}
+	}
+	return ids
+}
+
+// ── database/sql plumbing ─────────────────────────────────────────────────────
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, fmt.Errorf("fakeDB: use sql.OpenDB")
+}
+
+type fakeConn fakeConnector
+
+func (c fakeConn) Prepare(q string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakeDB: prepared statements not supported")
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+// BeginTx accepts any isolation level — there is only one connection's worth
+// of state anyway.
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(_ context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(q, values(args))
+}
+
+func (c fakeConn) ExecContext(_ context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	return c.db.exec(q, values(args))
+}
+
+// Writes apply immediately — the handler tests don't exercise rollbacks.
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func values(named []driver.NamedValue) []driver.Value {
+	out := make([]driver.Value, len(named))
+	for i, nv := range named {
+		out[i] = nv.Value
+	}
+	return out
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func rowsOf(row []driver.Value) *fakeRows { return &fakeRows{rows: [][]driver.Value{row}} }
+
+// Columns only needs the right count — Scan is positional.
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
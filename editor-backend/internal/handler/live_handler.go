@@ -0,0 +1,356 @@
+// internal/handler/live_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/service"
+	"editor-backend/internal/timeline"
+	"editor-backend/internal/validation"
+	"editor-backend/internal/websocket"
+
+	"github.com/google/uuid"
+)
+
+const (
+	livePing          = 30 * time.Second // server ping + presence heartbeat
+	liveIdleTimeout   = 75 * time.Second // no frame (not even a pong) for this long → dead
+	livePresenceFlush = 250 * time.Millisecond
+)
+
+// Presence actions carried in TypePresence events.
+const (
+	presenceJoin   = "join"   // connection opened — everyone answers with "here"
+	presenceHere   = "here"   // heartbeat / answer to a join
+	presenceUpdate = "update" // playhead or selection moved
+	presenceLeave  = "leave"  // connection closed
+)
+
+// liveMessage is what the client sends over the socket.
+type liveMessage struct {
+	Type string `json:"type"` // "op" | "presence" | "sync"
+
+	// op
+	ClientOpID string       `json:"client_op_id"`
+	Op         *timeline.Op `json:"op"`
+
+	// presence
+	Playhead       *float64 `json:"playhead"`
+	SelectedClipID *string  `json:"selected_clip_id"`
+
+	// sync
+	Since int `json:"since"`
+}
+
+// presenceState is one connection's cursor, published to the session.
+type presenceState struct {
+	ConnID         uuid.UUID `json:"conn_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Action         string    `json:"action"`
+	Playhead       float64   `json:"playhead"`
+	SelectedClipID string    `json:"selected_clip_id,omitempty"`
+}
+
+// SessionLive is the collaborative editing socket. Clients send ops; the
+// server applies each one to the stored timeline under a row lock, so every
+// op gets the next version and all clients see the same order.
+//
+// Client → server (JSON text messages):
+//
+//	{ "type": "op", "client_op_id": "c-17", "op": { "type": "move_clip", "clip_id": "clip-3", "start": 12 } }
+//	{ "type": "presence", "playhead": 41.2, "selected_clip_id": "clip-3" }
+//	{ "type": "sync", "since": 42 }
+//
+// Server → client, all in the SSE event shape { type, session_id, data, at }:
+//   - ready        → { conn_id, user_id, role, version } — first message
+//   - ack          → { client_op_id, version } — your op was applied
+//   - reject       → { client_op_id, error, fields? } — it wasn't; roll it back
+//   - op.applied   → { version, user_id, client_op_id, op } — everyone's ops, yours included
+//   - presence     → { conn_id, user_id, action, playhead, selected_clip_id }
+//   - resync       → ops were missed and can't be replayed; refetch the session
+//   - access.revoked → you lost access; the socket closes with 1008
+//   - plus every SessionEvents event (session.saved, status.changed, ...)
+//
+// A client that reconnects passes ?since=<last version seen> and gets the
+// missed ops replayed (or resync). Apply op.applied events in version order;
+// after a session.saved, refetch — a full save is not an op.
+//
+// GET /api/v1/sessions/{id}/live[?since=42]   (WebSocket; auth may use ?access_token=)
+func (h *EditorHandler) SessionLive(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if h.Broker == nil {
+		respondError(w, http.StatusServiceUnavailable, "live events are not enabled")
+		return
+	}
+
+	since := -1
+	if v := r.URL.Query().Get("since"); v != "" {
+		since, err = strconv.Atoi(v)
+		if err != nil || since < 0 {
+			respondError(w, http.StatusBadRequest, "since must be a non-negative version")
+			return
+		}
+	}
+
+	workspaceID := getWorkspaceID(r)
+
+	// Same ordering as SessionEvents: subscribe first so nothing is lost
+	// between the read below and the socket opening
+	ch, unsubscribe := h.Broker.Subscribe(sessionID)
+	defer unsubscribe()
+
+	session, err := h.Service.GetSession(sessionID, userID, workspaceID)
+	if err != nil {
+		if respondAccessError(w, err) {
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "failed to get session")
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r, h.AllowedOrigins)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetIdleTimeout(liveIdleTimeout)
+
+	connID := uuid.New()
+	ready := map[string]interface{}{
+		"conn_id": connID,
+		"user_id": userID,
+		"role":    session.Role,
+		"version": session.Version,
+	}
+	if err := writeLive(conn, sessionID, "ready", ready); err != nil {
+		return
+	}
+
+	if since >= 0 && since < session.Version {
+		if err := h.replayOperations(conn, sessionID, userID, workspaceID, since); err != nil {
+			return
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		presence = presenceState{ConnID: connID, UserID: userID}
+		dirty    bool
+	)
+	publishPresence := func(action string) {
+		mu.Lock()
+		p := presence
+		p.Action = action
+		dirty = false
+		mu.Unlock()
+		h.Events.Publish(sessionID, events.TypePresence, p)
+	}
+
+	publishPresence(presenceJoin)
+	defer publishPresence(presenceLeave)
+
+	// Reader: ops are applied here, one at a time in arrival order, while
+	// the loop below keeps forwarding broker events
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var msg liveMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				if writeLive(conn, sessionID, "reject", map[string]string{"error": "invalid message"}) != nil {
+					return
+				}
+				continue
+			}
+
+			switch msg.Type {
+			case "op":
+				err = h.liveOperation(conn, sessionID, userID, workspaceID, msg)
+			case "presence":
+				mu.Lock()
+				if msg.Playhead != nil {
+					presence.Playhead = *msg.Playhead
+				}
+				if msg.SelectedClipID != nil {
+					presence.SelectedClipID = *msg.SelectedClipID
+				}
+				dirty = true
+				mu.Unlock()
+			case "sync":
+				err = h.replayOperations(conn, sessionID, userID, workspaceID, msg.Since)
+			default:
+				err = writeLive(conn, sessionID, "reject", map[string]string{"error": "unknown message type"})
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(livePing)
+	defer ping.Stop()
+	flush := time.NewTicker(livePresenceFlush)
+	defer flush.Stop()
+	recheck := time.NewTicker(streamAccessRecheck)
+	defer recheck.Stop()
+
+	// Access is re-checked like SessionEvents does; ops are authorized one
+	// by one anyway, this stops a removed member from still watching
+	revoke := func() {
+		writeLive(conn, sessionID, events.TypeAccessRevoked, nil)
+		conn.CloseWith(websocket.ClosePolicy, "access revoked")
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case ev, ok := <-ch:
+			if !ok {
+				// Broker shutting down — the client reconnects elsewhere with ?since
+				conn.CloseWith(websocket.CloseGoingAway, "server shutting down")
+				return
+			}
+			if ev.Type == events.TypeMembersChanged && h.streamAccessLost(sessionID, userID, workspaceID) {
+				revoke()
+				return
+			}
+			if ev.Type == events.TypePresence {
+				var p presenceState
+				if json.Unmarshal(ev.Data, &p) == nil {
+					if p.ConnID == connID {
+						continue // our own echo
+					}
+					if p.Action == presenceJoin {
+						publishPresence(presenceHere)
+					}
+				}
+			}
+			if err := writeLiveEvent(conn, ev); err != nil {
+				return
+			}
+
+		case <-flush.C:
+			mu.Lock()
+			changed := dirty
+			mu.Unlock()
+			if changed {
+				publishPresence(presenceUpdate)
+			}
+
+		case <-ping.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+			publishPresence(presenceHere)
+
+		case <-recheck.C:
+			if h.streamAccessLost(sessionID, userID, workspaceID) {
+				revoke()
+				return
+			}
+		}
+	}
+}
+
+// liveOperation applies one client op and answers ack or reject. Only a
+// failed write to the socket is returned.
+func (h *EditorHandler) liveOperation(conn *websocket.Conn, sessionID, userID, workspaceID uuid.UUID, msg liveMessage) error {
+	if msg.Op == nil {
+		return writeLive(conn, sessionID, "reject", map[string]string{
+			"client_op_id": msg.ClientOpID,
+			"error":        "op is required",
+		})
+	}
+
+	applied, err := h.Service.ApplyOperation(sessionID, userID, workspaceID, *msg.Op, msg.ClientOpID)
+	if err != nil {
+		reject := map[string]interface{}{"client_op_id": msg.ClientOpID}
+		var invalid *validation.TimelineError
+		switch {
+		case errors.Is(err, service.ErrInvalidOperation), err == service.ErrOperationTooLarge,
+			errors.Is(err, service.ErrTimelineUnreadable):
+			reject["error"] = err.Error()
+		case errors.As(err, &invalid):
+			reject["error"] = "invalid timeline"
+			reject["fields"] = invalid.Fields
+		case err == service.ErrSessionNotFound:
+			reject["error"] = "session not found"
+		case err == service.ErrUnauthorized, err == service.ErrInsufficientRole,
+			err == service.ErrWorkspaceAccess, err == service.ErrWorkspaceRole:
+			reject["error"] = "your role on this session does not allow editing"
+		default:
+			log.Println("ApplyOperation error:", err)
+			reject["error"] = "failed to apply operation"
+		}
+		return writeLive(conn, sessionID, "reject", reject)
+	}
+
+	return writeLive(conn, sessionID, "ack", map[string]interface{}{
+		"client_op_id": applied.ClientOpID,
+		"version":      applied.Version,
+	})
+}
+
+// replayOperations sends the ops after `since` as op.applied events, or a
+// resync when they can't be replayed.
+func (h *EditorHandler) replayOperations(conn *websocket.Conn, sessionID, userID, workspaceID uuid.UUID, since int) error {
+	ops, err := h.Service.OperationsSince(sessionID, userID, workspaceID, since)
+	if err != nil {
+		if err != service.ErrOperationsPruned {
+			log.Println("OperationsSince error:", err)
+		}
+		return writeLive(conn, sessionID, events.TypeResync, nil)
+	}
+
+	for _, op := range ops {
+		if err := writeLive(conn, sessionID, events.TypeOperation, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeLive(conn *websocket.Conn, sessionID uuid.UUID, eventType string, data interface{}) error {
+	ev := events.Event{Type: eventType, SessionID: sessionID, At: time.Now().UTC()}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		ev.Data = raw
+	}
+	return writeLiveEvent(conn, ev)
+}
+
+func writeLiveEvent(conn *websocket.Conn, ev events.Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
@@ -0,0 +1,244 @@
+// internal/handler/live_handler_test.go
+package handler
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"editor-backend/internal/auth"
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+	"editor-backend/internal/websocket"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const liveOrigin = "https://editor.example.com"
+
+// liveServer serves SessionLive with the fake database's events looped back
+// through a local broker. The caller is ?user=, in the personal space.
+func liveServer(t *testing.T, db *fakeDB) *httptest.Server {
+	t.Helper()
+	h := newTestHandler(db)
+	db.broker = events.NewLocalBroker()
+	h.Broker = db.broker
+	h.Events = &events.Publisher{DB: h.Service.DB}
+	h.Service.Events = h.Events
+	h.AllowedOrigins = []string{liveOrigin}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/sessions/{id}/live", func(w http.ResponseWriter, r *http.Request) {
+		userID := uuid.MustParse(r.URL.Query().Get("user"))
+		h.SessionLive(w, r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{UserID: userID})))
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(func() {
+		srv.Close()
+		db.broker.Close()
+	})
+	return srv
+}
+
+// liveClient is a bare WebSocket client: masked text frames out, events in.
+type liveClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialLive(t *testing.T, srv *httptest.Server, sessionID, userID uuid.UUID, query, origin string) (*liveClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/sessions/"+sessionID.String()+"/live?user="+userID.String()+query, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", origin)
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &liveClient{t: t, conn: conn, br: br}, resp
+}
+
+func (c *liveClient) send(msg string) {
+	c.t.Helper()
+	frame := []byte{0x80 | websocket.TextMessage}
+	if len(msg) <= 125 {
+		frame = append(frame, 0x80|byte(len(msg)))
+	} else {
+		frame = append(frame, 0x80|126, byte(len(msg)>>8), byte(len(msg)))
+	}
+	// A zero mask leaves the payload as is
+	frame = append(frame, 0, 0, 0, 0)
+	if _, err := c.conn.Write(append(frame, msg...)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// next returns the next event; a close frame comes back as type "close"
+// with {"code"} in Data.
+func (c *liveClient) next() events.Event {
+	c.t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		c.t.Fatalf("reading frame: %v", err)
+	}
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatalf("reading payload: %v", err)
+	}
+
+	switch int(header[0] & 0x0f) {
+	case websocket.CloseMessage:
+		code, _ := json.Marshal(map[string]uint16{"code": binary.BigEndian.Uint16(payload)})
+		return events.Event{Type: "close", Data: code}
+	case websocket.PingMessage:
+		return c.next()
+	}
+	var ev events.Event
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		c.t.Fatalf("event %q: %v", payload, err)
+	}
+	return ev
+}
+
+// expect skips presence chatter until an event of type eventType arrives.
+func (c *liveClient) expect(eventType string) map[string]interface{} {
+	c.t.Helper()
+	for {
+		ev := c.next()
+		if ev.Type == events.TypePresence {
+			continue
+		}
+		if ev.Type != eventType {
+			c.t.Fatalf("got %s %s, want %s", ev.Type, ev.Data, eventType)
+		}
+		data := map[string]interface{}{}
+		json.Unmarshal(ev.Data, &data)
+		return data
+	}
+}
+
+// expectAck reads until ack and op.applied for the same op have arrived,
+// in either order — the op.applied echo is notified before the ack is written.
+func (c *liveClient) expectAck(clientOpID string) (ack, applied map[string]interface{}) {
+	c.t.Helper()
+	for ack == nil || applied == nil {
+		ev := c.next()
+		data := map[string]interface{}{}
+		json.Unmarshal(ev.Data, &data)
+		switch ev.Type {
+		case events.TypePresence:
+		case "ack":
+			ack = data
+		case events.TypeOperation:
+			applied = data
+		default:
+			c.t.Fatalf("got %s %s, want ack / op.applied", ev.Type, ev.Data)
+		}
+	}
+	if ack["client_op_id"] != clientOpID || applied["client_op_id"] != clientOpID {
+		c.t.Fatalf("ack %v, op.applied %v: want client_op_id %s", ack, applied, clientOpID)
+	}
+	return ack, applied
+}
+
+func TestSessionLive(t *testing.T) {
+	db := newFakeDB()
+	owner, editor, viewer := uuid.New(), uuid.New(), uuid.New()
+	sessionID := db.addSession(owner)
+	db.setTimeline(sessionID, testTimeline)
+	db.addMember(sessionID, editor, models.RoleEditor)
+	db.addMember(sessionID, viewer, models.RoleViewer)
+	srv := liveServer(t, db)
+
+	// Cross-site pages can't open the socket
+	if _, resp := dialLive(t, srv, sessionID, editor, "", "https://evil.example.net"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin: status = %d, want 403", resp.StatusCode)
+	}
+
+	c, resp := dialLive(t, srv, sessionID, editor, "", liveOrigin)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d", resp.StatusCode)
+	}
+	if ready := c.expect("ready"); ready["version"] != float64(1) || ready["role"] != models.RoleEditor {
+		t.Fatalf("ready = %v", ready)
+	}
+
+	// ack: the op is applied as the next version and broadcast
+	c.send(`{"type":"op","client_op_id":"c-1","op":{"type":"move_clip","clip_id":"title","start":2}}`)
+	ack, applied := c.expectAck("c-1")
+	if ack["version"] != float64(2) || applied["version"] != float64(2) {
+		t.Fatalf("ack %v, op.applied %v: want version 2", ack, applied)
+	}
+
+	// reject: unknown clip, and an op whose result isn't a valid timeline
+	c.send(`{"type":"op","client_op_id":"c-2","op":{"type":"move_clip","clip_id":"nope","start":1}}`)
+	if reject := c.expect("reject"); reject["client_op_id"] != "c-2" || !strings.Contains(reject["error"].(string), "clip not found") {
+		t.Fatalf("reject = %v", reject)
+	}
+	c.send(`{"type":"op","client_op_id":"c-3","op":{"type":"move_clip","clip_id":"title","start":-5}}`)
+	if reject := c.expect("reject"); reject["error"] != "invalid timeline" || reject["fields"] == nil {
+		t.Fatalf("reject = %v", reject)
+	}
+	c.send(`not json`)
+	if reject := c.expect("reject"); reject["error"] != "invalid message" {
+		t.Fatalf("reject = %v", reject)
+	}
+
+	// replay: a reconnect with ?since gets the missed ops ...
+	r, _ := dialLive(t, srv, sessionID, editor, "&since=1", liveOrigin)
+	r.expect("ready")
+	if op := r.expect(events.TypeOperation); op["version"] != float64(2) || op["client_op_id"] != "c-1" {
+		t.Fatalf("replayed op = %v", op)
+	}
+	// ... and a gap it can't fill is a resync
+	r.send(`{"type":"sync","since":0}`)
+	r.expect(events.TypeResync)
+
+	// A viewer watches but can't edit
+	v, _ := dialLive(t, srv, sessionID, viewer, "", liveOrigin)
+	v.expect("ready")
+	v.send(`{"type":"op","client_op_id":"v-1","op":{"type":"move_clip","clip_id":"title","start":3}}`)
+	if reject := v.expect("reject"); reject["error"] != "your role on this session does not allow editing" {
+		t.Fatalf("viewer reject = %v", reject)
+	}
+
+	// revoke: removed from the session, the viewer's socket closes with 1008
+	db.removeMember(sessionID, viewer)
+	db.broker.Deliver(events.Event{Type: events.TypeMembersChanged, SessionID: sessionID})
+	v.expect(events.TypeAccessRevoked)
+	if closed := v.expect("close"); closed["code"] != float64(websocket.ClosePolicy) {
+		t.Fatalf("close = %v, want 1008", closed)
+	}
+	// Members who kept access just hear about the change
+	c.expect(events.TypeMembersChanged)
+}
@@ -0,0 +1,212 @@
+// internal/handler/member_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// ListMembers returns the session's owner and members. Any role may call it.
+//
+// GET /api/v1/sessions/{id}/members
+func (h *EditorHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	members, err := h.Service.ListMembers(sessionID, userID, getWorkspaceID(r))
+	if err != nil {
+		respondMemberError(w, err, "failed to list members")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"members": members})
+}
+
+// AddMember invites a user with a role: editor, commenter or viewer. Owner only.
+//
+// POST /api/v1/sessions/{id}/members   { "user_id": "...", "role": "editor" }
+func (h *EditorHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	memberID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "user_id must be a UUID")
+		return
+	}
+
+	member, err := h.Service.AddMember(sessionID, userID, getWorkspaceID(r), memberID, req.Role)
+	if err != nil {
+		respondMemberError(w, err, "failed to add member")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, member)
+}
+
+// UpdateMember changes a member's role. Owner only.
+//
+// PUT /api/v1/sessions/{id}/members/{user_id}   { "role": "viewer" }
+func (h *EditorHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	memberID, err := parseUUIDParam(r, "user_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	member, err := h.Service.UpdateMemberRole(sessionID, userID, getWorkspaceID(r), memberID, req.Role)
+	if err != nil {
+		respondMemberError(w, err, "failed to update member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, member)
+}
+
+// RemoveMember revokes a member's access. The owner can remove anyone; a
+// member can remove themselves to leave the session.
+//
+// DELETE /api/v1/sessions/{id}/members/{user_id}
+func (h *EditorHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	memberID, err := parseUUIDParam(r, "user_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.Service.RemoveMember(sessionID, userID, getWorkspaceID(r), memberID); err != nil {
+		respondMemberError(w, err, "failed to remove member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+// TransferOwnership hands the session to an existing member. The previous
+// owner stays on as an editor.
+//
+// POST /api/v1/sessions/{id}/transfer   { "user_id": "..." }
+func (h *EditorHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	newOwnerID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "user_id must be a UUID")
+		return
+	}
+
+	if err := h.Service.TransferOwnership(sessionID, userID, getWorkspaceID(r), newOwnerID); err != nil {
+		respondMemberError(w, err, "failed to transfer ownership")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "transferred",
+		"user_id": newOwnerID.String(),
+	})
+}
+
+func respondMemberError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrInsufficientRole:
+		respondError(w, http.StatusForbidden, "only the session owner can manage members")
+	case service.ErrNotWorkspaceMember:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrMemberNotFound:
+		respondError(w, http.StatusNotFound, "member not found")
+	case service.ErrMemberExists:
+		respondError(w, http.StatusConflict, err.Error())
+	case service.ErrInvalidRole:
+		respondError(w, http.StatusBadRequest, "role must be editor, commenter or viewer")
+	case service.ErrOwnerMembership:
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Member error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,214 @@
+// internal/handler/member_handler_test.go
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// roleSession is a session with one user per role, plus target — a viewer
+// the member endpoints act on — and a stranger with no access.
+type roleSession struct {
+	db        *fakeDB
+	h         *EditorHandler
+	sessionID uuid.UUID
+	users     map[string]uuid.UUID // role → user, and "target" / "stranger"
+}
+
+func newRoleSession() *roleSession {
+	db := newFakeDB()
+	users := map[string]uuid.UUID{}
+	for _, name := range []string{models.RoleOwner, models.RoleEditor, models.RoleCommenter, models.RoleViewer, "target", "stranger"} {
+		users[name] = uuid.New()
+	}
+	sessionID := db.addSession(users[models.RoleOwner])
+	db.setTimeline(sessionID, testTimeline)
+	for _, role := range []string{models.RoleEditor, models.RoleCommenter, models.RoleViewer} {
+		db.addMember(sessionID, users[role], role)
+	}
+	db.addMember(sessionID, users["target"], models.RoleViewer)
+	return &roleSession{db: db, h: newTestHandler(db), sessionID: sessionID, users: users}
+}
+
+func (s *roleSession) vars() map[string]string {
+	return map[string]string{"id": s.sessionID.String(), "user_id": s.users["target"].String()}
+}
+
+// Every endpoint answers each role by the role table: the write paths that
+// check with canEditSQL refuse commenters and viewers just like the ones
+// that call authorize, and member management is the owner's alone.
+func TestSessionRoles(t *testing.T) {
+	const (
+		owner, editor, commenter, viewer = models.RoleOwner, models.RoleEditor, models.RoleCommenter, models.RoleViewer
+	)
+	ok, created, forbidden := http.StatusOK, http.StatusCreated, http.StatusForbidden
+
+	cases := []struct {
+		name    string
+		request func(s *roleSession) *http.Request
+		handler func(h *EditorHandler) http.HandlerFunc
+		want    map[string]int // by role; the stranger always gets 403
+	}{
+		{"get session",
+			func(*roleSession) *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			func(h *EditorHandler) http.HandlerFunc { return h.GetSession },
+			map[string]int{owner: ok, editor: ok, commenter: ok, viewer: ok}},
+		{"list members",
+			func(*roleSession) *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			func(h *EditorHandler) http.HandlerFunc { return h.ListMembers },
+			map[string]int{owner: ok, editor: ok, commenter: ok, viewer: ok}},
+		{"save",
+			func(*roleSession) *http.Request {
+				return httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"timeline":`+testTimeline+`}`))
+			},
+			func(h *EditorHandler) http.HandlerFunc { return h.SaveSession },
+			map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{"patch",
+			func(*roleSession) *http.Request {
+				return jsonPatch(`[{"op":"replace","path":"/tracks/0/clips/0/text","value":"Hi"}]`)
+			},
+			func(h *EditorHandler) http.HandlerFunc { return h.PatchSession },
+			map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{"operations",
+			func(*roleSession) *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+					`{"operations":[{"type":"move_clip","clip_id":"title","start":2}]}`))
+			},
+			func(h *EditorHandler) http.HandlerFunc { return h.ApplyOperations },
+			map[string]int{owner: ok, editor: ok, commenter: forbidden, viewer: forbidden}},
+		{"delete",
+			func(*roleSession) *http.Request { return httptest.NewRequest(http.MethodDelete, "/", nil) },
+			func(h *EditorHandler) http.HandlerFunc { return h.DeleteSession },
+			map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{"add member",
+			func(*roleSession) *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+					fmt.Sprintf(`{"user_id":%q,"role":"viewer"}`, uuid.NewString())))
+			},
+			func(h *EditorHandler) http.HandlerFunc { return h.AddMember },
+			map[string]int{owner: created, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{"change a member's role",
+			func(*roleSession) *http.Request {
+				return httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"role":"editor"}`))
+			},
+			func(h *EditorHandler) http.HandlerFunc { return h.UpdateMember },
+			map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{"remove a member",
+			func(*roleSession) *http.Request { return httptest.NewRequest(http.MethodDelete, "/", nil) },
+			func(h *EditorHandler) http.HandlerFunc { return h.RemoveMember },
+			map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+		{"transfer ownership",
+			func(s *roleSession) *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+					fmt.Sprintf(`{"user_id":%q}`, s.users["target"])))
+			},
+			func(h *EditorHandler) http.HandlerFunc { return h.TransferOwnership },
+			map[string]int{owner: ok, editor: forbidden, commenter: forbidden, viewer: forbidden}},
+	}
+	for _, tc := range cases {
+		for _, role := range []string{owner, editor, commenter, viewer, "stranger"} {
+			want, listed := tc.want[role]
+			if !listed {
+				want = forbidden
+			}
+			t.Run(tc.name+"/"+role, func(t *testing.T) {
+				s := newRoleSession()
+				w := serveRequest(tc.handler(s.h), tc.request(s), s.users[role], s.vars())
+				if w.Code != want {
+					t.Fatalf("status = %d (%s), want %d", w.Code, w.Body, want)
+				}
+			})
+		}
+	}
+}
+
+// A member may leave on their own; the owner can't leave their session.
+func TestRemoveMemberSelf(t *testing.T) {
+	s := newRoleSession()
+	for role, want := range map[string]int{
+		models.RoleViewer: http.StatusOK,
+		models.RoleOwner:  http.StatusBadRequest,
+	} {
+		vars := map[string]string{"id": s.sessionID.String(), "user_id": s.users[role].String()}
+		if w := serve(s.h.RemoveMember, http.MethodDelete, "", s.users[role], vars); w.Code != want {
+			t.Errorf("%s leaving: status = %d (%s), want %d", role, w.Code, w.Body, want)
+		}
+	}
+	if _, ok := s.db.sessions[s.sessionID].members[s.users[models.RoleViewer]]; ok {
+		t.Fatal("viewer is still a member")
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	transfer := func(s *roleSession, caller, workspace, to uuid.UUID) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf(`{"user_id":%q}`, to)))
+		return serveInWorkspace(s.h.TransferOwnership, r, caller, workspace, s.vars())
+	}
+
+	t.Run("personal space", func(t *testing.T) {
+		s := newRoleSession()
+		owner, target := s.users[models.RoleOwner], s.users["target"]
+
+		if w := transfer(s, owner, uuid.Nil, owner); w.Code != http.StatusBadRequest {
+			t.Fatalf("to self: status = %d, want 400", w.Code)
+		}
+		if w := transfer(s, owner, uuid.Nil, uuid.New()); w.Code != http.StatusNotFound {
+			t.Fatalf("to a non-member: status = %d, want 404", w.Code)
+		}
+
+		if w := transfer(s, owner, uuid.Nil, target); w.Code != http.StatusOK {
+			t.Fatalf("status = %d (%s)", w.Code, w.Body)
+		}
+		session := s.db.sessions[s.sessionID]
+		if session.owner != target {
+			t.Fatal("owner unchanged")
+		}
+		if _, ok := session.members[target]; ok || session.members[owner] != models.RoleEditor {
+			t.Fatalf("members = %v: want the new owner out and the old one an editor", session.members)
+		}
+		// The old owner is now only an editor
+		if w := transfer(s, owner, uuid.Nil, s.users[models.RoleEditor]); w.Code != http.StatusForbidden {
+			t.Fatalf("old owner transferring again: status = %d, want 403", w.Code)
+		}
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		db := newFakeDB()
+		workspace, other := uuid.New(), uuid.New()
+		owner, outsider := uuid.New(), uuid.New()
+		db.addWorkspaceMember(workspace, owner, models.WorkspaceRoleMember)
+		db.addWorkspaceMember(other, owner, models.WorkspaceRoleAdmin)
+		sessionID := db.addWorkspaceSession(owner, workspace)
+		// A session member who isn't (or is no longer) in the workspace
+		db.addMember(sessionID, outsider, models.RoleEditor)
+		s := &roleSession{db: db, h: newTestHandler(db), sessionID: sessionID,
+			users: map[string]uuid.UUID{"target": outsider}}
+
+		// From outside the session's workspace it doesn't exist
+		for _, from := range []uuid.UUID{uuid.Nil, other} {
+			if w := transfer(s, owner, from, outsider); w.Code != http.StatusNotFound {
+				t.Fatalf("from workspace %v: status = %d (%s), want 404", from, w.Code, w.Body)
+			}
+		}
+		// Nor can it go to someone outside the workspace
+		w := transfer(s, owner, workspace, outsider)
+		if w.Code != http.StatusBadRequest || !strings.Contains(jsonField(t, w, "error"), "not a member of this workspace") {
+			t.Fatalf("to an outsider: status = %d (%s), want 400", w.Code, w.Body)
+		}
+		if db.sessions[sessionID].owner != owner {
+			t.Fatal("owner changed")
+		}
+
+		db.addWorkspaceMember(workspace, outsider, models.WorkspaceRoleViewer)
+		if w := transfer(s, owner, workspace, outsider); w.Code != http.StatusOK {
+			t.Fatalf("to a workspace member: status = %d (%s)", w.Code, w.Body)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+// internal/handler/operation_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"editor-backend/internal/service"
+	"editor-backend/internal/timeline"
+	"editor-backend/internal/validation"
+)
+
+// ApplyOperations edits a session with typed commands instead of a whole
+// timeline — for automations and other services that shouldn't reimplement
+// editorStore.js. The ops are applied in order and atomically: if any fails,
+// or the result doesn't validate, nothing is written. Needs the editor role.
+//
+// POST /api/v1/sessions/{id}/operations
+//
+//	{
+//	    "operations": [
+//	        { "type": "split_clip", "clip_id": "clip-3", "at": 12.5 },
+//	        { "type": "ripple_delete", "clip_id": "clip-4" },
+//	        { "type": "insert_gap", "at": 30, "duration": 2 }
+//	    ],
+//	    "version": 7,             // optional, or If-Match — 409 if the session moved on
+//	    "client_op_id": "job-91"  // optional, echoed on each op.applied event
+//	}
+//
+// Response: { "version": 10, "operations": [...as applied...], "timeline": {...} }
+// Generated clip IDs (split / duplicate) are in the applied ops' new_clip_id.
+func (h *EditorHandler) ApplyOperations(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var body struct {
+		Operations []timeline.Op `json:"operations"`
+		Version    int           `json:"version"`
+		ClientOpID string        `json:"client_op_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	expectedVersion, ok := requestVersion(w, r, body.Version)
+	if !ok {
+		return
+	}
+
+	result, err := h.Service.ApplyOperations(sessionID, userID, getWorkspaceID(r), body.Operations, body.ClientOpID, expectedVersion)
+	if err != nil {
+		respondOperationError(w, err, "failed to apply operations")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(result.Version))
+	respondJSON(w, http.StatusOK, result)
+}
+
+func respondOperationError(w http.ResponseWriter, err error, fallback string) {
+	var conflict *service.VersionConflictError
+	if errors.As(err, &conflict) {
+		respondVersionConflict(w, conflict)
+		return
+	}
+	var invalid *validation.TimelineError
+	if errors.As(err, &invalid) {
+		respondInvalidTimeline(w, invalid)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidOperation) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrTimelineUnreadable) {
+		respondTimelineUnreadable(w, err)
+		return
+	}
+
+	switch err {
+	case service.ErrTooManyOperations:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrOperationTooLarge:
+		respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Operation error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
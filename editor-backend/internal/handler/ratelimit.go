@@ -0,0 +1,58 @@
+// internal/handler/ratelimit.go
+package handler
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows Limit requests per key (a client IP) in each Window.
+// Counts live in this process only — with several API pods each enforces
+// its own limit, which still bounds what one client can make any pod do.
+type RateLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{Limit: limit, Window: window, windows: map[string]*rateWindow{}}
+}
+
+// Allow counts one request for key. When the limit is reached it returns
+// false and how long until the window resets.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop finished windows now and then so idle clients don't pile up
+	if now.Sub(l.lastSweep) > l.Window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.Window {
+				delete(l.windows, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.Window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.Limit {
+		return false, w.start.Add(l.Window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
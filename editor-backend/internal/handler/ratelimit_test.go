@@ -0,0 +1,63 @@
+// internal/handler/ratelimit_test.go
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPerKey(t *testing.T) {
+	l := NewRateLimiter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("203.0.113.7"); !ok {
+			t.Fatalf("request %d refused under the limit", i+1)
+		}
+	}
+	ok, retryAfter := l.Allow("203.0.113.7")
+	if ok {
+		t.Fatal("third request in the window allowed")
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Fatalf("retryAfter = %v, want within the window", retryAfter)
+	}
+
+	if ok, _ := l.Allow("198.51.100.1"); !ok {
+		t.Fatal("another IP was refused")
+	}
+}
+
+func TestRateLimiterWindowResets(t *testing.T) {
+	l := NewRateLimiter(1, 20*time.Millisecond)
+	l.Allow("a")
+	if ok, _ := l.Allow("a"); ok {
+		t.Fatal("second request in the window allowed")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Fatal("request after the window refused")
+	}
+}
+
+// Over the limit the public share routes answer 429 before looking at the token
+func TestShareRoutesThrottled(t *testing.T) {
+	h := &EditorHandler{ShareLimiter: NewRateLimiter(0, time.Minute)}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"open":          h.OpenShareLink,
+		"list comments": h.ListSharedComments,
+		"add comment":   h.CreateSharedComment,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/share/token", nil)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("%s: status = %d, want 429", name, w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Fatalf("%s: no Retry-After", name)
+		}
+	}
+}
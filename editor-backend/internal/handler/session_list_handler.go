@@ -0,0 +1,103 @@
+// internal/handler/session_list_handler.go
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"editor-backend/internal/service"
+)
+
+// ListSessions returns the caller's own and shared sessions, most recently
+// updated first, for the "My Projects" dashboard.
+//
+// GET /api/v1/sessions?source_module=repurposer&platform=tiktok
+//
+//	&export_status=completed&status=in_review
+//	&created_after=2026-01-01T00:00:00Z&created_before=...
+//	&updated_after=...&updated_before=...
+//	&order=desc&limit=20&cursor=<next_cursor>
+//
+// Timestamps are RFC 3339. Pass next_cursor back as cursor for the next page;
+// it is omitted on the last page.
+func (h *EditorHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	filter, ok := parseSessionFilter(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListSessions(userID, getWorkspaceID(r), filter)
+	if err != nil {
+		if err == service.ErrInvalidCursor {
+			respondError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("ListSessions error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+// parseSessionFilter reads the GET /sessions query parameters. On a bad
+// parameter it has already answered 400 and reports false.
+func parseSessionFilter(w http.ResponseWriter, r *http.Request) (service.SessionFilter, bool) {
+	q := r.URL.Query()
+	filter := service.SessionFilter{
+		SourceModule: q.Get("source_module"),
+		Platform:     q.Get("platform"),
+		ExportStatus: q.Get("export_status"),
+		Status:       q.Get("status"),
+		Cursor:       q.Get("cursor"),
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		respondError(w, http.StatusBadRequest, "order must be asc or desc")
+		return filter, false
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return filter, false
+		}
+		filter.Limit = limit
+	}
+
+	for param, dst := range map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+		"updated_after":  &filter.UpdatedAfter,
+		"updated_before": &filter.UpdatedBefore,
+	} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, param+" must be an RFC 3339 timestamp")
+			return filter, false
+		}
+		*dst = &t
+	}
+
+	return filter, true
+}
@@ -0,0 +1,242 @@
+// internal/handler/share_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateShareLink issues a public review link. Needs the editor role.
+//
+// POST /api/v1/sessions/{id}/shares
+//
+//	{
+//	    "permission": "view" | "comment",   // optional, default "view"
+//	    "expires_in": 604800,               // optional, seconds
+//	    "expires_at": "2026-03-01T00:00:00Z", // optional, instead of expires_in
+//	    "password":   "..."                 // optional
+//	}
+//
+// The response carries "token" and "url" — they are never shown again.
+func (h *EditorHandler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Permission string     `json:"permission"`
+		ExpiresIn  int        `json:"expires_in"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		Password   string     `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	opts := service.ShareOptions{
+		Permission: req.Permission,
+		ExpiresAt:  req.ExpiresAt,
+		Password:   req.Password,
+	}
+	if req.ExpiresIn > 0 {
+		if req.ExpiresAt != nil {
+			respondError(w, http.StatusBadRequest, "set expires_in or expires_at, not both")
+			return
+		}
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		opts.ExpiresAt = &expiresAt
+	}
+
+	link, err := h.Service.CreateShareLink(sessionID, userID, getWorkspaceID(r), opts)
+	if err != nil {
+		respondShareError(w, err, "failed to create share link")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"share": link,
+		"url":   strings.TrimRight(h.BaseURL, "/") + "/api/v1/share/" + link.Token,
+	})
+}
+
+// ListShareLinks returns the session's share links (without tokens).
+//
+// GET /api/v1/sessions/{id}/shares
+func (h *EditorHandler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	links, err := h.Service.ListShareLinks(sessionID, userID, getWorkspaceID(r))
+	if err != nil {
+		respondShareError(w, err, "failed to list share links")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"shares": links})
+}
+
+// RevokeShareLink disables a share link immediately.
+//
+// DELETE /api/v1/sessions/{id}/shares/{share_id}
+func (h *EditorHandler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	shareID, err := parseUUIDParam(r, "share_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid share id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.Service.RevokeShareLink(sessionID, userID, getWorkspaceID(r), shareID); err != nil {
+		respondShareError(w, err, "failed to revoke share link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// OpenShareLink is the PUBLIC review endpoint — no account, no auth
+// middleware. It returns the timeline and absolute media URLs; there is no
+// write counterpart, so a link holder can never save.
+//
+// GET /api/v1/share/{token}
+// X-Share-Password: ...   (only for password-protected links)
+func (h *EditorHandler) OpenShareLink(w http.ResponseWriter, r *http.Request) {
+	// Review links must not end up in caches or search results
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	if !h.allowShareRequest(w, r) {
+		return
+	}
+
+	access := service.ShareAccess{
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+
+	shared, err := h.Service.OpenShareLink(mux.Vars(r)["token"], r.Header.Get("X-Share-Password"), access)
+	if err != nil {
+		respondShareError(w, err, "failed to open share link")
+		return
+	}
+
+	shared.Media = h.resolveMedia(&shared.Timeline)
+	respondJSON(w, http.StatusOK, shared)
+}
+
+// allowShareRequest applies ShareLimiter to a public share request and
+// answers 429 when the caller's IP is over the limit. Checked before the
+// token is looked up, so unknown tokens count too.
+func (h *EditorHandler) allowShareRequest(w http.ResponseWriter, r *http.Request) bool {
+	if h.ShareLimiter == nil {
+		return true
+	}
+	ok, retryAfter := h.ShareLimiter.Allow(clientIP(r))
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		respondError(w, http.StatusTooManyRequests, "too many requests — try again later")
+	}
+	return ok
+}
+
+// resolveMedia rewrites relative clip sources (e.g. /uploads/x.mp4) against
+// BaseURL, in place, and lists every clip's media URL.
+func (h *EditorHandler) resolveMedia(tl *models.Timeline) []models.SharedClip {
+	media := []models.SharedClip{}
+	for ti := range tl.Tracks {
+		track := &tl.Tracks[ti]
+		for ci := range track.Clips {
+			clip := &track.Clips[ci]
+			if clip.Src == "" {
+				continue
+			}
+			clip.Src = h.absoluteURL(clip.Src)
+			media = append(media, models.SharedClip{ClipID: clip.ClipID, Type: clip.KindIn(*track), URL: clip.Src})
+		}
+	}
+	return media
+}
+
+func (h *EditorHandler) absoluteURL(src string) string {
+	u, err := url.Parse(src)
+	if err != nil || u.IsAbs() || h.BaseURL == "" {
+		return src
+	}
+	base, err := url.Parse(h.BaseURL)
+	if err != nil {
+		return src
+	}
+	return base.ResolveReference(u).String()
+}
+
+// clientIP is the connecting address. Behind a proxy this is the proxy —
+// X-Forwarded-For is client-controlled and not trusted here.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func respondShareError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrShareNotFound:
+		respondError(w, http.StatusNotFound, "share link not found")
+	case service.ErrShareExpired:
+		respondError(w, http.StatusGone, "share link has expired")
+	case service.ErrSharePasswordRequired, service.ErrSharePasswordWrong:
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case service.ErrShareThrottled:
+		respondError(w, http.StatusTooManyRequests, err.Error())
+	case service.ErrInvalidSharePermission, service.ErrInvalidShareExpiry:
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Share link error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,244 @@
+// internal/handler/tus_handler.go
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/service"
+	"editor-backend/internal/validation"
+)
+
+// tus 1.0 resumable uploads (https://tus.io/protocols/resumable-upload):
+// core protocol plus the creation, termination and expiration extensions.
+//
+//	POST   /api/v1/tus                Upload-Length, Upload-Metadata → 201 Location
+//	HEAD   /api/v1/tus/{upload_id}    → Upload-Offset (where to resume)
+//	PATCH  /api/v1/tus/{upload_id}    Upload-Offset + chunk → 204 new Upload-Offset
+//	DELETE /api/v1/tus/{upload_id}    → 204
+//
+// The chunk that reaches Upload-Length completes the upload and records the
+// asset; POST /uploads/{upload_id}/complete then returns it.
+const (
+	tusVersion     = "1.0.0"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// TusCreate starts a resumable upload. Upload-Metadata carries the
+// base64 "filename" and "filetype"; both get the POST /upload checks.
+// Needs the workspace member role.
+func (h *EditorHandler) TusCreate(w http.ResponseWriter, r *http.Request) {
+	if !tusResumable(w, r) {
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	// Upload-Defer-Length isn't supported — the size is validated up front
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		respondError(w, http.StatusBadRequest, "Upload-Length header is required")
+		return
+	}
+
+	metadata, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid Upload-Metadata header")
+		return
+	}
+	filename := metadata["filename"]
+	if filename == "" {
+		filename = metadata["name"]
+	}
+	contentType := metadata["filetype"]
+	if contentType == "" {
+		contentType = metadata["type"]
+	}
+
+	upload, err := h.Uploads.CreateTusUpload(userID, getWorkspaceID(r), filename, contentType, length)
+	if err != nil {
+		respondTusError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimRight(h.BaseURL, "/")+"/api/v1/tus/"+upload.UploadID.String())
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// TusHead reports how many bytes the server has — the client resumes from
+// Upload-Offset.
+func (h *EditorHandler) TusHead(w http.ResponseWriter, r *http.Request) {
+	if !tusResumable(w, r) {
+		return
+	}
+	upload, ok := h.tusUpload(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	setTusOffset(w, upload)
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.SizeBytes, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// TusPatch appends a chunk at Upload-Offset. A dropped connection keeps
+// what was stored; HEAD says where to continue.
+func (h *EditorHandler) TusPatch(w http.ResponseWriter, r *http.Request) {
+	if !tusResumable(w, r) {
+		return
+	}
+	if r.Header.Get("Content-Type") != tusContentType {
+		respondError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+tusContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		respondError(w, http.StatusBadRequest, "Upload-Offset header is required")
+		return
+	}
+
+	upload, ok := h.tusUpload(w, r)
+	if !ok {
+		return
+	}
+	if r.ContentLength > upload.SizeBytes-offset {
+		respondError(w, http.StatusRequestEntityTooLarge, "chunk runs past Upload-Length")
+		return
+	}
+
+	extendUploadDeadlines(w)
+
+	upload, err = h.Uploads.AppendTusUpload(upload.UploadID, upload.UserID, offset, r.Body)
+	if err != nil && upload == nil {
+		respondTusError(w, err)
+		return
+	}
+	if err != nil {
+		// The client went away mid-chunk — what arrived is stored
+		log.Println("TusPatch:", err)
+	}
+
+	setTusOffset(w, upload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TusDelete terminates an upload and discards its chunks.
+func (h *EditorHandler) TusDelete(w http.ResponseWriter, r *http.Request) {
+	if !tusResumable(w, r) {
+		return
+	}
+
+	uploadID, err := parseUUIDParam(r, "upload_id")
+	if err != nil {
+		respondError(w, http.StatusNotFound, "upload not found")
+		return
+	}
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := h.Uploads.TerminateTusUpload(uploadID, userID); err != nil {
+		respondTusError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusUpload loads the caller's upload named in the path, or answers.
+func (h *EditorHandler) tusUpload(w http.ResponseWriter, r *http.Request) (*models.Upload, bool) {
+	uploadID, err := parseUUIDParam(r, "upload_id")
+	if err != nil {
+		respondError(w, http.StatusNotFound, "upload not found")
+		return nil, false
+	}
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return nil, false
+	}
+
+	upload, err := h.Uploads.GetTusUpload(uploadID, userID)
+	if err != nil {
+		respondTusError(w, err)
+		return nil, false
+	}
+	if upload.Status == models.UploadStatusFailed {
+		respondTusError(w, service.ErrUploadFailed)
+		return nil, false
+	}
+	return upload, true
+}
+
+// tusResumable stamps the protocol version on the response and rejects
+// clients speaking another one (412).
+func tusResumable(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		respondError(w, http.StatusPreconditionFailed, "unsupported tus version — this server speaks "+tusVersion)
+		return false
+	}
+	return true
+}
+
+func setTusOffset(w http.ResponseWriter, upload *models.Upload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.Status == models.UploadStatusPending {
+		w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseTusMetadata decodes "key base64value,key2 base64value2". A key may
+// come without a value.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty metadata key")
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+func respondTusError(w http.ResponseWriter, err error) {
+	switch err {
+	case validation.ErrFileTooLarge:
+		respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case validation.ErrEmptyFile, validation.ErrInvalidFileType, validation.ErrFilenameTooLong:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrUploadNotFound:
+		respondError(w, http.StatusNotFound, err.Error())
+	case service.ErrUploadExpired, service.ErrUploadFailed:
+		respondError(w, http.StatusGone, err.Error())
+	case service.ErrUploadOffset:
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Tus upload error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to process upload")
+	}
+}
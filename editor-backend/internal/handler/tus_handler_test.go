@@ -0,0 +1,312 @@
+// internal/handler/tus_handler_test.go
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/service"
+	"editor-backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// memStorage is an in-memory storage.Storage.
+type memStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStorage() *memStorage { return &memStorage{objects: map[string][]byte{}} }
+
+func (m *memStorage) Upload(r io.Reader, size int64, filename, contentType string) (*storage.ObjectInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return nil, fmt.Errorf("%w: got %d bytes, expected %d", storage.ErrSizeMismatch, len(data), size)
+	}
+	key := uuid.NewString() + path.Ext(filename)
+	m.mu.Lock()
+	m.objects[key] = data
+	m.mu.Unlock()
+	return m.Stat(key)
+}
+
+func (m *memStorage) Open(key string, offset, length int64) (io.ReadCloser, *storage.ObjectInfo, error) {
+	info, err := m.Stat(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.mu.Lock()
+	data := m.objects[key][offset:]
+	m.mu.Unlock()
+	if length > 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return io.NopCloser(bytes.NewReader(data)), info, nil
+}
+
+func (m *memStorage) Stat(key string) (*storage.ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return &storage.ObjectInfo{Key: key, URL: "/uploads/" + key, Size: int64(len(data))}, nil
+}
+
+func (m *memStorage) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memStorage) List(prefix string) ([]storage.ObjectInfo, error) {
+	m.mu.Lock()
+	var keys []string
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.Unlock()
+	objects := []storage.ObjectInfo{}
+	for _, key := range keys {
+		info, _ := m.Stat(key)
+		objects = append(objects, *info)
+	}
+	return objects, nil
+}
+
+func (m *memStorage) Exists(key string) (bool, error) {
+	_, err := m.Stat(key)
+	return err == nil, nil
+}
+
+func (m *memStorage) PresignUpload(string, string, int64, time.Duration) (*storage.PresignedUpload, error) {
+	return nil, errors.New("memStorage: presigned uploads not supported")
+}
+
+// contents returns every stored object's bytes.
+func (m *memStorage) contents() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for _, data := range m.objects {
+		out = append(out, string(data))
+	}
+	return out
+}
+
+func newTusHandler(db *fakeDB, store storage.Storage) *EditorHandler {
+	h := newTestHandler(db)
+	h.Uploads = &service.UploadService{DB: h.Service.DB, Storage: store}
+	h.BaseURL = "https://api.example.com"
+	return h
+}
+
+// tusRequest builds a tus 1.0 request; headers are name, value pairs.
+func tusRequest(method string, body io.Reader, headers ...string) *http.Request {
+	r := httptest.NewRequest(method, "/", body)
+	r.Header.Set("Tus-Resumable", tusVersion)
+	if method == http.MethodPatch {
+		r.Header.Set("Content-Type", tusContentType)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	return r
+}
+
+// tusCreate starts a length-byte upload of clip.mp4 and returns its id.
+func tusCreate(t *testing.T, h *EditorHandler, userID uuid.UUID, length int) uuid.UUID {
+	t.Helper()
+	w := serveRequest(h.TusCreate, tusRequest(http.MethodPost, nil,
+		"Upload-Length", fmt.Sprint(length),
+		"Upload-Metadata", "filename Y2xpcC5tcDQ=,filetype dmlkZW8vbXA0", // clip.mp4, video/mp4
+	), userID, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d (%s)", w.Code, w.Body)
+	}
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "https://api.example.com/api/v1/tus/") {
+		t.Fatalf("Location = %q", location)
+	}
+	return uuid.MustParse(path.Base(location))
+}
+
+func tusPatch(h *EditorHandler, userID, uploadID uuid.UUID, offset int, body io.Reader) *httptest.ResponseRecorder {
+	return serveRequest(h.TusPatch, tusRequest(http.MethodPatch, body, "Upload-Offset", fmt.Sprint(offset)),
+		userID, map[string]string{"upload_id": uploadID.String()})
+}
+
+func tusHead(h *EditorHandler, userID, uploadID uuid.UUID) *httptest.ResponseRecorder {
+	return serveRequest(h.TusHead, tusRequest(http.MethodHead, nil),
+		userID, map[string]string{"upload_id": uploadID.String()})
+}
+
+// An upload in two chunks: a chunk at the wrong offset is refused, HEAD
+// says where to resume, and the chunk reaching Upload-Length completes it.
+func TestTusUpload(t *testing.T) {
+	db, store := newFakeDB(), newMemStorage()
+	h := newTusHandler(db, store)
+	user := uuid.New()
+	uploadID := tusCreate(t, h, user, 10)
+
+	w := tusHead(h, user, uploadID)
+	if w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != "0" || w.Header().Get("Upload-Length") != "10" {
+		t.Fatalf("HEAD: status = %d, headers %v", w.Code, w.Header())
+	}
+
+	if w := tusPatch(h, user, uploadID, 0, strings.NewReader("01234")); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "5" {
+		t.Fatalf("first chunk: status = %d, Upload-Offset %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+
+	// A retry of the first chunk, or a skip ahead, doesn't match the 5 bytes stored
+	for _, offset := range []int{0, 7} {
+		if w := tusPatch(h, user, uploadID, offset, strings.NewReader("abc")); w.Code != http.StatusConflict {
+			t.Fatalf("offset %d: status = %d, want 409", offset, w.Code)
+		}
+	}
+	if got := db.upload(uploadID).Offset; got != 5 {
+		t.Fatalf("offset after mismatched PATCHes = %d, want 5", got)
+	}
+
+	// Past the declared length is refused before anything is read
+	if w := tusPatch(h, user, uploadID, 5, strings.NewReader("56789X")); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("overlong chunk: status = %d, want 413", w.Code)
+	}
+
+	// Someone else's upload doesn't exist
+	if w := tusHead(h, uuid.New(), uploadID); w.Code != http.StatusNotFound {
+		t.Fatalf("other user: status = %d, want 404", w.Code)
+	}
+
+	w = tusPatch(h, user, uploadID, 5, strings.NewReader("56789"))
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "10" || w.Header().Get("Upload-Expires") != "" {
+		t.Fatalf("last chunk: status = %d, headers %v", w.Code, w.Header())
+	}
+
+	upload := db.upload(uploadID)
+	if upload.Status != models.UploadStatusCompleted || upload.AssetID == nil {
+		t.Fatalf("upload = %+v, want completed with an asset", upload)
+	}
+	if len(db.assets) != 1 || db.assets[0].AssetID != *upload.AssetID || db.assets[0].SizeBytes != 10 {
+		t.Fatalf("assets = %+v", db.assets)
+	}
+	// The parts were joined and removed: only the final file is left
+	if got := store.contents(); len(got) != 1 || got[0] != "0123456789" {
+		t.Fatalf("stored objects = %q, want the joined file", got)
+	}
+	if len(db.parts[uploadID]) != 0 {
+		t.Fatalf("parts left: %v", db.parts[uploadID])
+	}
+
+	// Repeating the completing request at the full offset is harmless
+	if w := tusPatch(h, user, uploadID, 10, strings.NewReader("")); w.Code != http.StatusNoContent {
+		t.Fatalf("repeat at full offset: status = %d", w.Code)
+	}
+}
+
+// brokenBody delivers data, then fails like a dropped connection.
+type brokenBody struct{ data io.Reader }
+
+func (b brokenBody) Read(p []byte) (int, error) {
+	n, err := b.data.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+// A chunk cut off mid-way keeps what arrived; the client resumes from HEAD.
+func TestTusResume(t *testing.T) {
+	db, store := newFakeDB(), newMemStorage()
+	h := newTusHandler(db, store)
+	user := uuid.New()
+	uploadID := tusCreate(t, h, user, 10)
+
+	if w := tusPatch(h, user, uploadID, 0, brokenBody{strings.NewReader("0123")}); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "4" {
+		t.Fatalf("dropped chunk: status = %d, Upload-Offset %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+
+	w := tusHead(h, user, uploadID)
+	if w.Header().Get("Upload-Offset") != "4" || w.Header().Get("Upload-Expires") == "" {
+		t.Fatalf("HEAD after drop: headers %v", w.Header())
+	}
+	if w := tusPatch(h, user, uploadID, 4, strings.NewReader("456789")); w.Code != http.StatusNoContent {
+		t.Fatalf("resumed chunk: status = %d (%s)", w.Code, w.Body)
+	}
+	if db.upload(uploadID).Status != models.UploadStatusCompleted {
+		t.Fatalf("upload = %+v, want completed", db.upload(uploadID))
+	}
+	if got := store.contents(); len(got) != 1 || got[0] != "0123456789" {
+		t.Fatalf("stored objects = %q", got)
+	}
+}
+
+// Past Upload-Expires an unfinished upload is gone: 410 on HEAD and PATCH.
+func TestTusExpired(t *testing.T) {
+	db := newFakeDB()
+	h := newTusHandler(db, newMemStorage())
+	user := uuid.New()
+	uploadID := tusCreate(t, h, user, 10)
+	tusPatch(h, user, uploadID, 0, strings.NewReader("01234"))
+
+	db.expireUpload(uploadID)
+	if w := tusHead(h, user, uploadID); w.Code != http.StatusGone {
+		t.Fatalf("HEAD: status = %d, want 410", w.Code)
+	}
+	if w := tusPatch(h, user, uploadID, 5, strings.NewReader("56789")); w.Code != http.StatusGone {
+		t.Fatalf("PATCH: status = %d, want 410", w.Code)
+	}
+	if db.upload(uploadID).Offset != 5 {
+		t.Fatalf("expired upload moved: %+v", db.upload(uploadID))
+	}
+}
+
+// Every tus endpoint refuses another protocol version with 412 and says
+// which one it speaks.
+func TestTusResumableVersion(t *testing.T) {
+	db := newFakeDB()
+	h := newTusHandler(db, newMemStorage())
+	user := uuid.New()
+	uploadID := tusCreate(t, h, user, 10)
+	vars := map[string]string{"upload_id": uploadID.String()}
+
+	for name, handler := range map[string]http.HandlerFunc{
+		http.MethodPost:   h.TusCreate,
+		http.MethodHead:   h.TusHead,
+		http.MethodPatch:  h.TusPatch,
+		http.MethodDelete: h.TusDelete,
+	} {
+		for _, version := range []string{"0.2.2", ""} {
+			r := tusRequest(name, strings.NewReader("01234"), "Upload-Length", "10", "Upload-Offset", "0")
+			r.Header.Set("Tus-Resumable", version)
+			w := serveRequest(handler, r, user, vars)
+			if w.Code != http.StatusPreconditionFailed || w.Header().Get("Tus-Version") != tusVersion {
+				t.Errorf("%s with Tus-Resumable %q: status = %d, Tus-Version %q", name, version, w.Code, w.Header().Get("Tus-Version"))
+			}
+		}
+	}
+	if u := db.upload(uploadID); u.Offset != 0 {
+		t.Fatalf("upload changed: %+v", u)
+	}
+	if len(db.uploads) != 1 {
+		t.Fatalf("uploads = %d, want 1", len(db.uploads))
+	}
+}
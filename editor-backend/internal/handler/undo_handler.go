@@ -0,0 +1,126 @@
+// internal/handler/undo_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// Undo reverts the session's most recent timeline write — a save, an edit
+// operation batch, a restore — by saving the timeline before it as a new
+// version. The stack is per session, so it survives a reload and is shared
+// across devices and collaborators. Needs the editor role.
+//
+// POST /api/v1/sessions/{id}/undo   { "version": 7 }   (optional, or If-Match)
+//
+// Response: { "version": 8, "timeline": {...}, "undo_depth": 3, "redo_depth": 1 }
+func (h *EditorHandler) Undo(w http.ResponseWriter, r *http.Request) {
+	h.undoRedo(w, r, h.Service.Undo)
+}
+
+// Redo re-applies the last undone write. Any other write clears the redo
+// stack.
+//
+// POST /api/v1/sessions/{id}/redo   { "version": 8 }   (optional, or If-Match)
+func (h *EditorHandler) Redo(w http.ResponseWriter, r *http.Request) {
+	h.undoRedo(w, r, h.Service.Redo)
+}
+
+func (h *EditorHandler) undoRedo(
+	w http.ResponseWriter, r *http.Request,
+	apply func(sessionID, userID, workspaceID uuid.UUID, expectedVersion int) (*service.UndoResult, error),
+) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	// Body is optional — an empty POST skips the version check
+	var body struct {
+		Version int `json:"version"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	expectedVersion, ok := requestVersion(w, r, body.Version)
+	if !ok {
+		return
+	}
+
+	result, err := apply(sessionID, userID, getWorkspaceID(r), expectedVersion)
+	if err != nil {
+		respondUndoError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(result.Version))
+	respondJSON(w, http.StatusOK, result)
+}
+
+// GetUndoState reports how many undo / redo steps the session has, so the
+// editor can enable its buttons after a reload.
+//
+// GET /api/v1/sessions/{id}/undo
+//
+// Response: { "undo_depth": 3, "redo_depth": 0 }
+func (h *EditorHandler) GetUndoState(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	state, err := h.Service.GetUndoState(sessionID, userID, getWorkspaceID(r))
+	if err != nil {
+		respondUndoError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, state)
+}
+
+func respondUndoError(w http.ResponseWriter, err error) {
+	var conflict *service.VersionConflictError
+	if errors.As(err, &conflict) {
+		respondVersionConflict(w, conflict)
+		return
+	}
+	if errors.Is(err, service.ErrTimelineUnreadable) {
+		respondTimelineUnreadable(w, err)
+		return
+	}
+
+	switch err {
+	case service.ErrNothingToUndo, service.ErrNothingToRedo:
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Undo error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to undo / redo")
+	}
+}
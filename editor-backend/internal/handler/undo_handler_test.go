@@ -0,0 +1,187 @@
+// internal/handler/undo_handler_test.go
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// titled is testTimeline with the title clip saying text.
+func titled(text string) string {
+	return strings.Replace(testTimeline, `"Hello"`, `"`+text+`"`, 1)
+}
+
+// title reads the title clip's text from the stored timeline.
+func title(t *testing.T, db *fakeDB, sessionID uuid.UUID) string {
+	t.Helper()
+	db.mu.Lock()
+	raw := db.sessions[sessionID].timeline
+	db.mu.Unlock()
+	var tl struct {
+		Tracks []struct {
+			Clips []struct {
+				Text string `json:"text"`
+			} `json:"clips"`
+		} `json:"tracks"`
+	}
+	if err := json.Unmarshal(raw, &tl); err != nil {
+		t.Fatalf("stored timeline %s: %v", raw, err)
+	}
+	return tl.Tracks[0].Clips[0].Text
+}
+
+type undoFixture struct {
+	t         *testing.T
+	db        *fakeDB
+	h         *EditorHandler
+	owner     uuid.UUID
+	sessionID uuid.UUID
+}
+
+func newUndoFixture(t *testing.T, depth int) *undoFixture {
+	db := newFakeDB()
+	owner := uuid.New()
+	sessionID := db.addSession(owner)
+	db.setTimeline(sessionID, titled("A"))
+	h := newTestHandler(db)
+	h.Service.UndoDepth = depth
+	return &undoFixture{t: t, db: db, h: h, owner: owner, sessionID: sessionID}
+}
+
+func (f *undoFixture) call(handler http.HandlerFunc, method, body string, want int) {
+	f.t.Helper()
+	w := serve(handler, method, body, f.owner, map[string]string{"id": f.sessionID.String()})
+	if w.Code != want {
+		f.t.Fatalf("status = %d (%s), want %d", w.Code, w.Body, want)
+	}
+}
+
+func (f *undoFixture) save(text string) {
+	f.t.Helper()
+	f.call(f.h.SaveSession, http.MethodPut, `{"timeline":`+titled(text)+`}`, http.StatusOK)
+}
+
+func (f *undoFixture) retitle(text string) {
+	f.t.Helper()
+	f.call(f.h.ApplyOperations, http.MethodPost,
+		`{"operations":[{"type":"update_clip","clip_id":"title","changes":{"text":"`+text+`"}}]}`, http.StatusOK)
+}
+
+func (f *undoFixture) undo(want int) { f.t.Helper(); f.call(f.h.Undo, http.MethodPost, "", want) }
+func (f *undoFixture) redo(want int) { f.t.Helper(); f.call(f.h.Redo, http.MethodPost, "", want) }
+
+// expect checks the stored title and the depths GET /undo reports.
+func (f *undoFixture) expect(current string, undo, redo int) {
+	f.t.Helper()
+	if got := title(f.t, f.db, f.sessionID); got != current {
+		f.t.Fatalf("title = %q, want %q", got, current)
+	}
+	w := serve(f.h.GetUndoState, http.MethodGet, "", f.owner, map[string]string{"id": f.sessionID.String()})
+	var state struct {
+		UndoDepth int `json:"undo_depth"`
+		RedoDepth int `json:"redo_depth"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &state)
+	if state.UndoDepth != undo || state.RedoDepth != redo {
+		f.t.Fatalf("depths = %d undo / %d redo, want %d / %d", state.UndoDepth, state.RedoDepth, undo, redo)
+	}
+}
+
+// Full saves and operation batches share one stack: undo walks back through
+// both, redo walks forward again, and each undo is itself a new version.
+func TestUndoRedoAcrossSavesAndOps(t *testing.T) {
+	f := newUndoFixture(t, 0)
+	f.save("B")
+	f.retitle("C")
+	f.save("D")
+	f.expect("D", 3, 0)
+
+	f.undo(http.StatusOK)
+	f.expect("C", 2, 1)
+	f.undo(http.StatusOK)
+	f.expect("B", 1, 2)
+	f.redo(http.StatusOK)
+	f.expect("C", 2, 1)
+	f.undo(http.StatusOK)
+	f.undo(http.StatusOK)
+	f.expect("A", 0, 3)
+	f.undo(http.StatusConflict)
+
+	if got := f.db.sessions[f.sessionID].version; got != 9 {
+		t.Fatalf("version = %d, want 9 (4 edits + 4 undo / redo)", got)
+	}
+	// An op after undo lands on the restored timeline
+	f.retitle("E")
+	f.expect("E", 1, 0)
+	f.undo(http.StatusOK)
+	f.expect("A", 0, 1)
+}
+
+// Any new edit ends the redo branch.
+func TestUndoNewEditClearsRedo(t *testing.T) {
+	for name, edit := range map[string]func(f *undoFixture){
+		"full save": func(f *undoFixture) { f.save("X") },
+		"operation": func(f *undoFixture) { f.retitle("X") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newUndoFixture(t, 0)
+			f.save("B")
+			f.save("C")
+			f.undo(http.StatusOK)
+			f.undo(http.StatusOK)
+			f.expect("A", 0, 2)
+
+			edit(f)
+			f.expect("X", 1, 0)
+			f.redo(http.StatusConflict)
+			f.undo(http.StatusOK)
+			f.expect("A", 0, 1)
+		})
+	}
+}
+
+// Each stack keeps the newest UndoDepth steps; the oldest fall off.
+func TestUndoDepth(t *testing.T) {
+	f := newUndoFixture(t, 2)
+	for _, text := range []string{"B", "C", "D", "E"} {
+		f.save(text)
+	}
+	f.expect("E", 2, 0)
+	if got := f.db.stack(f.sessionID, "undo"); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Fatalf("undo stack versions = %v, want [3 4]", got)
+	}
+
+	f.undo(http.StatusOK)
+	f.undo(http.StatusOK)
+	f.expect("C", 0, 2)
+	f.undo(http.StatusConflict)
+
+	// The redo stack is trimmed the same way
+	f.redo(http.StatusOK)
+	f.redo(http.StatusOK)
+	f.expect("E", 2, 0)
+}
+
+// A step that can't be decoded is refused like an unreadable session, and
+// stays put rather than being written over the timeline.
+func TestUndoUnreadableStep(t *testing.T) {
+	f := newUndoFixture(t, 0)
+	f.db.setTimeline(f.sessionID, `{"tracks":`)
+	f.save("B")
+
+	w := serve(f.h.Undo, http.MethodPost, "", f.owner, map[string]string{"id": f.sessionID.String()})
+	if w.Code != http.StatusConflict || !strings.Contains(jsonField(t, w, "error"), "could not be read") {
+		t.Fatalf("status = %d (%s), want 409", w.Code, w.Body)
+	}
+	f.expect("B", 1, 0)
+
+	// A full save puts a readable step on top
+	f.save("C")
+	f.undo(http.StatusOK)
+	f.expect("B", 1, 1)
+}
@@ -0,0 +1,159 @@
+// internal/handler/upload_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"editor-backend/internal/service"
+	"editor-backend/internal/storage"
+	"editor-backend/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// uploadBodyTimeout replaces the server's 10s read / 30s write timeouts
+// for a request that carries media (local direct upload, tus PATCH) — a
+// 500MB body takes a while.
+const uploadBodyTimeout = 30 * time.Minute
+
+// CreateUpload starts a direct upload: the file is validated from its
+// description and the client gets a presigned URL to PUT it to, straight
+// into storage. Needs the workspace member role, like POST /upload.
+//
+// POST /api/v1/uploads
+//
+//	{ "filename": "intro.mp4", "content_type": "video/mp4", "size": 73400320 }
+//
+// Response 201:
+//
+//	{ "upload_id": "...", "upload_url": "https://...", "method": "PUT",
+//	  "headers": { "Content-Type": "video/mp4", ... }, "expires_at": "...", ... }
+func (h *EditorHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Filename    string `json:"filename"`
+		ContentType string `json:"content_type"`
+		Size        int64  `json:"size"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	slot, err := h.Uploads.CreateUpload(userID, getWorkspaceID(r), req.Filename, req.ContentType, req.Size)
+	if err != nil {
+		respondUploadError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, slot)
+}
+
+// CompleteUpload verifies the file reached storage and records it as an
+// asset. Safe to retry; answers exactly like POST /upload.
+//
+// POST /api/v1/uploads/{upload_id}/complete
+//
+// Response: { "file_url": "...", "asset_id": "..." }
+func (h *EditorHandler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	uploadID, err := parseUUIDParam(r, "upload_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid upload id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	asset, err := h.Uploads.CompleteUpload(uploadID, userID)
+	if err != nil {
+		respondUploadError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"file_url": asset.FileURL,
+		"asset_id": asset.AssetID.String(),
+	})
+}
+
+// LocalUpload receives a direct upload when STORAGE_TYPE=local — the
+// stand-in for S3's presigned PUT. The signed URL is the only credential,
+// so this route sits outside authentication.
+//
+// PUT /api/v1/uploads/local/{key}?size=...&content_type=...&expires=...&signature=...
+func (h *EditorHandler) LocalUpload(w http.ResponseWriter, r *http.Request) {
+	local, ok := h.Storage.(*storage.LocalStorage)
+	if !ok {
+		respondError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	size, contentType, err := local.VerifyUpload(key, r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusForbidden, storage.ErrUploadURL.Error())
+		return
+	}
+	if r.Header.Get("Content-Type") != contentType {
+		respondError(w, http.StatusBadRequest, "Content-Type must be "+contentType)
+		return
+	}
+	if r.ContentLength >= 0 && r.ContentLength != size {
+		respondError(w, http.StatusBadRequest, storage.ErrSizeMismatch.Error())
+		return
+	}
+
+	extendUploadDeadlines(w)
+
+	if _, err := local.Put(key, http.MaxBytesReader(w, r.Body, size), size); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.Is(err, storage.ErrSizeMismatch) || errors.As(err, &tooLarge) {
+			respondError(w, http.StatusBadRequest, storage.ErrSizeMismatch.Error())
+			return
+		}
+		log.Println("LocalUpload error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to store upload")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func extendUploadDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Now().Add(uploadBodyTimeout))
+	rc.SetWriteDeadline(time.Now().Add(uploadBodyTimeout))
+}
+
+func respondUploadError(w http.ResponseWriter, err error) {
+	switch err {
+	case validation.ErrEmptyFile, validation.ErrFileTooLarge,
+		validation.ErrInvalidFileType, validation.ErrFilenameTooLong:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrUploadNotFound:
+		respondError(w, http.StatusNotFound, err.Error())
+	case service.ErrUploadNotReceived, service.ErrUploadFailed:
+		respondError(w, http.StatusConflict, err.Error())
+	case service.ErrUploadMismatch:
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Upload error:", err)
+		respondError(w, http.StatusInternalServerError, "failed to process upload")
+	}
+}
@@ -0,0 +1,195 @@
+// internal/handler/version_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"editor-backend/internal/service"
+	"editor-backend/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// ListVersions returns the session's saved versions, newest first (no timelines).
+//
+// GET /api/v1/sessions/{id}/versions?limit=50
+func (h *EditorHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	versions, err := h.Service.ListVersions(sessionID, userID, getWorkspaceID(r), limit)
+	if err != nil {
+		respondVersionError(w, err, "failed to list versions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"versions": versions})
+}
+
+// GetVersion returns one stored version with its full timeline.
+//
+// GET /api/v1/sessions/{id}/versions/{version}
+func (h *EditorHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	version, err := parseVersionParam(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid version — must be a positive integer")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	v, err := h.Service.GetVersion(sessionID, userID, getWorkspaceID(r), version)
+	if err != nil {
+		respondVersionError(w, err, "failed to get version")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, v)
+}
+
+// RestoreVersion saves an old version's timeline as a new version.
+// Accepts the same optional concurrency check as SaveSession
+// (body "version" or If-Match) against the session's CURRENT version.
+//
+// POST /api/v1/sessions/{id}/versions/{version}/restore
+func (h *EditorHandler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	version, err := parseVersionParam(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid version — must be a positive integer")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	// Body is optional here — an empty POST restores unconditionally
+	var body struct {
+		Version int `json:"version"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	expectedVersion := body.Version
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		v, ok := parseVersionETag(ifMatch)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "invalid If-Match header — expected an ETag from GET /sessions/{id}")
+			return
+		}
+		if v != 0 {
+			expectedVersion = v
+		}
+	}
+
+	newVersion, err := h.Service.RestoreVersion(sessionID, userID, getWorkspaceID(r), version, expectedVersion)
+	if err != nil {
+		respondVersionError(w, err, "failed to restore version")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(newVersion))
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "restored",
+		"restored_from": version,
+		"version":       newVersion,
+	})
+}
+
+// DiffVersions returns a structural diff between two versions:
+// clips added / removed / moved / trimmed / modified and transition changes.
+//
+// GET /api/v1/sessions/{id}/versions/diff?from=3&to=7
+func (h *EditorHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session id — must be a UUID")
+		return
+	}
+
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		respondError(w, http.StatusBadRequest, "from and to must be positive version numbers")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	diff, err := h.Service.DiffVersions(sessionID, userID, getWorkspaceID(r), from, to)
+	if err != nil {
+		respondVersionError(w, err, "failed to diff versions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, diff)
+}
+
+func parseVersionParam(r *http.Request) (int, error) {
+	v, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil || v < 1 {
+		return 0, errors.New("invalid version")
+	}
+	return v, nil
+}
+
+func respondVersionError(w http.ResponseWriter, err error, fallback string) {
+	var conflict *service.VersionConflictError
+	var invalid *validation.TimelineError
+	switch {
+	case errors.As(err, &conflict):
+		respondVersionConflict(w, conflict)
+	case errors.As(err, &invalid):
+		// Versions saved before validation existed can fail today's rules
+		respondInvalidTimeline(w, invalid)
+	case err == service.ErrVersionNotFound:
+		respondError(w, http.StatusNotFound, "version not found — it may have been pruned by retention")
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Version error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,228 @@
+// internal/handler/workspace_handler.go
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"editor-backend/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// CreateWorkspace creates a workspace with the caller as its admin.
+//
+// POST /api/v1/workspaces   { "name": "Acme Agency" }
+func (h *EditorHandler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ws, err := h.Workspaces.CreateWorkspace(userID, req.Name)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to create workspace")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, ws)
+}
+
+// ListWorkspaces returns the workspaces the caller belongs to.
+//
+// GET /api/v1/workspaces
+func (h *EditorHandler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	workspaces, err := h.Workspaces.ListWorkspaces(userID)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to list workspaces")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"workspaces": workspaces})
+}
+
+// ListWorkspaceMembers returns the workspace's members. Any member may call it.
+//
+// GET /api/v1/workspaces/{id}/members
+func (h *EditorHandler) ListWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := workspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.Workspaces.ListWorkspaceMembers(workspaceID, userID)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to list workspace members")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"members": members})
+}
+
+// SetWorkspaceMember adds a user to the workspace or changes their role.
+// Admin only.
+//
+// PUT /api/v1/workspaces/{id}/members/{user_id}   { "role": "member" }
+func (h *EditorHandler) SetWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := workspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := parseUUIDParam(r, "user_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id — must be a UUID")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	member, err := h.Workspaces.SetWorkspaceMember(workspaceID, userID, memberID, req.Role)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to set workspace member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, member)
+}
+
+// RemoveWorkspaceMember removes a user from the workspace. Admins can remove
+// anyone; members can remove themselves.
+//
+// DELETE /api/v1/workspaces/{id}/members/{user_id}
+func (h *EditorHandler) RemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := workspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := parseUUIDParam(r, "user_id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id — must be a UUID")
+		return
+	}
+
+	if err := h.Workspaces.RemoveWorkspaceMember(workspaceID, userID, memberID); err != nil {
+		respondWorkspaceError(w, err, "failed to remove workspace member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+// ListWorkspaceSessions returns every session in the workspace. Accepts the
+// same filters, ordering and cursor as GET /sessions.
+//
+// GET /api/v1/workspaces/{id}/sessions
+func (h *EditorHandler) ListWorkspaceSessions(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := workspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseSessionFilter(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListWorkspaceSessions(workspaceID, userID, filter)
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to list sessions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+// ListWorkspaceAssets returns the workspace's uploaded media, newest first.
+//
+// GET /api/v1/workspaces/{id}/assets?limit=20&cursor=<next_cursor>
+func (h *EditorHandler) ListWorkspaceAssets(w http.ResponseWriter, r *http.Request) {
+	workspaceID, userID, ok := workspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	page, err := h.Workspaces.ListWorkspaceAssets(workspaceID, userID, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		respondWorkspaceError(w, err, "failed to list assets")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+// workspaceRequest parses the {id} workspace and the caller. A request scoped
+// to one workspace (token claim / X-Workspace-ID) cannot reach another.
+func workspaceRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	workspaceID, err := parseUUIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid workspace id — must be a UUID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if active := getWorkspaceID(r); active != uuid.Nil && active != workspaceID {
+		respondError(w, http.StatusForbidden, "this request is scoped to another workspace")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return workspaceID, userID, true
+}
+
+func respondWorkspaceError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case service.ErrInvalidWorkspace, service.ErrLastWorkspaceAdmin:
+		respondError(w, http.StatusBadRequest, err.Error())
+	case service.ErrInvalidRole:
+		respondError(w, http.StatusBadRequest, "role must be admin, member or viewer")
+	case service.ErrMemberNotFound:
+		respondError(w, http.StatusNotFound, "member not found")
+	case service.ErrInvalidCursor:
+		respondError(w, http.StatusBadRequest, "invalid cursor")
+	default:
+		if respondAccessError(w, err) {
+			return
+		}
+		log.Println("Workspace error:", err)
+		respondError(w, http.StatusInternalServerError, fallback)
+	}
+}
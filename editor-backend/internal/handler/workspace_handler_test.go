@@ -0,0 +1,192 @@
+// internal/handler/workspace_handler_test.go
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// workspaceFixture: a workspace with a session in it, a second workspace the
+// owner also belongs to, and the owner's personal session.
+type workspaceFixture struct {
+	db                *fakeDB
+	h                 *EditorHandler
+	workspace, other  uuid.UUID
+	session, personal uuid.UUID
+	users             map[string]uuid.UUID
+}
+
+func newWorkspaceFixture() *workspaceFixture {
+	db := newFakeDB()
+	f := &workspaceFixture{db: db, h: newTestHandler(db), workspace: uuid.New(), other: uuid.New(), users: map[string]uuid.UUID{}}
+	for _, name := range []string{"owner", "admin", "member", "viewer", "viewer+editor", "outside editor", "stranger"} {
+		f.users[name] = uuid.New()
+	}
+	db.addWorkspaceMember(f.workspace, f.users["owner"], models.WorkspaceRoleMember)
+	db.addWorkspaceMember(f.workspace, f.users["admin"], models.WorkspaceRoleAdmin)
+	db.addWorkspaceMember(f.workspace, f.users["member"], models.WorkspaceRoleMember)
+	db.addWorkspaceMember(f.workspace, f.users["viewer"], models.WorkspaceRoleViewer)
+	db.addWorkspaceMember(f.workspace, f.users["viewer+editor"], models.WorkspaceRoleViewer)
+	db.addWorkspaceMember(f.other, f.users["owner"], models.WorkspaceRoleAdmin)
+
+	f.session = db.addWorkspaceSession(f.users["owner"], f.workspace)
+	db.setTimeline(f.session, testTimeline)
+	db.addMember(f.session, f.users["viewer+editor"], models.RoleEditor)
+	// Shared on the session, but not (or no longer) in the workspace
+	db.addMember(f.session, f.users["outside editor"], models.RoleEditor)
+
+	f.personal = db.addSession(f.users["owner"])
+	db.setTimeline(f.personal, testTimeline)
+	return f
+}
+
+// Sessions are reached through the workspace they live in: from any other
+// workspace (or the personal space) they don't exist, and inside it the
+// workspace role counts alongside the session role.
+func TestWorkspaceSessionScoping(t *testing.T) {
+	f := newWorkspaceFixture()
+	ok, forbidden, missing := http.StatusOK, http.StatusForbidden, http.StatusNotFound
+
+	cases := []struct {
+		name      string
+		user      string
+		workspace func(f *workspaceFixture) uuid.UUID
+		personal  bool // target the personal session
+		get, save int
+	}{
+		{"owner in the workspace", "owner", inWorkspace, false, ok, ok},
+		{"owner from the personal space", "owner", inPersonal, false, missing, missing},
+		{"owner from another workspace", "owner", inOther, false, missing, missing},
+		{"workspace admin", "admin", inWorkspace, false, ok, ok},
+		{"workspace member", "member", inWorkspace, false, ok, ok},
+		{"workspace viewer", "viewer", inWorkspace, false, ok, forbidden},
+		{"workspace viewer who is a session editor", "viewer+editor", inWorkspace, false, ok, ok},
+		{"session editor outside the workspace", "outside editor", inWorkspace, false, forbidden, forbidden},
+		{"session editor outside, from the personal space", "outside editor", inPersonal, false, missing, missing},
+		{"workspace stranger", "stranger", inWorkspace, false, forbidden, forbidden},
+		{"personal session from the workspace", "owner", inWorkspace, true, missing, missing},
+		{"personal session from the personal space", "owner", inPersonal, true, ok, ok},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := f.session
+			if tc.personal {
+				target = f.personal
+			}
+			vars := map[string]string{"id": target.String()}
+			user, workspace := f.users[tc.user], tc.workspace(f)
+
+			w := serveInWorkspace(f.h.GetSession, httptest.NewRequest(http.MethodGet, "/", nil), user, workspace, vars)
+			if w.Code != tc.get {
+				t.Errorf("GET: status = %d (%s), want %d", w.Code, w.Body, tc.get)
+			}
+			save := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"timeline":`+testTimeline+`}`))
+			if w := serveInWorkspace(f.h.SaveSession, save, user, workspace, vars); w.Code != tc.save {
+				t.Errorf("save: status = %d (%s), want %d", w.Code, w.Body, tc.save)
+			}
+			// The canEditSQL write path agrees with authorize
+			ops := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(
+				`{"operations":[{"type":"move_clip","clip_id":"title","start":1}]}`))
+			if w := serveInWorkspace(f.h.ApplyOperations, ops, user, workspace, vars); w.Code != tc.save {
+				t.Errorf("operations: status = %d (%s), want %d", w.Code, w.Body, tc.save)
+			}
+		})
+	}
+}
+
+func inWorkspace(f *workspaceFixture) uuid.UUID { return f.workspace }
+func inPersonal(*workspaceFixture) uuid.UUID    { return uuid.Nil }
+func inOther(f *workspaceFixture) uuid.UUID     { return f.other }
+
+// sessionIDs reads the session ids out of a SessionPage response.
+func sessionIDs(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+	var page struct {
+		Sessions []struct {
+			SessionID string `json:"session_id"`
+		} `json:"sessions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("%s: %v", w.Body, err)
+	}
+	ids := []string{}
+	for _, s := range page.Sessions {
+		ids = append(ids, s.SessionID)
+	}
+	return ids
+}
+
+// GET /sessions lists the caller's sessions in the active workspace only;
+// GET /workspaces/{id}/sessions lists everyone's, for workspace members.
+func TestWorkspaceSessionLists(t *testing.T) {
+	f := newWorkspaceFixture()
+	list := func(user, workspace uuid.UUID) *httptest.ResponseRecorder {
+		return serveInWorkspace(f.h.ListSessions, httptest.NewRequest(http.MethodGet, "/", nil), user, workspace, nil)
+	}
+	listWorkspace := func(user, active, workspace uuid.UUID) *httptest.ResponseRecorder {
+		return serveInWorkspace(f.h.ListWorkspaceSessions, httptest.NewRequest(http.MethodGet, "/", nil), user, active,
+			map[string]string{"id": workspace.String()})
+	}
+	owner := f.users["owner"]
+
+	if got := sessionIDs(t, list(owner, f.workspace)); fmt.Sprint(got) != fmt.Sprint([]string{f.session.String()}) {
+		t.Errorf("owner in the workspace: %v", got)
+	}
+	if got := sessionIDs(t, list(owner, uuid.Nil)); fmt.Sprint(got) != fmt.Sprint([]string{f.personal.String()}) {
+		t.Errorf("owner in the personal space: %v", got)
+	}
+	if got := sessionIDs(t, list(owner, f.other)); len(got) != 0 {
+		t.Errorf("owner in the other workspace: %v", got)
+	}
+	// A workspace member sees nothing of their own here, but the workspace list has it all
+	if got := sessionIDs(t, list(f.users["member"], f.workspace)); len(got) != 0 {
+		t.Errorf("member's own sessions: %v", got)
+	}
+	if got := sessionIDs(t, listWorkspace(f.users["viewer"], f.workspace, f.workspace)); fmt.Sprint(got) != fmt.Sprint([]string{f.session.String()}) {
+		t.Errorf("workspace list: %v", got)
+	}
+
+	// Outsiders can't list, and a request scoped to one workspace can't reach another
+	if w := list(f.users["outside editor"], f.workspace); w.Code != http.StatusForbidden {
+		t.Errorf("outsider listing: status = %d, want 403", w.Code)
+	}
+	if w := listWorkspace(f.users["outside editor"], uuid.Nil, f.workspace); w.Code != http.StatusForbidden {
+		t.Errorf("outsider listing the workspace: status = %d, want 403", w.Code)
+	}
+	if w := listWorkspace(owner, f.other, f.workspace); w.Code != http.StatusForbidden {
+		t.Errorf("cross-workspace listing: status = %d, want 403", w.Code)
+	}
+}
+
+// Uploads land in the active workspace and need at least the member role.
+func TestWorkspaceUploadScoping(t *testing.T) {
+	f := newWorkspaceFixture()
+	f.h.Uploads = newTusHandler(f.db, newMemStorage()).Uploads
+	create := func(user uuid.UUID) *httptest.ResponseRecorder {
+		r := tusRequest(http.MethodPost, nil, "Upload-Length", "10", "Upload-Metadata", "filename Y2xpcC5tcDQ=")
+		return serveInWorkspace(f.h.TusCreate, r, user, f.workspace, nil)
+	}
+
+	for user, want := range map[string]int{
+		"member":         http.StatusCreated,
+		"viewer":         http.StatusForbidden,
+		"outside editor": http.StatusForbidden,
+	} {
+		if w := create(f.users[user]); w.Code != want {
+			t.Errorf("%s: status = %d (%s), want %d", user, w.Code, w.Body, want)
+		}
+	}
+	for _, u := range f.db.uploads {
+		if u.WorkspaceID == nil || *u.WorkspaceID != f.workspace {
+			t.Errorf("upload workspace = %v, want %s", u.WorkspaceID, f.workspace)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// internal/jsonpatch/jsonpatch_test.go
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sameJSON compares two documents by value, ignoring key order.
+func sameJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %v (%s)", err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("bad expectation %s: %v", want, err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+// RFC 6902 Appendix A. want "" means the patch must fail with err (nil =
+// any error).
+func TestApplyRFC6902AppendixA(t *testing.T) {
+	cases := []struct {
+		name, doc, patch, want string
+		err                    error
+	}{
+		{"A.1 adding an object member",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"baz":"qux","foo":"bar"}`, nil},
+		{"A.2 adding an array element",
+			`{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`, nil},
+		{"A.3 removing an object member",
+			`{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`,
+			`{"foo":"bar"}`, nil},
+		{"A.4 removing an array element",
+			`{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`, nil},
+		{"A.5 replacing a value",
+			`{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`, nil},
+		{"A.6 moving a value",
+			`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"A.7 moving an array element",
+			`{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`, nil},
+		{"A.8 testing a value: success",
+			`{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"A.9 testing a value: error",
+			`{"baz":"qux"}`,
+			`[{"op":"test","path":"/baz","value":"bar"}]`,
+			``, ErrTestFailed},
+		{"A.10 adding a nested member object",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"A.11 ignoring unrecognized elements",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`, nil},
+		{"A.12 adding to a nonexistent target",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			``, ErrPathNotFound},
+		{"A.13 invalid JSON patch document",
+			`{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`,
+			``, nil},
+		{"A.14 ~ escape ordering",
+			`{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`,
+			`{"/":9,"~1":10}`, nil},
+		{"A.15 comparing strings and numbers",
+			`{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":"10"}]`,
+			``, ErrTestFailed},
+		{"A.16 adding an array value",
+			`{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Apply([]byte(tc.doc), []byte(tc.patch))
+			if tc.want == "" {
+				if err == nil || tc.err != nil && !errors.Is(err, tc.err) {
+					t.Fatalf("err = %v, want %v (result %s)", err, tc.err, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameJSON(t, got, tc.want) {
+				t.Fatalf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// RFC 7396 Appendix A.
+func TestMergeRFC7396AppendixA(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		got, err := Merge([]byte(tc.doc), []byte(tc.patch))
+		if err != nil {
+			t.Errorf("Merge(%s, %s): %v", tc.doc, tc.patch, err)
+			continue
+		}
+		if !sameJSON(t, got, tc.want) {
+			t.Errorf("Merge(%s, %s) = %s, want %s", tc.doc, tc.patch, got, tc.want)
+		}
+	}
+}
+
+// A failing operation leaves nothing half-applied, and the error names it.
+func TestApplyAllOrNothing(t *testing.T) {
+	doc := []byte(`{"foo":["a","b"]}`)
+	_, err := Apply(doc, []byte(`[
+		{"op":"add","path":"/foo/-","value":"c"},
+		{"op":"remove","path":"/missing"}
+	]`))
+	if !errors.Is(err, ErrPathNotFound) {
+		t.Fatalf("err = %v, want ErrPathNotFound", err)
+	}
+	if want := "operation 1 (remove /missing)"; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("err = %q, want it to start with %q", err, want)
+	}
+	if string(doc) != `{"foo":["a","b"]}` {
+		t.Fatalf("input changed: %s", doc)
+	}
+
+	for _, patch := range []string{
+		`{}`,
+		`[{"op":"copy","path":"/x"}]`,
+		`[{"op":"move","from":"/foo","path":"/foo/0"}]`,
+		`[{"op":"jump","path":"/x"}]`,
+		`[{"op":"add","path":"foo","value":1}]`,
+		`[{"op":"add","path":"/foo/3","value":1}]`,
+		`[{"op":"replace","path":"/bar","value":1}]`,
+	} {
+		if _, err := Apply(doc, []byte(patch)); err == nil {
+			t.Errorf("patch %s: want an error", patch)
+		}
+	}
+}
+
+// copy duplicates by value: changing the copy leaves the original alone.
+func TestApplyCopy(t *testing.T) {
+	got, err := Apply([]byte(`{"a":{"b":[1]}}`), []byte(`[
+		{"op":"copy","from":"/a","path":"/c"},
+		{"op":"add","path":"/c/b/-","value":2}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJSON(t, got, `{"a":{"b":[1]},"c":{"b":[1,2]}}`) {
+		t.Fatalf("got %s", got)
+	}
+}
@@ -0,0 +1,44 @@
+// internal/models/comment.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment is review feedback pinned to a moment (or range) on the timeline,
+// optionally to one clip. A comment with a ParentID is a reply; replies carry
+// their thread's anchor (start / end / clip) and are resolved with it.
+//
+// Comments live beside the timeline, not inside it, so saves never touch
+// them. When a save removes the clip a comment points at, ClipDeleted is set
+// (and cleared again if the clip comes back, e.g. on restore).
+type Comment struct {
+	CommentID uuid.UUID  `json:"comment_id"`
+	SessionID uuid.UUID  `json:"session_id"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+
+	Start       float64 `json:"start"` // seconds; Start == End pins a single frame
+	End         float64 `json:"end"`
+	ClipID      *string `json:"clip_id,omitempty"`
+	ClipDeleted bool    `json:"clip_deleted"`
+
+	Body     string      `json:"body"`
+	Mentions []uuid.UUID `json:"mentions"`
+
+	// Account holders have AuthorID; share link reviewers have AuthorName
+	// and the ShareID they commented through
+	AuthorID   *uuid.UUID `json:"author_id,omitempty"`
+	AuthorName string     `json:"author_name,omitempty"`
+	ShareID    *uuid.UUID `json:"share_id,omitempty"`
+
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy *uuid.UUID `json:"resolved_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Thread replies, oldest first — only set on top-level comments
+	Replies []Comment `json:"replies,omitempty"`
+}
@@ -0,0 +1,47 @@
+// internal/models/export_job.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportJob is one render request sitting in the export_jobs queue.
+// The API enqueues it; cmd/worker leases, renders and completes it.
+type ExportJob struct {
+	JobID     uuid.UUID `json:"job_id"`
+	SessionID uuid.UUID `json:"session_id"`
+	UserID    uuid.UUID `json:"user_id"`
+
+	// WorkspaceID is copied from the session at enqueue time
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+
+	// Version is the session version the job renders. Timeline is the
+	// session's timeline at that version, snapshotted at enqueue and only
+	// loaded by Claim. Both are empty on jobs queued before snapshots existed.
+	Version  int             `json:"version,omitempty"`
+	Timeline json.RawMessage `json:"-"`
+
+	Status      string    `json:"status"` // "queued" | "running" | "completed" | "failed" | "cancelled"
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	RunAfter    time.Time `json:"run_after"`
+
+	LeasedBy       string     `json:"leased_by,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+
+	// Progress reported by the worker from ffmpeg's -progress output
+	ProgressPercent float64 `json:"progress_percent"`
+	ETASeconds      *int    `json:"eta_seconds,omitempty"`
+	CancelRequested bool    `json:"cancel_requested"`
+
+	LastError string `json:"last_error,omitempty"`
+	OutputURL string `json:"output_url,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
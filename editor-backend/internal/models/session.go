@@ -2,6 +2,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,11 +13,24 @@ type EditorSession struct {
 	UserID    uuid.UUID `json:"user_id"`
 	ContentID uuid.UUID `json:"content_id"`
 
-	Timeline map[string]interface{} `json:"timeline"`
+	// WorkspaceID is the agency / org the session belongs to; nil for a
+	// personal session
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+
+	Timeline Timeline `json:"timeline"`
+
+	// TimelineError is set when the stored timeline can't be read (corrupt,
+	// or a legacy shape the upgrade doesn't know). Timeline is then empty and
+	// RawTimeline holds what is stored; saving a timeline replaces it.
+	TimelineError string          `json:"timeline_error,omitempty"`
+	RawTimeline   json.RawMessage `json:"raw_timeline,omitempty"`
 
 	Version int    `json:"version"`
 	Status  string `json:"status"`
 
+	// Role is the requesting user's role (owner | editor | commenter | viewer)
+	Role string `json:"role,omitempty"`
+
 	// Source context — tracks where this editing session originated
 	// Populated when a clip is sent to the editor from Repurposer or Content Hub
 	SourceAssetID *uuid.UUID `json:"source_asset_id,omitempty"`  // Content Hub asset being edited
@@ -26,7 +40,9 @@ type EditorSession struct {
 
 	// Export tracking — what happened after editing (Phase 2)
 	ExportedAssetID *uuid.UUID `json:"exported_asset_id,omitempty"` // Content Hub asset created by export
-	ExportStatus    string     `json:"export_status,omitempty"`     // "" | "rendering" | "uploading" | "completed" | "failed"
+	ExportStatus    string     `json:"export_status,omitempty"`     // "" | "queued" | "rendering" | "uploading" | "completed" | "failed" | "cancelled"
+	ExportURL       string     `json:"export_url,omitempty"`        // Rendered MP4 location in storage
+	ExportJobID     *uuid.UUID `json:"export_job_id,omitempty"`     // Latest export job — poll GET /exports/{id}
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -0,0 +1,27 @@
+// internal/models/session_member.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session roles, strongest first. The owner is editor_sessions.user_id;
+// the others are rows in editor_session_members.
+const (
+	RoleOwner     = "owner"
+	RoleEditor    = "editor"
+	RoleCommenter = "commenter"
+	RoleViewer    = "viewer"
+)
+
+// SessionMember is one user's access to a session.
+type SessionMember struct {
+	SessionID uuid.UUID  `json:"session_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Role      string     `json:"role"`
+	InvitedBy *uuid.UUID `json:"invited_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
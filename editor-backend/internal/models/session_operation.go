@@ -0,0 +1,21 @@
+// internal/models/session_operation.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionOperation is one collaborative edit as applied by the server.
+// Version is the session version it produced — the server-assigned order
+// every client replays ops in.
+type SessionOperation struct {
+	SessionID  uuid.UUID       `json:"session_id"`
+	Version    int             `json:"version"`
+	UserID     uuid.UUID       `json:"user_id"`
+	ClientOpID string          `json:"client_op_id,omitempty"`
+	Op         json.RawMessage `json:"op"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+// internal/models/session_transition.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session approval states (editor_sessions.status):
+//
+//	draft → in_review → changes_requested → in_review → approved → exported
+//
+// See service.statusTransitions for who may move a session where.
+const (
+	SessionStatusDraft            = "draft"
+	SessionStatusInReview         = "in_review"
+	SessionStatusChangesRequested = "changes_requested"
+	SessionStatusApproved         = "approved"
+	SessionStatusExported         = "exported"
+)
+
+// SessionTransition is one row of a session's approval audit trail.
+// UserID is nil for transitions the system makes (e.g. export completed).
+type SessionTransition struct {
+	TransitionID int64      `json:"transition_id"`
+	SessionID    uuid.UUID  `json:"session_id"`
+	FromStatus   string     `json:"from_status"`
+	ToStatus     string     `json:"to_status"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"`
+	Version      int        `json:"version"` // timeline version the transition applied to
+	Note         string     `json:"note,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+// internal/models/session_version.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionVersion is one saved timeline in a session's history.
+// Timeline is omitted from list responses to keep them small.
+type SessionVersion struct {
+	SessionID uuid.UUID `json:"session_id"`
+	Version   int       `json:"version"`
+	Timeline  *Timeline `json:"timeline,omitempty"`
+	UserID    uuid.UUID `json:"user_id"`
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+// internal/models/share_link.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Share link permissions. Neither lets the holder save; "comment" also lets
+// them read and post comments.
+const (
+	SharePermissionView    = "view"
+	SharePermissionComment = "comment"
+)
+
+// ShareLink is a public, account-less link to a session for outside
+// reviewers. Only a hash of the token is stored; Token is set once, in the
+// response that creates the link.
+type ShareLink struct {
+	ShareID     uuid.UUID  `json:"share_id"`
+	SessionID   uuid.UUID  `json:"session_id"`
+	Token       string     `json:"token,omitempty"`
+	Permission  string     `json:"permission"`
+	HasPassword bool       `json:"has_password"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedBy   uuid.UUID  `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SharedSession is what a share link holder sees — the timeline and the
+// media it plays, nothing that identifies the owner.
+type SharedSession struct {
+	SessionID  uuid.UUID    `json:"session_id"`
+	Permission string       `json:"permission"`
+	Version    int          `json:"version"`
+	Timeline   Timeline     `json:"timeline"`
+	Media      []SharedClip `json:"media"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// SharedClip maps a clip to the absolute URL its media is served from.
+type SharedClip struct {
+	ClipID string `json:"clip_id"`
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+}
@@ -0,0 +1,156 @@
+// internal/models/timeline.go
+package models
+
+// Timeline is the document the editor UI saves and the renderer consumes.
+//
+// Field names follow what editorStore.js writes (snake_case for clip timing,
+// camelCase for textStyle / transitions / selectedClipId) so the JSON the UI
+// sends round-trips unchanged. Anything not declared here is dropped on save.
+type Timeline struct {
+	// SchemaVersion is stamped on every save; older documents are upgraded
+	// on read by timeline.Decode.
+	SchemaVersion int `json:"schema_version"`
+
+	Duration    float64      `json:"duration"`
+	Tracks      []Track      `json:"tracks"`
+	Transitions []Transition `json:"transitions,omitempty"`
+
+	// UI view state — persisted so a reload lands where the user left off
+	ZoomLevel        float64 `json:"zoom_level,omitempty"`
+	PlayheadPosition float64 `json:"playhead_position,omitempty"`
+	SelectedClipID   string  `json:"selectedClipId,omitempty"`
+
+	// Highlight reels (CreateHighlightSession)
+	SessionType    string  `json:"session_type,omitempty"` // "" | "highlight_reel"
+	TargetDuration float64 `json:"target_duration,omitempty"`
+}
+
+// Track types the editor and renderer understand.
+const (
+	TrackTypeVideo = "video"
+	TrackTypeAudio = "audio"
+	TrackTypeText  = "text"
+	TrackTypeImage = "image"
+)
+
+// Track is one lane on the timeline. Visible / Muted are pointers because a
+// missing value means "default" (visible, unmuted), not false.
+type Track struct {
+	TrackID string `json:"track_id,omitempty"`
+	Type    string `json:"type"`
+	Visible *bool  `json:"visible,omitempty"`
+	Muted   *bool  `json:"muted,omitempty"`
+	Clips   []Clip `json:"clips"`
+}
+
+func (t Track) IsVisible() bool { return t.Visible == nil || *t.Visible }
+func (t Track) IsMuted() bool   { return t.Muted != nil && *t.Muted }
+
+// Clip is a video, audio, image or text item placed on a track.
+//
+//	start / end           → position on the timeline (seconds)
+//	trim_start / trim_end → in / out points within the source media (seconds)
+type Clip struct {
+	ClipID         string `json:"clip_id"`
+	OriginalClipID string `json:"original_clip_id,omitempty"`
+	Type           string `json:"type,omitempty"` // empty → the track's type
+
+	Src       string  `json:"src,omitempty"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	Duration  float64 `json:"duration,omitempty"` // source length, informational
+	TrimStart float64 `json:"trim_start,omitempty"`
+	TrimEnd   float64 `json:"trim_end,omitempty"`
+
+	// Text clips
+	Text      string     `json:"text,omitempty"`
+	TextStyle *TextStyle `json:"textStyle,omitempty"`
+	Position  *Position  `json:"position,omitempty"` // centre of the text box, 1280×720 canvas
+	Size      *Size      `json:"size,omitempty"`
+
+	// Per-clip transition picker in the library flow ("none" | "fade" ...)
+	Transition string `json:"transition,omitempty"`
+
+	// Repurposer metadata carried through for display
+	Platform     string  `json:"platform,omitempty"`
+	Score        float64 `json:"score,omitempty"`
+	Topic        string  `json:"topic,omitempty"`
+	AIGenerated  bool    `json:"ai_generated,omitempty"`
+	SourceModule string  `json:"source_module,omitempty"`
+}
+
+// Length is how long the clip occupies the timeline.
+func (c Clip) Length() float64 { return c.End - c.Start }
+
+// KindIn resolves the clip type, defaulting to its track's type.
+func (c Clip) KindIn(track Track) string {
+	if c.Type != "" {
+		return c.Type
+	}
+	return track.Type
+}
+
+// TextStyle mirrors TextPropertiesPanel.jsx. FontWeight is a string because
+// the UI stores both "bold" and numeric weights like "600".
+type TextStyle struct {
+	FontSize        float64 `json:"fontSize,omitempty"`
+	FontFamily      string  `json:"fontFamily,omitempty"`
+	FontWeight      string  `json:"fontWeight,omitempty"`
+	Color           string  `json:"color,omitempty"`
+	BackgroundColor string  `json:"backgroundColor,omitempty"`
+	TextAlign       string  `json:"textAlign,omitempty"`
+}
+
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Size struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Transition types offered by TransitionOverlay.jsx.
+var TransitionTypes = map[string]bool{
+	"none":        true,
+	"fade":        true,
+	"crossfade":   true,
+	"slide-left":  true,
+	"slide-right": true,
+	"zoom":        true,
+	"blur":        true,
+}
+
+// Transition joins two adjacent clips, referenced by clip ID.
+type Transition struct {
+	FromClipID string  `json:"fromClipId"`
+	ToClipID   string  `json:"toClipId"`
+	Type       string  `json:"type"`
+	Duration   float64 `json:"duration"`
+}
+
+// FindClip returns the clip with the given ID and the index of its track.
+func (t *Timeline) FindClip(clipID string) (*Clip, int) {
+	for ti := range t.Tracks {
+		for ci := range t.Tracks[ti].Clips {
+			if t.Tracks[ti].Clips[ci].ClipID == clipID {
+				return &t.Tracks[ti].Clips[ci], ti
+			}
+		}
+	}
+	return nil, -1
+}
+
+// End is where the last clip finishes — the length of the rendered output.
+func (t *Timeline) End() float64 {
+	var maxEnd float64
+	for _, track := range t.Tracks {
+		for _, clip := range track.Clips {
+			if clip.End > maxEnd {
+				maxEnd = clip.End
+			}
+		}
+	}
+	return maxEnd
+}
@@ -0,0 +1,42 @@
+// internal/models/upload.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload statuses
+const (
+	UploadStatusPending   = "pending"
+	UploadStatusCompleted = "completed"
+	UploadStatusFailed    = "failed"
+)
+
+// Upload protocols
+const (
+	UploadProtocolPresigned = "presigned" // POST /uploads → PUT → complete
+	UploadProtocolTus       = "tus"       // tus 1.0 resumable, POST /tus
+)
+
+// Upload is a direct-to-storage upload slot. With the presigned protocol
+// the client PUTs the file to the URL it was issued with, then completes
+// the upload, which records the asset. With tus it PATCHes the file in
+// chunks; Offset counts the bytes stored, and the last chunk completes it.
+type Upload struct {
+	UploadID    uuid.UUID  `json:"upload_id"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	UserID      uuid.UUID  `json:"user_id"`
+	StorageKey  string     `json:"-"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Protocol    string     `json:"protocol"`
+	Offset      int64      `json:"offset"`
+	Status      string     `json:"status"`
+	AssetID     *uuid.UUID `json:"asset_id,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
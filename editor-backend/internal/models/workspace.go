@@ -0,0 +1,51 @@
+// internal/models/workspace.go
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace roles, strongest first.
+//
+//	admin  → manage workspace members; edit every session in the workspace
+//	member → create sessions and uploads; edit every session in the workspace
+//	viewer → read every session and asset in the workspace
+const (
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleViewer = "viewer"
+)
+
+// Workspace is a shared project space — typically one agency or team.
+type Workspace struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	Name        string    `json:"name"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Role is the requesting user's workspace role
+	Role string `json:"role,omitempty"`
+}
+
+// WorkspaceMember is one user's membership of a workspace.
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Asset is an uploaded media file, recorded so a workspace can list them.
+type Asset struct {
+	AssetID     uuid.UUID  `json:"asset_id"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	UserID      uuid.UUID  `json:"user_id"`
+	FileURL     string     `json:"file_url"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	SizeBytes   int64      `json:"size_bytes"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,470 @@
+// internal/render/ffmpeg.go
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/validation"
+)
+
+// Output canvas — matches PREVIEW_WIDTH / PREVIEW_HEIGHT in CompositePreview.jsx,
+// so text positions saved by the UI land in the same place in the render.
+const (
+	DefaultWidth  = 1280
+	DefaultHeight = 720
+	DefaultFPS    = 30
+)
+
+// Protocols ffmpeg and ffprobe may open for an input, nested playlist
+// entries included: remote media over http(s), and local files only for
+// inputs inside the work dir.
+const (
+	remoteProtocols = "http,https,tcp,tls"
+	localProtocols  = "file"
+)
+
+var (
+	ErrEmptyTimeline = errors.New("timeline has no renderable clips")
+	ErrFFmpegMissing = errors.New("ffmpeg binary not found — set FFMPEG_PATH or install ffmpeg")
+	ErrMediaSource   = errors.New("clip source is not allowed")
+	ErrTextStyle     = errors.New("text style is not allowed")
+)
+
+// Renderer turns a saved editor timeline into an MP4 by shelling out to ffmpeg.
+//
+// The whole timeline is expressed as ONE filter_complex graph:
+//
+//	black canvas ─┬─ overlay(video clip 1, enable between start/end)
+//	              ├─ overlay(video clip 2, ...)
+//	              └─ drawtext(text clip 1, ...) ...
+//	clip audio ──── adelay(start) ── amix
+//
+// This keeps gaps, stacked tracks and text overlays in a single ffmpeg pass —
+// no intermediate files per clip.
+type Renderer struct {
+	FFmpegPath  string
+	FFprobePath string
+	Width       int
+	Height      int
+	FPS         int
+
+	// BaseURL resolves relative clip sources (/uploads/x.mp4) — BASE_URL
+	BaseURL string
+}
+
+func NewRenderer(ffmpegPath, ffprobePath string) *Renderer {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if ffprobePath == "" {
+		ffprobePath = "ffprobe"
+	}
+	return &Renderer{
+		FFmpegPath:  ffmpegPath,
+		FFprobePath: ffprobePath,
+		Width:       DefaultWidth,
+		Height:      DefaultHeight,
+		FPS:         DefaultFPS,
+	}
+}
+
+// ── Progress ──────────────────────────────────────────────────────────────────
+
+// Progress is a snapshot parsed from ffmpeg's -progress output.
+type Progress struct {
+	Rendered time.Duration // output timestamp reached so far
+	Total    time.Duration // full output length
+	Percent  float64       // 0–100
+	ETA      time.Duration // estimated wall time remaining (0 until known)
+}
+
+// ProgressFunc receives progress snapshots roughly every ffmpeg stats period.
+type ProgressFunc func(Progress)
+
+// ── Render ────────────────────────────────────────────────────────────────────
+
+// Render writes the timeline to outputPath as H.264/AAC MP4.
+// workDir holds scratch files (drawtext text files) and may be removed afterwards.
+// Cancelling ctx kills the ffmpeg process. onProgress may be nil.
+func (r *Renderer) Render(ctx context.Context, timeline *models.Timeline, workDir, outputPath string, onProgress ProgressFunc) error {
+	if _, err := exec.LookPath(r.FFmpegPath); err != nil {
+		return ErrFFmpegMissing
+	}
+
+	args, err := r.buildArgs(ctx, timeline, workDir, outputPath)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, r.FFmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	total := time.Duration(renderDuration(timeline) * float64(time.Second))
+	readProgress(stdout, total, time.Now(), onProgress)
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, tail(stderr.String(), 2000))
+	}
+	return nil
+}
+
+// readProgress consumes "-progress pipe:1" key=value blocks until ffmpeg closes
+// stdout. Each block ends with "progress=continue" or "progress=end".
+func readProgress(out io.Reader, total time.Duration, started time.Time, onProgress ProgressFunc) {
+	scanner := bufio.NewScanner(out)
+	var rendered time.Duration
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "out_time_us", "out_time_ms":
+			// Despite the name, out_time_ms is also microseconds in ffmpeg
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				rendered = time.Duration(us) * time.Microsecond
+			}
+		case "progress":
+			if onProgress == nil || total <= 0 {
+				continue
+			}
+			if value == "end" {
+				rendered = total
+			}
+			onProgress(newProgress(rendered, total, time.Since(started)))
+		}
+	}
+
+	// Keep draining if the scanner bailed out — a full pipe would stall ffmpeg
+	io.Copy(io.Discard, out)
+}
+
+func newProgress(rendered, total, elapsed time.Duration) Progress {
+	fraction := float64(rendered) / float64(total)
+	fraction = min(max(fraction, 0), 1)
+
+	p := Progress{Rendered: rendered, Total: total, Percent: fraction * 100}
+	if fraction > 0 && fraction < 1 {
+		p.ETA = time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	}
+	return p
+}
+
+// renderDuration is the end of the last clip — the UI pads timeline.duration with
+// empty space for dragging, which must not end up as black frames in the output.
+func renderDuration(tl *models.Timeline) float64 {
+	if end := tl.End(); end > 0 {
+		return end
+	}
+	return tl.Duration
+}
+
+func (r *Renderer) buildArgs(ctx context.Context, tl *models.Timeline, workDir, outputPath string) ([]string, error) {
+	duration := renderDuration(tl)
+	if duration <= 0 {
+		return nil, ErrEmptyTimeline
+	}
+
+	// Transition fades keyed by clip — a fade-out on the outgoing clip and a
+	// fade-in on the incoming one composite as a dip through the canvas.
+	fadeOut := map[string]float64{}
+	fadeIn := map[string]float64{}
+	for _, t := range tl.Transitions {
+		if t.Type == "" || t.Type == "none" || t.Duration <= 0 {
+			continue
+		}
+		fadeOut[t.FromClipID] = t.Duration
+		fadeIn[t.ToClipID] = t.Duration
+	}
+
+	args := []string{"-hide_banner", "-nostdin", "-y", "-nostats", "-progress", "pipe:1"}
+	var filters []string
+	var audioLabels []string
+	inputIdx := 0
+
+	filters = append(filters, fmt.Sprintf("color=c=black:s=%dx%d:r=%d:d=%s[base]",
+		r.Width, r.Height, r.FPS, ff(duration)))
+	current := "base"
+	overlays := 0
+
+	var textClips []models.Clip
+
+	for _, track := range tl.Tracks {
+		clips := append([]models.Clip(nil), track.Clips...)
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].Start < clips[j].Start })
+
+		for _, clip := range clips {
+			if clip.Length() <= 0 {
+				continue
+			}
+			kind := clip.KindIn(track)
+
+			if kind == "text" {
+				if track.IsVisible() {
+					textClips = append(textClips, clip)
+				}
+				continue
+			}
+			if clip.Src == "" {
+				continue
+			}
+
+			input, protocols, err := r.resolveSource(clip.Src, workDir)
+			if err != nil {
+				return nil, fmt.Errorf("clip %s: %w", clip.ClipID, err)
+			}
+
+			length := clip.Length()
+			args = append(args, "-protocol_whitelist", protocols)
+			if kind == "image" {
+				args = append(args, "-loop", "1", "-t", ff(length), "-i", input)
+			} else {
+				args = append(args, "-ss", ff(clip.TrimStart), "-t", ff(length), "-i", input)
+			}
+
+			if (kind == "video" || kind == "image") && track.IsVisible() {
+				label := fmt.Sprintf("v%d", overlays)
+				chain := fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,"+
+					"pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%d,format=yuva420p",
+					inputIdx, r.Width, r.Height, r.Width, r.Height, r.FPS)
+				if d, ok := fadeIn[clip.ClipID]; ok {
+					chain += fmt.Sprintf(",fade=t=in:st=0:d=%s:alpha=1", ff(d))
+				}
+				if d, ok := fadeOut[clip.ClipID]; ok {
+					chain += fmt.Sprintf(",fade=t=out:st=%s:d=%s:alpha=1", ff(max(length-d, 0)), ff(d))
+				}
+				chain += fmt.Sprintf(",setpts=PTS-STARTPTS+%s/TB[%s]", ff(clip.Start), label)
+				filters = append(filters, chain)
+
+				next := fmt.Sprintf("o%d", overlays)
+				filters = append(filters, fmt.Sprintf("[%s][%s]overlay=eof_action=pass:enable='between(t,%s,%s)'[%s]",
+					current, label, ff(clip.Start), ff(clip.End), next))
+				current = next
+				overlays++
+			}
+
+			if (kind == "video" || kind == "audio") && !track.IsMuted() && r.hasAudio(ctx, input, protocols) {
+				label := fmt.Sprintf("a%d", len(audioLabels))
+				delayMs := int64(clip.Start * 1000)
+				filters = append(filters, fmt.Sprintf("[%d:a]atrim=0:%s,asetpts=PTS-STARTPTS,adelay=%d:all=1[%s]",
+					inputIdx, ff(length), delayMs, label))
+				audioLabels = append(audioLabels, label)
+			}
+
+			inputIdx++
+		}
+	}
+
+	if inputIdx == 0 && len(textClips) == 0 {
+		return nil, ErrEmptyTimeline
+	}
+
+	for i, clip := range textClips {
+		textPath := filepath.Join(workDir, fmt.Sprintf("text_%d.txt", i))
+		if err := os.WriteFile(textPath, []byte(clip.Text), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write text overlay: %w", err)
+		}
+		drawText, err := r.drawText(clip, textPath)
+		if err != nil {
+			return nil, fmt.Errorf("clip %s: %w", clip.ClipID, err)
+		}
+		next := fmt.Sprintf("t%d", i)
+		filters = append(filters, fmt.Sprintf("[%s]%s[%s]", current, drawText, next))
+		current = next
+	}
+
+	mapAudio := ""
+	if len(audioLabels) > 0 {
+		var mix strings.Builder
+		for _, l := range audioLabels {
+			mix.WriteString("[" + l + "]")
+		}
+		fmt.Fprintf(&mix, "amix=inputs=%d:normalize=0,apad,atrim=0:%s[aout]", len(audioLabels), ff(duration))
+		filters = append(filters, mix.String())
+		mapAudio = "[aout]"
+	}
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "["+current+"]",
+	)
+	if mapAudio != "" {
+		args = append(args, "-map", mapAudio, "-c:a", "aac", "-b:a", "192k")
+	} else {
+		args = append(args, "-an")
+	}
+	args = append(args,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "20", "-pix_fmt", "yuv420p",
+		"-t", ff(duration),
+		"-movflags", "+faststart",
+		outputPath,
+	)
+	return args, nil
+}
+
+// drawText mirrors the UI overlay: position is the CENTRE of the text box,
+// defaults match TextPropertiesPanel.jsx. Font and colors get the save-time
+// checks again (validation.ValidateFontFamily / ValidateColor) — timelines
+// saved before them are rendered too.
+func (r *Renderer) drawText(clip models.Clip, textPath string) (string, error) {
+	style := models.TextStyle{FontSize: 48, FontFamily: "Arial", Color: "#FFFFFF"}
+	if clip.TextStyle != nil {
+		if clip.TextStyle.FontSize > 0 {
+			style.FontSize = clip.TextStyle.FontSize
+		}
+		if clip.TextStyle.FontFamily != "" {
+			style.FontFamily = clip.TextStyle.FontFamily
+		}
+		if clip.TextStyle.Color != "" {
+			style.Color = clip.TextStyle.Color
+		}
+		style.BackgroundColor = clip.TextStyle.BackgroundColor
+	}
+
+	if validation.ValidateFontFamily(style.FontFamily) != nil {
+		return "", fmt.Errorf("%w: font %q", ErrTextStyle, style.FontFamily)
+	}
+	if validation.ValidateColor(style.Color) != nil {
+		return "", fmt.Errorf("%w: color %q", ErrTextStyle, style.Color)
+	}
+	bg := style.BackgroundColor
+	if bg == "transparent" {
+		bg = ""
+	}
+	if bg != "" && validation.ValidateColor(bg) != nil {
+		return "", fmt.Errorf("%w: background color %q", ErrTextStyle, bg)
+	}
+
+	x, y := float64(r.Width)/2, float64(r.Height)/2
+	if clip.Position != nil {
+		x, y = clip.Position.X, clip.Position.Y
+	}
+
+	opts := []string{
+		"textfile=" + escapeFilterValue(textPath),
+		"font=" + escapeFilterValue(style.FontFamily),
+		"fontsize=" + ff(style.FontSize),
+		"fontcolor=" + escapeFilterValue(ffColor(style.Color)),
+		fmt.Sprintf("x=%s-text_w/2", ff(x)),
+		fmt.Sprintf("y=%s-text_h/2", ff(y)),
+		fmt.Sprintf("enable='between(t,%s,%s)'", ff(clip.Start), ff(clip.End)),
+	}
+	if bg != "" {
+		opts = append(opts, "box=1", "boxborderw=10", "boxcolor="+escapeFilterValue(ffColor(bg)))
+	}
+	return "drawtext=" + strings.Join(opts, ":"), nil
+}
+
+// resolveSource turns a clip src into an ffmpeg input and the protocols it
+// may use. Sources get the save-time checks again (validation.ValidateMediaSrc)
+// — timelines saved before them are rendered too. A local path is only
+// accepted inside workDir.
+func (r *Renderer) resolveSource(src, workDir string) (input, protocols string, err error) {
+	if filepath.IsAbs(src) && !strings.HasPrefix(src, "/uploads/") {
+		path := filepath.Clean(src)
+		rel, err := filepath.Rel(workDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", "", ErrMediaSource
+		}
+		return path, localProtocols, nil
+	}
+
+	if err := validation.ValidateMediaSrc(src); err != nil {
+		return "", "", ErrMediaSource
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", "", ErrMediaSource
+	}
+	if !u.IsAbs() {
+		base, err := url.Parse(r.BaseURL)
+		if err != nil || r.BaseURL == "" {
+			return "", "", fmt.Errorf("%w: relative source needs BASE_URL", ErrMediaSource)
+		}
+		u = base.ResolveReference(u)
+	}
+	return u.String(), remoteProtocols, nil
+}
+
+// hasAudio asks ffprobe whether src carries an audio stream. Referencing [n:a]
+// on a silent input fails the whole graph, so we only mix inputs that have one.
+func (r *Renderer) hasAudio(ctx context.Context, src, protocols string) bool {
+	out, err := exec.CommandContext(ctx, r.FFprobePath,
+		"-v", "error", "-protocol_whitelist", protocols, "-select_streams", "a",
+		"-show_entries", "stream=index", "-of", "csv=p=0", src,
+	).Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(out)) != ""
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+// ff formats seconds for ffmpeg — fixed precision, no exponent notation.
+func ff(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+// ffColor converts CSS "#RRGGBB" to ffmpeg's "0xRRGGBB".
+func ffColor(c string) string {
+	if strings.HasPrefix(c, "#") {
+		return "0x" + c[1:]
+	}
+	return c
+}
+
+// escapeFilterValue makes v one literal option value inside -filter_complex.
+// ffmpeg unescapes twice: the graph parser first splits filters on , ; [ ]
+// and strips one level of quotes and backslashes, then the filter splits its
+// own key=value list on ':' and strips another. So v is quoted for the
+// option level, and the result escaped for the graph level.
+func escapeFilterValue(v string) string {
+	return escapeGraphLevel(escapeOptionLevel(v))
+}
+
+// escapeOptionLevel single-quotes v; inside quotes only ' is special.
+func escapeOptionLevel(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
+
+var graphLevelEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, `,`, `\,`, `;`, `\;`, `[`, `\[`, `]`, `\]`)
+
+func escapeGraphLevel(v string) string {
+	return graphLevelEscaper.Replace(v)
+}
+
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
@@ -0,0 +1,241 @@
+// internal/render/ffmpeg_test.go
+package render
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/validation"
+)
+
+// avGetToken is libavutil's av_get_token: read up to an unescaped,
+// unquoted byte of term, dropping one level of backslashes and single
+// quotes, and trimming unprotected trailing whitespace.
+func avGetToken(buf, term string) (token, rest string) {
+	p := strings.TrimLeft(buf, " \n\t\r")
+	var out []byte
+	end := 0
+	for len(p) > 0 && !strings.ContainsRune(term, rune(p[0])) {
+		c := p[0]
+		p = p[1:]
+		switch {
+		case c == '\\' && len(p) > 0:
+			out = append(out, p[0])
+			p = p[1:]
+			end = len(out)
+		case c == '\'':
+			i := strings.IndexByte(p, '\'')
+			if i < 0 {
+				out = append(out, p...)
+				p = ""
+				break
+			}
+			out = append(out, p[:i]...)
+			p = p[i+1:]
+			end = len(out)
+		default:
+			out = append(out, c)
+		}
+	}
+	for len(out) > end && strings.ContainsRune(" \n\t\r", rune(out[len(out)-1])) {
+		out = out[:len(out)-1]
+	}
+	return string(out), p
+}
+
+// parseDrawText runs a "drawtext=..." filter through both of ffmpeg's
+// parsing levels — the filtergraph's, then drawtext's key=value list — and
+// returns the options drawtext would see.
+func parseDrawText(t *testing.T, filter string) map[string][]string {
+	t.Helper()
+	args, ok := strings.CutPrefix(filter, "drawtext=")
+	if !ok {
+		t.Fatalf("not a drawtext filter: %s", filter)
+	}
+
+	// Level 1: the graph parser takes the arguments up to , ; [ ] — here
+	// that must be the output label, or the end
+	args, rest := avGetToken(args, "[],;")
+	if rest != "" && !strings.HasPrefix(rest, "[t0]") {
+		t.Fatalf("filter arguments end early, graph continues with %q", rest)
+	}
+
+	// Level 2: drawtext splits key=value pairs on ':'
+	opts := map[string][]string{}
+	for args != "" {
+		key, value, ok := strings.Cut(args, "=")
+		if !ok || strings.ContainsAny(key, ":'\\") {
+			t.Fatalf("malformed option list at %q", args)
+		}
+		value, args = avGetToken(value, ":")
+		args = strings.TrimPrefix(args, ":")
+		opts[key] = append(opts[key], value)
+	}
+	return opts
+}
+
+func textTimeline(style *models.TextStyle) *models.Timeline {
+	return &models.Timeline{
+		Duration: 5,
+		Tracks: []models.Track{{
+			Type: models.TrackTypeText,
+			Clips: []models.Clip{{
+				ClipID: "title", Start: 0, End: 5,
+				Text:      "Hello: it's [me], again; \\o/",
+				TextStyle: style,
+			}},
+		}},
+	}
+}
+
+// drawTextFilter returns the -filter_complex graph from its drawtext on.
+func drawTextFilter(t *testing.T, args []string) string {
+	t.Helper()
+	for i, a := range args {
+		if a == "-filter_complex" {
+			graph := args[i+1]
+			if at := strings.Index(graph, "drawtext="); at >= 0 {
+				return graph[at:]
+			}
+		}
+	}
+	t.Fatalf("no drawtext in %q", args)
+	return ""
+}
+
+// Style values the editor can't produce are refused before ffmpeg runs —
+// "\:textfile=" would otherwise add a drawtext option of the user's choosing.
+func TestBuildArgsRejectsHostileTextStyle(t *testing.T) {
+	r := NewRenderer("", "")
+	cases := map[string]*models.TextStyle{
+		"font with option":         {FontFamily: `Arial\:textfile=/proc/self/environ`},
+		"font double escaped":      {FontFamily: `Arial\\\:textfile=/proc/self/environ`},
+		"font breaking the graph":  {FontFamily: `Arial'[x];movie=/etc/passwd[y`},
+		"unknown font":             {FontFamily: "Comic Sans MS"},
+		"color with option":        {Color: `white:textfile=/proc/self/environ`},
+		"color with quote":         {Color: `#FFFFFF'`},
+		"background with option":   {BackgroundColor: `black\:textfile=/etc/passwd`},
+		"background CSS function":  {BackgroundColor: "rgba(0,0,0,0.5)"},
+		"short hex":                {Color: "#FFF"},
+		"background filter switch": {BackgroundColor: "0x000000,drawbox"},
+	}
+	for name, style := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := r.buildArgs(context.Background(), textTimeline(style), t.TempDir(), "out.mp4")
+			if !errors.Is(err, ErrTextStyle) {
+				t.Fatalf("err = %v, want ErrTextStyle", err)
+			}
+		})
+	}
+}
+
+func TestBuildArgsDrawTextOptions(t *testing.T) {
+	// A work dir with graph and option syntax in its name must still arrive
+	// as one textfile value
+	workDir := filepath.Join(t.TempDir(), `we:ird 'dir', [x]; \y`)
+	if err := os.Mkdir(workDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRenderer("", "")
+	style := &models.TextStyle{FontSize: 40, FontFamily: "Times New Roman", Color: "#FF0000", BackgroundColor: "0x00000080"}
+	args, err := r.buildArgs(context.Background(), textTimeline(style), workDir, "out.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := parseDrawText(t, drawTextFilter(t, args))
+	want := map[string]string{
+		"textfile":  filepath.Join(workDir, "text_0.txt"),
+		"font":      "Times New Roman",
+		"fontsize":  "40.000",
+		"fontcolor": "0xFF0000",
+		"box":       "1",
+		"boxcolor":  "0x00000080",
+	}
+	for key, value := range want {
+		if got := opts[key]; len(got) != 1 || got[0] != value {
+			t.Errorf("%s = %q, want [%q]", key, got, value)
+		}
+	}
+	known := map[string]bool{"x": true, "y": true, "enable": true, "boxborderw": true}
+	for key := range opts {
+		if _, ok := want[key]; !ok && !known[key] {
+			t.Errorf("unexpected drawtext option %s=%q", key, opts[key])
+		}
+	}
+
+	// The text itself never enters the graph — it's read from textfile
+	text, err := os.ReadFile(want["textfile"])
+	if err != nil || string(text) != "Hello: it's [me], again; \\o/" {
+		t.Fatalf("text file = %q, %v", text, err)
+	}
+}
+
+// Whatever escapeFilterValue gets comes out of both parsing levels unchanged
+// and as a single value.
+func TestEscapeFilterValueRoundTrip(t *testing.T) {
+	values := []string{
+		"plain",
+		`Arial\:textfile=/proc/self/environ`,
+		`Arial\\:textfile=/proc/self/environ`,
+		`it's`,
+		`'quoted'`,
+		`a:b,c;d[e]f`,
+		`\`,
+		`\'`,
+		`''\\''`,
+		" padded ",
+	}
+	for _, v := range values {
+		opts := parseDrawText(t, "drawtext=font="+escapeFilterValue(v)+":fontsize=10")
+		if got := opts["font"]; len(got) != 1 || got[0] != v {
+			t.Errorf("font %q came out as %q", v, got)
+		}
+		if len(opts) != 2 {
+			t.Errorf("font %q produced options %q", v, opts)
+		}
+	}
+}
+
+func TestResolveSource(t *testing.T) {
+	validation.AllowMediaHosts("media.example.com")
+	r := NewRenderer("", "")
+	r.BaseURL = "https://api.example.com"
+	workDir := t.TempDir()
+
+	cases := []struct {
+		src       string
+		input     string
+		protocols string
+	}{
+		{"/uploads/a.mp4", "https://api.example.com/uploads/a.mp4", remoteProtocols},
+		{"https://media.example.com/b.mp4", "https://media.example.com/b.mp4", remoteProtocols},
+		{filepath.Join(workDir, "part.mp4"), filepath.Join(workDir, "part.mp4"), localProtocols},
+	}
+	for _, tc := range cases {
+		input, protocols, err := r.resolveSource(tc.src, workDir)
+		if err != nil || input != tc.input || protocols != tc.protocols {
+			t.Errorf("resolveSource(%q) = %q, %q, %v; want %q, %q", tc.src, input, protocols, err, tc.input, tc.protocols)
+		}
+	}
+
+	for _, src := range []string{
+		"/etc/passwd",
+		filepath.Join(workDir, "..", "escape.mp4"),
+		"file:///etc/passwd",
+		"https://evil.example.net/x.mp4",
+		"http://169.254.169.254/latest/meta-data/",
+		"/uploads/../etc/passwd",
+		"concat:/uploads/a.mp4|/etc/passwd",
+	} {
+		if _, _, err := r.resolveSource(src, workDir); !errors.Is(err, ErrMediaSource) {
+			t.Errorf("resolveSource(%q) err = %v, want ErrMediaSource", src, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// internal/service/approval_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("status must be draft, in_review, changes_requested, approved or exported")
+	ErrInvalidTransition = errors.New("the session cannot move to that status from its current one")
+	ErrApprovalRequired  = errors.New("the session must be approved before it can be exported")
+)
+
+// ============================================================================
+// APPROVAL WORKFLOW
+// ============================================================================
+//
+//	draft             → in_review          editor    (submit)
+//	in_review         → approved           approver
+//	in_review         → changes_requested  approver
+//	in_review         → draft              editor    (withdraw)
+//	changes_requested → in_review          editor    (resubmit)
+//	approved          → changes_requested  approver
+//	approved          → exported           system    (export completed)
+//	exported          → draft              editor    (start a new revision)
+//
+// "approver" is SessionService.ApprovalRole (owner by default). Saving an
+// approved session sends it back to in_review — an approval covers exactly
+// the version that was approved.
+
+// roleApprover stands in for SessionService.ApprovalRole in statusTransitions.
+const roleApprover = "approver"
+
+// statusTransitions[from][to] is the role needed to make that move.
+var statusTransitions = map[string]map[string]string{
+	models.SessionStatusDraft: {
+		models.SessionStatusInReview: models.RoleEditor,
+	},
+	models.SessionStatusInReview: {
+		models.SessionStatusApproved:         roleApprover,
+		models.SessionStatusChangesRequested: roleApprover,
+		models.SessionStatusDraft:            models.RoleEditor,
+	},
+	models.SessionStatusChangesRequested: {
+		models.SessionStatusInReview: models.RoleEditor,
+	},
+	models.SessionStatusApproved: {
+		models.SessionStatusChangesRequested: roleApprover,
+	},
+	models.SessionStatusExported: {
+		models.SessionStatusDraft: models.RoleEditor,
+	},
+}
+
+// IsSessionStatus reports whether status is one of the workflow states.
+func IsSessionStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// ExportAllowed reports whether a session in status may be exported when
+// approval is required.
+func ExportAllowed(status string) bool {
+	return status == models.SessionStatusApproved || status == models.SessionStatusExported
+}
+
+// sessionStatus maps legacy / empty values to draft.
+func sessionStatus(status string) string {
+	if IsSessionStatus(status) {
+		return status
+	}
+	return models.SessionStatusDraft
+}
+
+func (s *SessionService) approvalRole() string {
+	if s.ApprovalRole != "" {
+		return s.ApprovalRole
+	}
+	return models.RoleOwner
+}
+
+// TransitionStatus moves the session to status `to` and records the move.
+// expectedVersion, when non-zero, must match the current timeline version —
+// so an approver signs off on exactly what they reviewed (*VersionConflictError
+// otherwise).
+func (s *SessionService) TransitionStatus(sessionID, userID, workspaceID uuid.UUID, to, note string, expectedVersion int) (*models.SessionTransition, error) {
+	if !IsSessionStatus(to) {
+		return nil, ErrInvalidStatus
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the row so two reviewers can't both act on the same state
+	var current string
+	var version int
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, version FROM editor_sessions WHERE session_id = $1 FOR UPDATE
+	`, sessionID).Scan(&current, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	from := sessionStatus(current)
+	need, ok := statusTransitions[from][to]
+	if !ok {
+		return nil, ErrInvalidTransition
+	}
+	if need == roleApprover {
+		need = s.approvalRole()
+	}
+	if roleRank[role] < roleRank[need] {
+		return nil, ErrInsufficientRole
+	}
+
+	if expectedVersion != 0 && expectedVersion != version {
+		tx.Rollback()
+		latest, err := s.getSession(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &VersionConflictError{Current: latest}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE editor_sessions SET status = $2, updated_at = NOW() WHERE session_id = $1
+	`, sessionID, to); err != nil {
+		return nil, err
+	}
+
+	t, err := recordTransition(ctx, tx, sessionID, from, to, &userID, version, note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.publishStatusChanged(t)
+	return t, nil
+}
+
+// ListTransitions returns the session's approval audit trail, newest first.
+// Any role may look.
+func (s *SessionService) ListTransitions(sessionID, userID, workspaceID uuid.UUID) ([]models.SessionTransition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT transition_id, session_id, from_status, to_status, user_id, version, note, created_at
+		FROM editor_session_transitions
+		WHERE session_id = $1
+		ORDER BY transition_id DESC
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []models.SessionTransition{}
+	for rows.Next() {
+		var t models.SessionTransition
+		if err := rows.Scan(&t.TransitionID, &t.SessionID, &t.FromStatus, &t.ToStatus,
+			&t.UserID, &t.Version, &t.Note, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}
+
+// markExported moves an approved session to exported once its export has
+// completed. Sessions in any other state are left alone.
+func (s *SessionService) markExported(ctx context.Context, sessionID uuid.UUID) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE editor_sessions
+		SET status = $2, updated_at = NOW()
+		WHERE session_id = $1 AND status = $3
+		RETURNING version
+	`, sessionID, models.SessionStatusExported, models.SessionStatusApproved).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	t, err := recordTransition(ctx, tx, sessionID, models.SessionStatusApproved, models.SessionStatusExported, nil, version, "export completed")
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.publishStatusChanged(t)
+	return nil
+}
+
+// recordTransition appends to the audit trail inside the caller's transaction.
+func recordTransition(
+	ctx context.Context, tx *sql.Tx,
+	sessionID uuid.UUID, from, to string,
+	userID *uuid.UUID, version int, note string,
+) (*models.SessionTransition, error) {
+	t := &models.SessionTransition{
+		SessionID:  sessionID,
+		FromStatus: from,
+		ToStatus:   to,
+		UserID:     userID,
+		Version:    version,
+		Note:       note,
+	}
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO editor_session_transitions (session_id, from_status, to_status, user_id, version, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING transition_id, created_at
+	`, sessionID, from, to, userID, version, note).Scan(&t.TransitionID, &t.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record status transition: %w", err)
+	}
+	return t, nil
+}
+
+func (s *SessionService) publishStatusChanged(t *models.SessionTransition) {
+	s.Events.Publish(t.SessionID, events.TypeStatusChanged, map[string]interface{}{
+		"from":    t.FromStatus,
+		"to":      t.ToStatus,
+		"version": t.Version,
+	})
+}
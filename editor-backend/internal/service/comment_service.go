@@ -0,0 +1,593 @@
+// internal/service/comment_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCommentNotFound     = errors.New("comment not found")
+	ErrInvalidComment      = errors.New("comment body must be 1-10000 characters")
+	ErrInvalidCommentRange = errors.New("start must be >= 0 and end >= start")
+	ErrCommentClipNotFound = errors.New("clip_id is not on the session's timeline")
+	ErrCommentIsReply      = errors.New("replies are resolved with their thread")
+	ErrNotCommentAuthor    = errors.New("only the author can edit a comment")
+	ErrInvalidMention      = errors.New("mentioned users must have access to this session")
+	ErrInvalidAuthorName   = errors.New("author_name must be 1-100 characters")
+	ErrShareNoComments     = errors.New("this share link does not allow comments")
+)
+
+const (
+	maxCommentLength    = 10000
+	maxAuthorNameLength = 100
+)
+
+// Comment actions carried by events.TypeCommentsChanged
+const (
+	CommentActionCreated      = "created"
+	CommentActionUpdated      = "updated"
+	CommentActionResolved     = "resolved"
+	CommentActionReopened     = "reopened"
+	CommentActionDeleted      = "deleted"
+	CommentActionClipsChanged = "clips_changed"
+)
+
+// CommentInput is a new comment or reply. For a reply (ParentID set) Start,
+// End and ClipID are ignored — replies take their thread's anchor.
+type CommentInput struct {
+	ParentID *uuid.UUID
+	Start    float64
+	End      float64
+	ClipID   string
+	Body     string
+	Mentions []uuid.UUID
+
+	// AuthorName is required for share link reviewers, unused otherwise
+	AuthorName string
+}
+
+// CommentFilter narrows ListComments. Zero values mean "no filter". Filters
+// select threads: a thread matches Author / Mentioned if any of its comments
+// does.
+type CommentFilter struct {
+	Resolved    *bool
+	ClipID      string
+	ClipDeleted *bool
+	AuthorID    *uuid.UUID
+	Mentioned   *uuid.UUID
+
+	// Threads whose [start, end] overlaps [From, To]
+	From *float64
+	To   *float64
+}
+
+const commentSelectColumns = `
+	comment_id, session_id, parent_id, start_time, end_time, clip_id, clip_deleted,
+	body, mentions, author_id, author_name, share_id, resolved_at, resolved_by,
+	created_at, updated_at`
+
+func scanComment(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.Comment, error) {
+	var c models.Comment
+	var mentions []string
+	if err := scanner.Scan(
+		&c.CommentID, &c.SessionID, &c.ParentID, &c.Start, &c.End, &c.ClipID, &c.ClipDeleted,
+		&c.Body, pq.Array(&mentions), &c.AuthorID, &c.AuthorName, &c.ShareID, &c.ResolvedAt, &c.ResolvedBy,
+		&c.CreatedAt, &c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	c.Mentions = make([]uuid.UUID, 0, len(mentions))
+	for _, m := range mentions {
+		id, err := uuid.Parse(m)
+		if err != nil {
+			return nil, err
+		}
+		c.Mentions = append(c.Mentions, id)
+	}
+	return &c, nil
+}
+
+// ============================================================================
+// COMMENTS — viewers read, commenters write
+// ============================================================================
+
+// CreateComment adds a comment, or a reply when in.ParentID is set. Needs the
+// commenter role. Mentioned users must be able to see the session.
+func (s *SessionService) CreateComment(sessionID, userID, workspaceID uuid.UUID, in CommentInput) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleCommenter); err != nil {
+		return nil, err
+	}
+
+	in.Mentions = uniqueUUIDs(in.Mentions)
+	if err := s.checkMentions(ctx, sessionID, workspaceID, in.Mentions); err != nil {
+		return nil, err
+	}
+
+	return s.createComment(ctx, sessionID, &userID, nil, in)
+}
+
+// ListComments returns the session's threads in timeline order, each with
+// its replies. Any role may look.
+func (s *SessionService) ListComments(sessionID, userID, workspaceID uuid.UUID, f CommentFilter) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.listComments(ctx, sessionID, f)
+}
+
+// GetComment returns one thread — the top-level comment with its replies.
+// Asking for a reply returns the thread it belongs to.
+func (s *SessionService) GetComment(sessionID, userID, workspaceID, commentID uuid.UUID) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.getThread(ctx, sessionID, commentID)
+}
+
+// UpdateComment rewrites a comment's body and mentions. Only its author may,
+// and only while they still hold the commenter role.
+func (s *SessionService) UpdateComment(sessionID, userID, workspaceID, commentID uuid.UUID, body string, mentions []uuid.UUID) (*models.Comment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxCommentLength {
+		return nil, ErrInvalidComment
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleCommenter); err != nil {
+		return nil, err
+	}
+
+	mentions = uniqueUUIDs(mentions)
+	if err := s.checkMentions(ctx, sessionID, workspaceID, mentions); err != nil {
+		return nil, err
+	}
+
+	result, err := s.DB.ExecContext(ctx, `
+		UPDATE editor_comments
+		SET body = $4, mentions = $5, updated_at = NOW()
+		WHERE comment_id = $1 AND session_id = $2 AND author_id = $3
+	`, commentID, sessionID, userID, body, pq.Array(uuidStrings(mentions)))
+	if err != nil {
+		return nil, err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		if _, err := s.getComment(ctx, sessionID, commentID); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotCommentAuthor
+	}
+
+	s.publishCommentsChanged(sessionID, commentID, CommentActionUpdated, mentions)
+	return s.getThread(ctx, sessionID, commentID)
+}
+
+// ResolveComment resolves (or, with resolved false, reopens) a thread. Needs
+// the commenter role. Replies can't be resolved on their own.
+func (s *SessionService) ResolveComment(sessionID, userID, workspaceID, commentID uuid.UUID, resolved bool) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleCommenter); err != nil {
+		return nil, err
+	}
+
+	c, err := s.getComment(ctx, sessionID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c.ParentID != nil {
+		return nil, ErrCommentIsReply
+	}
+
+	action := CommentActionResolved
+	if resolved {
+		_, err = s.DB.ExecContext(ctx, `
+			UPDATE editor_comments
+			SET resolved_at = NOW(), resolved_by = $2
+			WHERE comment_id = $1 AND resolved_at IS NULL
+		`, commentID, userID)
+	} else {
+		action = CommentActionReopened
+		_, err = s.DB.ExecContext(ctx, `
+			UPDATE editor_comments
+			SET resolved_at = NULL, resolved_by = NULL
+			WHERE comment_id = $1
+		`, commentID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishCommentsChanged(sessionID, commentID, action, nil)
+	return s.getThread(ctx, sessionID, commentID)
+}
+
+// DeleteComment removes a comment; removing a top-level comment removes its
+// thread. Authors can delete their own comments, editors anyone's.
+func (s *SessionService) DeleteComment(sessionID, userID, workspaceID, commentID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleCommenter)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.getComment(ctx, sessionID, commentID)
+	if err != nil {
+		return err
+	}
+	isAuthor := c.AuthorID != nil && *c.AuthorID == userID
+	if !isAuthor && roleRank[role] < roleRank[models.RoleEditor] {
+		return ErrInsufficientRole
+	}
+
+	if _, err := s.DB.ExecContext(ctx, `
+		DELETE FROM editor_comments WHERE comment_id = $1 AND session_id = $2
+	`, commentID, sessionID); err != nil {
+		return err
+	}
+
+	s.publishCommentsChanged(sessionID, commentID, CommentActionDeleted, nil)
+	return nil
+}
+
+// ============================================================================
+// SHARE LINK REVIEWERS — "comment" links can read and post, nothing else
+// ============================================================================
+
+// ListSharedComments returns the threads of the session a "comment" share
+// link opens.
+func (s *SessionService) ListSharedComments(token, password string, access ShareAccess, f CommentFilter) ([]models.Comment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	grant, err := s.openCommentShare(ctx, token, password, access)
+	if err != nil {
+		return nil, err
+	}
+	return s.listComments(ctx, grant.SessionID, f)
+}
+
+// CreateSharedComment posts a comment as an account-less reviewer. in.AuthorName
+// identifies them; mentions are not available to share link reviewers.
+func (s *SessionService) CreateSharedComment(token, password string, access ShareAccess, in CommentInput) (*models.Comment, error) {
+	in.AuthorName = strings.TrimSpace(in.AuthorName)
+	if in.AuthorName == "" || len(in.AuthorName) > maxAuthorNameLength {
+		return nil, ErrInvalidAuthorName
+	}
+	in.Mentions = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	grant, err := s.openCommentShare(ctx, token, password, access)
+	if err != nil {
+		return nil, err
+	}
+	return s.createComment(ctx, grant.SessionID, nil, &grant.ShareID, in)
+}
+
+func (s *SessionService) openCommentShare(ctx context.Context, token, password string, access ShareAccess) (*shareGrant, error) {
+	grant, err := s.openShareLink(ctx, token, password, access)
+	if err != nil {
+		return nil, err
+	}
+	if grant.Permission != models.SharePermissionComment {
+		return nil, ErrShareNoComments
+	}
+	return grant, nil
+}
+
+// ============================================================================
+// SAVE HOOK — called inside SaveSession's transaction
+// ============================================================================
+
+// flagCommentClips marks comments whose clip the saved timeline no longer
+// has, and unmarks those whose clip is back. Returns how many changed.
+func flagCommentClips(ctx context.Context, tx *sql.Tx, sessionID uuid.UUID, tl *models.Timeline) (int64, error) {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE editor_comments
+		SET clip_deleted = NOT (clip_id = ANY($2::text[]))
+		WHERE session_id = $1 AND clip_id IS NOT NULL
+		  AND clip_deleted = (clip_id = ANY($2::text[]))
+	`, sessionID, pq.Array(timelineClipIDs(tl)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to flag comments: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ============================================================================
+// HELPERS
+// ============================================================================
+
+// createComment inserts a comment for an account holder (authorID) or a
+// share link reviewer (shareID). Access has already been checked.
+func (s *SessionService) createComment(ctx context.Context, sessionID uuid.UUID, authorID, shareID *uuid.UUID, in CommentInput) (*models.Comment, error) {
+	in.Body = strings.TrimSpace(in.Body)
+	if in.Body == "" || len(in.Body) > maxCommentLength {
+		return nil, ErrInvalidComment
+	}
+
+	var parentID *uuid.UUID
+	var clipID *string
+	clipDeleted := false
+
+	if in.ParentID != nil {
+		// Replies are one level deep: a reply to a reply joins the same thread
+		parent, err := s.getComment(ctx, sessionID, *in.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		root := parent.CommentID
+		if parent.ParentID != nil {
+			root = *parent.ParentID
+		}
+		parentID = &root
+		in.Start, in.End = parent.Start, parent.End
+		clipID, clipDeleted = parent.ClipID, parent.ClipDeleted
+	} else {
+		// A single point in time may omit end
+		if in.End == 0 {
+			in.End = in.Start
+		}
+		if in.Start < 0 || in.End < in.Start {
+			return nil, ErrInvalidCommentRange
+		}
+		if in.ClipID != "" {
+			session, err := s.getSession(ctx, sessionID)
+			if err != nil {
+				return nil, err
+			}
+			if !containsString(timelineClipIDs(&session.Timeline), in.ClipID) {
+				return nil, ErrCommentClipNotFound
+			}
+			clipID = &in.ClipID
+		}
+	}
+
+	c, err := scanComment(s.DB.QueryRowContext(ctx, `
+		INSERT INTO editor_comments
+			(session_id, parent_id, start_time, end_time, clip_id, clip_deleted,
+			 body, mentions, author_id, author_name, share_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+commentSelectColumns,
+		sessionID, parentID, in.Start, in.End, clipID, clipDeleted,
+		in.Body, pq.Array(uuidStrings(in.Mentions)), authorID, in.AuthorName, shareID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	s.publishCommentsChanged(sessionID, c.CommentID, CommentActionCreated, c.Mentions)
+	return c, nil
+}
+
+// checkMentions verifies every mentioned user can see the session.
+func (s *SessionService) checkMentions(ctx context.Context, sessionID, workspaceID uuid.UUID, mentions []uuid.UUID) error {
+	for _, mentioned := range mentions {
+		if _, err := sessionRole(ctx, s.DB, sessionID, mentioned, workspaceID); err != nil {
+			if err == ErrUnauthorized {
+				return ErrInvalidMention
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SessionService) getComment(ctx context.Context, sessionID, commentID uuid.UUID) (*models.Comment, error) {
+	c, err := scanComment(s.DB.QueryRowContext(ctx, `
+		SELECT `+commentSelectColumns+`
+		FROM editor_comments
+		WHERE comment_id = $1 AND session_id = $2
+	`, commentID, sessionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	return c, err
+}
+
+// getThread loads the thread commentID belongs to.
+func (s *SessionService) getThread(ctx context.Context, sessionID, commentID uuid.UUID) (*models.Comment, error) {
+	c, err := s.getComment(ctx, sessionID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if c.ParentID != nil {
+		if c, err = s.getComment(ctx, sessionID, *c.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	threads := []models.Comment{*c}
+	if err := s.attachReplies(ctx, threads); err != nil {
+		return nil, err
+	}
+	return &threads[0], nil
+}
+
+// listComments returns the matching threads of a session, with replies.
+func (s *SessionService) listComments(ctx context.Context, sessionID uuid.UUID, f CommentFilter) ([]models.Comment, error) {
+	args := []interface{}{sessionID}
+	where := []string{"c.session_id = $1", "c.parent_id IS NULL"}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.Resolved != nil {
+		if *f.Resolved {
+			where = append(where, "c.resolved_at IS NOT NULL")
+		} else {
+			where = append(where, "c.resolved_at IS NULL")
+		}
+	}
+	if f.ClipID != "" {
+		add("c.clip_id = $%d", f.ClipID)
+	}
+	if f.ClipDeleted != nil {
+		add("c.clip_deleted = $%d", *f.ClipDeleted)
+	}
+	if f.From != nil {
+		add("c.end_time >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("c.start_time <= $%d", *f.To)
+	}
+	if f.AuthorID != nil {
+		add(`(c.author_id = $%[1]d OR EXISTS (
+			SELECT 1 FROM editor_comments r WHERE r.parent_id = c.comment_id AND r.author_id = $%[1]d))`, *f.AuthorID)
+	}
+	if f.Mentioned != nil {
+		add(`($%[1]d = ANY(c.mentions) OR EXISTS (
+			SELECT 1 FROM editor_comments r WHERE r.parent_id = c.comment_id AND $%[1]d = ANY(r.mentions)))`, *f.Mentioned)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+prefixColumns("c", commentSelectColumns)+`
+		FROM editor_comments c
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY c.start_time, c.created_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	defer rows.Close()
+
+	threads := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachReplies(ctx, threads); err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+// attachReplies fills Replies on each thread, oldest first.
+func (s *SessionService) attachReplies(ctx context.Context, threads []models.Comment) error {
+	if len(threads) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(threads))
+	ids := make([]uuid.UUID, len(threads))
+	for i, t := range threads {
+		index[t.CommentID] = i
+		ids[i] = t.CommentID
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT `+commentSelectColumns+`
+		FROM editor_comments
+		WHERE parent_id = ANY($1::uuid[])
+		ORDER BY created_at
+	`, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return fmt.Errorf("failed to load replies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			return err
+		}
+		t := &threads[index[*reply.ParentID]]
+		t.Replies = append(t.Replies, *reply)
+	}
+	return rows.Err()
+}
+
+func (s *SessionService) publishCommentsChanged(sessionID, commentID uuid.UUID, action string, mentions []uuid.UUID) {
+	data := map[string]interface{}{"comment_id": commentID, "action": action}
+	if len(mentions) > 0 {
+		data["mentions"] = mentions
+	}
+	s.Events.Publish(sessionID, events.TypeCommentsChanged, data)
+}
+
+// timelineClipIDs lists every clip ID on the timeline.
+func timelineClipIDs(tl *models.Timeline) []string {
+	ids := []string{}
+	for _, track := range tl.Tracks {
+		for _, clip := range track.Clips {
+			if clip.ClipID != "" {
+				ids = append(ids, clip.ClipID)
+			}
+		}
+	}
+	return ids
+}
+
+// prefixColumns qualifies a comma-separated column list with a table alias.
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, p := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
@@ -0,0 +1,508 @@
+// internal/service/export_job_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+	"editor-backend/internal/timeline"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound = errors.New("export job not found")
+	ErrJobFinished = errors.New("export job already finished")
+	ErrLeaseLost   = errors.New("export job lease lost to another worker")
+)
+
+// Export job states stored in export_jobs.status.
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// ExportJobService is the Postgres-backed render queue.
+//
+// Any number of API pods enqueue; any number of workers claim. Correctness
+// comes entirely from the database:
+//   - FOR UPDATE SKIP LOCKED → two workers never claim the same job
+//   - lease_expires_at       → a crashed worker's job is reclaimed after the lease
+//   - run_after              → retry backoff without a scheduler
+type ExportJobService struct {
+	DB *sql.DB
+
+	// MaxAttempts for newly enqueued jobs (0 → 3)
+	MaxAttempts int
+	// RetryBackoff is the base delay; attempt n waits RetryBackoff * 2^(n-1) (0 → 30s)
+	RetryBackoff time.Duration
+	// RequireApproval blocks exports of sessions that aren't approved (see
+	// approval_service.go) — at enqueue, and again when a worker picks the job up
+	RequireApproval bool
+
+	// Events is optional — progress ticks are pushed to the session's SSE stream
+	Events *events.Publisher
+}
+
+const exportJobSelectColumns = `
+	job_id, session_id, user_id, workspace_id, version, status,
+	attempts, max_attempts, run_after,
+	leased_by, lease_expires_at,
+	progress_percent, eta_seconds, cancel_requested,
+	last_error, output_url,
+	created_at, updated_at, started_at, finished_at
+`
+
+// scanExportJob maps exportJobSelectColumns, then any extra columns the
+// query added after them into extra.
+func scanExportJob(scanner interface {
+	Scan(dest ...interface{}) error
+}, extra ...interface{}) (*models.ExportJob, error) {
+	job := &models.ExportJob{}
+	var version sql.NullInt64
+	var leasedBy sql.NullString
+	var etaSeconds sql.NullInt64
+
+	err := scanner.Scan(append([]interface{}{
+		&job.JobID,
+		&job.SessionID,
+		&job.UserID,
+		&job.WorkspaceID,
+		&version,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAfter,
+		&leasedBy,
+		&job.LeaseExpiresAt,
+		&job.ProgressPercent,
+		&etaSeconds,
+		&job.CancelRequested,
+		&job.LastError,
+		&job.OutputURL,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	}, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if version.Valid {
+		job.Version = int(version.Int64)
+	}
+	if leasedBy.Valid {
+		job.LeasedBy = leasedBy.String
+	}
+	if etaSeconds.Valid {
+		eta := int(etaSeconds.Int64)
+		job.ETASeconds = &eta
+	}
+	return job, nil
+}
+
+// ============================================================================
+// ENQUEUE — called by the API
+// ============================================================================
+
+// Enqueue adds an export job for the session. If one is already queued or
+// running, that job is returned instead — Export is safe to click twice.
+// The job snapshots the session's timeline and version: that is what gets
+// rendered, whatever is saved before a worker claims it.
+// workspaceID is the caller's active workspace and is stored on the job.
+func (s *ExportJobService) Enqueue(sessionID, userID, workspaceID uuid.UUID) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	// Exporting needs the editor role — the same check SessionService's
+	// writes apply
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO export_jobs (session_id, user_id, workspace_id, max_attempts, timeline, version)
+		SELECT session_id, $2, workspace_id, $3, timeline, version
+		FROM editor_sessions
+		WHERE session_id = $1
+		  AND ` + canEditSQL("$1", "$2", "$4") + `
+		  AND (NOT $5 OR status IN ('approved', 'exported'))
+		ON CONFLICT (session_id) WHERE status IN ('queued', 'running') DO NOTHING
+		RETURNING ` + exportJobSelectColumns
+
+	job, err := scanExportJob(s.DB.QueryRowContext(ctx, query, sessionID, userID, maxAttempts, workspaceArg(workspaceID), s.RequireApproval))
+	if errors.Is(err, sql.ErrNoRows) {
+		if s.RequireApproval {
+			var status string
+			if err := s.DB.QueryRowContext(ctx, `SELECT status FROM editor_sessions WHERE session_id = $1`, sessionID).Scan(&status); err != nil {
+				return nil, err
+			}
+			if !ExportAllowed(status) {
+				return nil, ErrApprovalRequired
+			}
+		}
+		// Conflict — an active job already exists for this session
+		return s.activeJobForSession(ctx, sessionID)
+	}
+	return job, err
+}
+
+// SnapshotSession returns session with the timeline and version the job
+// snapshotted at enqueue in place of the current ones; owner, workspace and
+// status stay current. A job without a snapshot (queued before snapshots
+// existed) gets session back as is.
+func SnapshotSession(session *models.EditorSession, job *models.ExportJob) (*models.EditorSession, error) {
+	if job.Timeline == nil {
+		return session, nil
+	}
+	tl, err := timeline.Decode(job.Timeline)
+	if err != nil {
+		return nil, fmt.Errorf("%w: export job %s snapshot: %v", ErrTimelineUnreadable, job.JobID, err)
+	}
+
+	snapshot := *session
+	snapshot.Timeline, snapshot.Version = *tl, job.Version
+	snapshot.TimelineError, snapshot.RawTimeline = "", nil
+	return &snapshot, nil
+}
+
+func (s *ExportJobService) activeJobForSession(ctx context.Context, sessionID uuid.UUID) (*models.ExportJob, error) {
+	query := `
+		SELECT ` + exportJobSelectColumns + `
+		FROM export_jobs
+		WHERE session_id = $1 AND status IN ('queued', 'running')
+	`
+
+	job, err := scanExportJob(s.DB.QueryRowContext(ctx, query, sessionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// ============================================================================
+// STATUS / CANCEL — called by the API
+// ============================================================================
+
+// Jobs are read and cancelled by the session's members, not just the user
+// who clicked Export — Enqueue hands every editor the session's active job.
+
+// GetJob fetches a job; userID needs the viewer role on its session, seen
+// from workspaceID (the caller's active workspace). A job on a session the
+// caller can't see is ErrJobNotFound.
+func (s *ExportJobService) GetJob(jobID, userID, workspaceID uuid.UUID) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.getJob(ctx, jobID, userID, workspaceID, models.RoleViewer)
+}
+
+// Cancel stops an export; userID needs the editor role on the job's session.
+// A queued job is cancelled on the spot; a running one is flagged and the
+// worker kills ffmpeg on its next progress tick or heartbeat.
+// Returns the job as it stands after the request.
+func (s *ExportJobService) Cancel(jobID, userID, workspaceID uuid.UUID) (*models.ExportJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := s.getJob(ctx, jobID, userID, workspaceID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single statement so a worker claiming the job concurrently can't slip between
+	// "is it queued?" and the update
+	query := `
+		UPDATE export_jobs
+		SET status           = CASE WHEN status = 'queued' THEN 'cancelled' ELSE status END,
+		    finished_at      = CASE WHEN status = 'queued' THEN NOW() ELSE finished_at END,
+		    cancel_requested = TRUE,
+		    updated_at       = NOW()
+		WHERE job_id = $1 AND status IN ('queued', 'running')
+		RETURNING ` + exportJobSelectColumns
+
+	updated, err := scanExportJob(s.DB.QueryRowContext(ctx, query, job.JobID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobFinished
+	}
+	return updated, err
+}
+
+// getJob loads a job and checks userID holds at least need on its session.
+func (s *ExportJobService) getJob(ctx context.Context, jobID, userID, workspaceID uuid.UUID, need string) (*models.ExportJob, error) {
+	query := `
+		SELECT ` + exportJobSelectColumns + `
+		FROM export_jobs
+		WHERE job_id = $1
+	`
+
+	job, err := scanExportJob(s.DB.QueryRowContext(ctx, query, jobID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := authorize(ctx, s.DB, job.SessionID, userID, workspaceID, need); err != nil {
+		if err == ErrSessionNotFound {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// ============================================================================
+// CLAIM / HEARTBEAT — called by cmd/worker
+// ============================================================================
+
+// Claim leases the next runnable job for workerID, or returns ErrJobNotFound
+// when the queue is empty. Runnable means queued and due, or running with an
+// expired lease (its worker died), attempts left and no pending cancel.
+// The claimed job carries its timeline snapshot — see SnapshotSession.
+func (s *ExportJobService) Claim(ctx context.Context, workerID string, lease time.Duration) (*models.ExportJob, error) {
+	query := `
+		UPDATE export_jobs
+		SET status           = 'running',
+		    attempts         = attempts + 1,
+		    leased_by        = $1,
+		    lease_expires_at = NOW() + make_interval(secs => $2),
+		    progress_percent = 0,
+		    eta_seconds      = NULL,
+		    started_at       = COALESCE(started_at, NOW()),
+		    updated_at       = NOW()
+		WHERE job_id = (
+			SELECT job_id
+			FROM export_jobs
+			WHERE (status = 'queued' AND run_after <= NOW())
+			   OR (status = 'running' AND lease_expires_at < NOW() AND attempts < max_attempts
+			       AND NOT cancel_requested)
+			ORDER BY run_after
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + exportJobSelectColumns + `, timeline`
+
+	var snapshot []byte
+	job, err := scanExportJob(s.DB.QueryRowContext(ctx, query, workerID, lease.Seconds()), &snapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Timeline = snapshot
+	return job, nil
+}
+
+// Heartbeat extends the lease on a running job and reports whether a cancel
+// was requested. ErrLeaseLost means another worker reclaimed it — the caller
+// must stop rendering.
+func (s *ExportJobService) Heartbeat(ctx context.Context, jobID uuid.UUID, workerID string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE export_jobs
+		SET lease_expires_at = NOW() + make_interval(secs => $1),
+		    updated_at       = NOW()
+		WHERE job_id = $2 AND leased_by = $3 AND status = 'running'
+		RETURNING cancel_requested
+	`
+	return s.queryLeasedCancel(ctx, query, lease.Seconds(), jobID, workerID)
+}
+
+// ReportProgress stores the latest render progress and reports whether a
+// cancel was requested — progress ticks are far more frequent than heartbeats,
+// so this is what makes DELETE /exports/{id} feel immediate.
+func (s *ExportJobService) ReportProgress(ctx context.Context, job *models.ExportJob, workerID string, percent float64, eta time.Duration) (bool, error) {
+	var etaSeconds sql.NullInt64
+	if eta > 0 {
+		etaSeconds = sql.NullInt64{Int64: int64(eta.Round(time.Second) / time.Second), Valid: true}
+	}
+
+	s.Events.Publish(job.SessionID, events.TypeExportProgress, map[string]interface{}{
+		"job_id":      job.JobID,
+		"percent":     percent,
+		"eta_seconds": etaSeconds.Int64,
+	})
+
+	query := `
+		UPDATE export_jobs
+		SET progress_percent = $1,
+		    eta_seconds      = $2,
+		    updated_at       = NOW()
+		WHERE job_id = $3 AND leased_by = $4 AND status = 'running'
+		RETURNING cancel_requested
+	`
+	return s.queryLeasedCancel(ctx, query, percent, etaSeconds, job.JobID, workerID)
+}
+
+// Complete marks the job done and records where the render was stored.
+func (s *ExportJobService) Complete(ctx context.Context, jobID uuid.UUID, workerID, outputURL string) error {
+	query := `
+		UPDATE export_jobs
+		SET status           = 'completed',
+		    output_url       = $1,
+		    progress_percent = 100,
+		    eta_seconds      = NULL,
+		    last_error       = '',
+		    lease_expires_at = NULL,
+		    finished_at      = NOW(),
+		    updated_at       = NOW()
+		WHERE job_id = $2 AND leased_by = $3 AND status = 'running'
+	`
+	return s.execLeased(ctx, query, outputURL, jobID, workerID)
+}
+
+// Fail records a failed attempt. The job goes back to the queue with
+// exponential backoff while attempts remain; otherwise it fails for good.
+// Returns true when the job was requeued.
+func (s *ExportJobService) Fail(ctx context.Context, job *models.ExportJob, workerID string, cause error) (bool, error) {
+	retry := job.Attempts < job.MaxAttempts
+	if !retry {
+		query := `
+			UPDATE export_jobs
+			SET status           = 'failed',
+			    last_error       = $1,
+			    lease_expires_at = NULL,
+			    finished_at      = NOW(),
+			    updated_at       = NOW()
+			WHERE job_id = $2 AND leased_by = $3 AND status = 'running'
+		`
+		return false, s.execLeased(ctx, query, cause.Error(), job.JobID, workerID)
+	}
+
+	query := `
+		UPDATE export_jobs
+		SET status           = 'queued',
+		    last_error       = $1,
+		    run_after        = NOW() + make_interval(secs => $2),
+		    leased_by        = NULL,
+		    lease_expires_at = NULL,
+		    updated_at       = NOW()
+		WHERE job_id = $3 AND leased_by = $4 AND status = 'running'
+	`
+	delay := s.backoff(job.Attempts)
+	return true, s.execLeased(ctx, query, cause.Error(), delay.Seconds(), job.JobID, workerID)
+}
+
+// MarkCancelled settles a running job after the worker stopped its render
+// in response to a cancel request.
+func (s *ExportJobService) MarkCancelled(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	query := `
+		UPDATE export_jobs
+		SET status           = 'cancelled',
+		    lease_expires_at = NULL,
+		    eta_seconds      = NULL,
+		    finished_at      = NOW(),
+		    updated_at       = NOW()
+		WHERE job_id = $1 AND leased_by = $2 AND status = 'running'
+	`
+	return s.execLeased(ctx, query, jobID, workerID)
+}
+
+// Release hands a job back untouched — used on worker shutdown. The attempt
+// is not counted against the job since the render never got to finish.
+func (s *ExportJobService) Release(ctx context.Context, jobID uuid.UUID, workerID string) error {
+	query := `
+		UPDATE export_jobs
+		SET status           = 'queued',
+		    attempts         = GREATEST(attempts - 1, 0),
+		    leased_by        = NULL,
+		    lease_expires_at = NULL,
+		    updated_at       = NOW()
+		WHERE job_id = $1 AND leased_by = $2 AND status = 'running'
+	`
+	return s.execLeased(ctx, query, jobID, workerID)
+}
+
+// FailAbandoned settles running jobs whose worker died and that Claim will
+// never pick up again — final attempt used up (→ failed) or cancel pending
+// (→ cancelled). Without this they would stay "running" forever.
+// Returns the affected jobs so the caller can update their sessions.
+func (s *ExportJobService) FailAbandoned(ctx context.Context) ([]*models.ExportJob, error) {
+	query := `
+		UPDATE export_jobs
+		SET status           = CASE WHEN cancel_requested THEN 'cancelled' ELSE 'failed' END,
+		    last_error       = CASE WHEN cancel_requested THEN last_error
+		                            ELSE 'worker lease expired on final attempt' END,
+		    lease_expires_at = NULL,
+		    eta_seconds      = NULL,
+		    finished_at      = NOW(),
+		    updated_at       = NOW()
+		WHERE status = 'running' AND lease_expires_at < NOW()
+		  AND (attempts >= max_attempts OR cancel_requested)
+		RETURNING ` + exportJobSelectColumns
+
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.ExportJob
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ============================================================================
+// INTERNAL
+// ============================================================================
+
+// queryLeasedCancel runs a leased UPDATE ... RETURNING cancel_requested.
+func (s *ExportJobService) queryLeasedCancel(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	var cancelRequested bool
+	err := s.DB.QueryRowContext(ctx, query, args...).Scan(&cancelRequested)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrLeaseLost
+	}
+	if err != nil {
+		return false, fmt.Errorf("export job update failed: %w", err)
+	}
+	return cancelRequested, nil
+}
+
+func (s *ExportJobService) backoff(attempt int) time.Duration {
+	base := s.RetryBackoff
+	if base <= 0 {
+		base = 30 * time.Second
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	return base * time.Duration(1<<(attempt-1))
+}
+
+// execLeased runs an UPDATE guarded by leased_by — zero rows means this worker
+// no longer owns the job.
+func (s *ExportJobService) execLeased(ctx context.Context, query string, args ...interface{}) error {
+	result, err := s.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("export job update failed: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
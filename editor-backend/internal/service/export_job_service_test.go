@@ -0,0 +1,49 @@
+// internal/service/export_job_service_test.go
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// The worker renders the job's snapshot: its timeline (upgraded like a
+// stored one) and version, on top of the session as it is now.
+func TestSnapshotSession(t *testing.T) {
+	session, err := scanSession(sessionRow(uuid.New(), []byte(`{"duration": 9, "tracks": []}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Status = models.SessionStatusApproved
+
+	legacy := []byte(`{"duration": 5, "tracks": [{"type": "video", "clips": [
+		{"id": "c1", "src": "/uploads/a.mp4", "start": 0, "end": 5, "duration": 5}]}]}`)
+	job := &models.ExportJob{JobID: uuid.New(), Version: 2, Timeline: legacy}
+
+	snapshot, err := SnapshotSession(session, job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Version != 2 || snapshot.Timeline.Duration != 5 || snapshot.Timeline.Tracks[0].Clips[0].ClipID != "c1" {
+		t.Fatalf("snapshot = version %d, %+v", snapshot.Version, snapshot.Timeline)
+	}
+	if snapshot.SessionID != session.SessionID || snapshot.Status != models.SessionStatusApproved {
+		t.Fatalf("session fields lost: %+v", snapshot)
+	}
+	if session.Version != 3 || session.Timeline.Duration != 9 {
+		t.Fatalf("the loaded session was changed: version %d, %+v", session.Version, session.Timeline)
+	}
+
+	// Jobs queued before snapshots existed render the session as it is
+	if got, err := SnapshotSession(session, &models.ExportJob{}); err != nil || got != session {
+		t.Fatalf("job without a snapshot: %v, %v", got, err)
+	}
+
+	job.Timeline = []byte(`{"tracks": [42]}`)
+	if _, err := SnapshotSession(session, job); !errors.Is(err, ErrTimelineUnreadable) {
+		t.Fatalf("unreadable snapshot: err = %v, want ErrTimelineUnreadable", err)
+	}
+}
@@ -0,0 +1,79 @@
+// internal/service/export_service.go
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/render"
+	"editor-backend/internal/storage"
+)
+
+// ExportService runs the render pipeline for a session:
+//
+//	timeline → ffmpeg (rendering) → storage.Upload (uploading)
+//
+// Every step is mirrored into editor_sessions.export_status so the UI (and the
+// Content Hub later) can see where an export is without asking this process.
+// The terminal status (completed, failed, cancelled, or back to queued) is
+// left to the caller: only it knows whether an error was a real failure or a
+// shutdown, cancel or lost lease.
+type ExportService struct {
+	Sessions *SessionService
+	Renderer *render.Renderer
+	Storage  storage.Storage
+}
+
+// Export renders the session timeline and stores the resulting MP4.
+// Returns the storage URL of the rendered file. onProgress may be nil.
+// On error the session is left at its last in-progress status.
+func (e *ExportService) Export(ctx context.Context, session *models.EditorSession, onProgress render.ProgressFunc) (string, error) {
+	if err := e.Sessions.UpdateExportStatus(session.SessionID, session.UserID, WorkspaceOf(session.WorkspaceID), ExportStatusRendering, ""); err != nil {
+		return "", err
+	}
+
+	return e.renderAndUpload(ctx, session, onProgress)
+}
+
+func (e *ExportService) renderAndUpload(ctx context.Context, session *models.EditorSession, onProgress render.ProgressFunc) (string, error) {
+	// An unreadable timeline loads as an empty one — don't render that
+	if session.TimelineError != "" {
+		return "", ErrTimelineUnreadable
+	}
+
+	// Scratch space per export — removed whether the render succeeds or not
+	workDir, err := os.MkdirTemp("", "editor-export-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	outputPath := filepath.Join(workDir, session.SessionID.String()+".mp4")
+	if err := e.Renderer.Render(ctx, &session.Timeline, workDir, outputPath, onProgress); err != nil {
+		return "", fmt.Errorf("render failed: %w", err)
+	}
+
+	if err := e.Sessions.UpdateExportStatus(session.SessionID, session.UserID, WorkspaceOf(session.WorkspaceID), ExportStatusUploading, ""); err != nil {
+		return "", err
+	}
+
+	out, err := os.Open(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open rendered file: %w", err)
+	}
+	defer out.Close()
+
+	stat, err := out.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat rendered file: %w", err)
+	}
+
+	object, err := e.Storage.Upload(out, stat.Size(), filepath.Base(outputPath), "video/mp4")
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	return object.URL, nil
+}
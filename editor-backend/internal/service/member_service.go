@@ -0,0 +1,376 @@
+// internal/service/member_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInsufficientRole = errors.New("your role on this session does not allow this")
+	ErrMemberNotFound   = errors.New("member not found")
+	ErrMemberExists     = errors.New("user is already a member of this session")
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrOwnerMembership  = errors.New("the owner's role cannot be changed — transfer ownership instead")
+)
+
+// ============================================================================
+// ROLES
+// ============================================================================
+//
+// The owner is editor_sessions.user_id; everyone else is a row in
+// editor_session_members. Each role includes the ones below it:
+//
+//	owner     → manage members, transfer ownership, delete
+//	editor    → save, restore versions, export
+//	commenter → comment (reads everything a viewer can)
+//	viewer    → read the session, its versions and live events
+//
+// Inside a workspace, workspace admins and members act as editors on every
+// session in it and workspace viewers as viewers, on top of any session role.
+
+var roleRank = map[string]int{
+	models.RoleViewer:    1,
+	models.RoleCommenter: 2,
+	models.RoleEditor:    3,
+	models.RoleOwner:     4,
+}
+
+// workspaceSessionRole is the session role a workspace role implies.
+var workspaceSessionRole = map[string]string{
+	models.WorkspaceRoleAdmin:  models.RoleEditor,
+	models.WorkspaceRoleMember: models.RoleEditor,
+	models.WorkspaceRoleViewer: models.RoleViewer,
+}
+
+// IsMemberRole reports whether role can be granted to a non-owner.
+func IsMemberRole(role string) bool {
+	return role == models.RoleEditor || role == models.RoleCommenter || role == models.RoleViewer
+}
+
+// sessionRole returns userID's role on a session, as seen from workspaceID
+// (uuid.Nil = personal space): ErrSessionNotFound when the session is gone or
+// lives in another workspace, ErrUnauthorized when the user has no access.
+//
+// Inside a workspace the user must be a workspace member, and their workspace
+// role counts too (see workspaceSessionRole) — the stronger role wins.
+func sessionRole(ctx context.Context, db *sql.DB, sessionID, userID, workspaceID uuid.UUID) (string, error) {
+	var owner uuid.UUID
+	var sessionWorkspace uuid.NullUUID
+	var role, wsRole sql.NullString
+
+	err := db.QueryRowContext(ctx, `
+		SELECT s.user_id, s.workspace_id, m.role, w.role
+		FROM editor_sessions s
+		LEFT JOIN editor_session_members m
+		       ON m.session_id = s.session_id AND m.user_id = $2
+		LEFT JOIN workspace_members w
+		       ON w.workspace_id = s.workspace_id AND w.user_id = $2
+		WHERE s.session_id = $1
+	`, sessionID, userID).Scan(&owner, &sessionWorkspace, &role, &wsRole)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrSessionNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// A session outside the caller's workspace doesn't exist for this request
+	if sessionWorkspace.UUID != workspaceID {
+		return "", ErrSessionNotFound
+	}
+	if workspaceID != uuid.Nil && !wsRole.Valid {
+		return "", ErrUnauthorized
+	}
+
+	best := ""
+	switch {
+	case owner == userID:
+		best = models.RoleOwner
+	case role.Valid:
+		best = role.String
+	}
+	if derived := workspaceSessionRole[wsRole.String]; roleRank[derived] > roleRank[best] {
+		best = derived
+	}
+	if best == "" {
+		return "", ErrUnauthorized
+	}
+	return best, nil
+}
+
+// authorize checks userID holds at least the given role on the session.
+func authorize(ctx context.Context, db *sql.DB, sessionID, userID, workspaceID uuid.UUID, need string) (string, error) {
+	role, err := sessionRole(ctx, db, sessionID, userID, workspaceID)
+	if err != nil {
+		return "", err
+	}
+	if roleRank[role] < roleRank[need] {
+		return role, ErrInsufficientRole
+	}
+	return role, nil
+}
+
+// canEditSQL is the WHERE fragment used by writes that need the editor role,
+// so the permission check and the write are one statement. session / user /
+// workspace are the placeholders of the caller's query; the query must read
+// from editor_sessions. It mirrors sessionRole: the session must be in the
+// caller's workspace, and inside a workspace the caller must belong to it.
+func canEditSQL(session, user, workspace string) string {
+	direct := fmt.Sprintf(`(editor_sessions.user_id = %[2]s OR EXISTS (
+			SELECT 1 FROM editor_session_members m
+			WHERE m.session_id = %[1]s AND m.user_id = %[2]s AND m.role = 'editor'))`, session, user)
+
+	return fmt.Sprintf(`(editor_sessions.workspace_id IS NOT DISTINCT FROM %[1]s::uuid AND CASE
+			WHEN %[1]s::uuid IS NULL THEN %[3]s
+			ELSE EXISTS (
+				SELECT 1 FROM workspace_members w
+				WHERE w.workspace_id = %[1]s::uuid AND w.user_id = %[2]s
+				  AND (w.role IN ('admin', 'member') OR %[3]s))
+		END)`, workspace, user, direct)
+}
+
+// SessionRole returns the caller's current role on the session without
+// loading it — open streams re-check access with it. Errors as sessionRole.
+func (s *SessionService) SessionRole(sessionID, userID, workspaceID uuid.UUID) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return sessionRole(ctx, s.DB, sessionID, userID, workspaceID)
+}
+
+// ============================================================================
+// MEMBERS
+// ============================================================================
+
+// ListMembers returns the owner followed by every member. Any role may look.
+func (s *SessionService) ListMembers(sessionID, userID, workspaceID uuid.UUID) ([]models.SessionMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT session_id, user_id, role, invited_by, created_at, updated_at
+		FROM (
+			SELECT session_id, user_id, 'owner' AS role, NULL::uuid AS invited_by, created_at, updated_at, 0 AS rank
+			FROM editor_sessions
+			WHERE session_id = $1
+			UNION ALL
+			SELECT session_id, user_id, role, invited_by, created_at, updated_at, 1 AS rank
+			FROM editor_session_members
+			WHERE session_id = $1
+		) members
+		ORDER BY rank, created_at
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.SessionMember{}
+	for rows.Next() {
+		var m models.SessionMember
+		if err := rows.Scan(&m.SessionID, &m.UserID, &m.Role, &m.InvitedBy, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// AddMember grants memberID a role on the session. Owner only.
+func (s *SessionService) AddMember(sessionID, userID, workspaceID, memberID uuid.UUID, role string) (*models.SessionMember, error) {
+	if !IsMemberRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+	if memberID == userID {
+		return nil, ErrOwnerMembership
+	}
+	// Workspace sessions are only reachable by workspace members
+	if workspaceID != uuid.Nil {
+		if _, err := workspaceRole(ctx, s.DB, workspaceID, memberID); err != nil {
+			if err == ErrWorkspaceAccess {
+				return nil, ErrNotWorkspaceMember
+			}
+			return nil, err
+		}
+	}
+
+	m := &models.SessionMember{SessionID: sessionID, UserID: memberID, Role: role, InvitedBy: &userID}
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO editor_session_members (session_id, user_id, role, invited_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at
+	`, sessionID, memberID, role, userID).Scan(&m.CreatedAt, &m.UpdatedAt)
+	if isUniqueViolation(err) {
+		return nil, ErrMemberExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	s.publishMembersChanged(sessionID)
+	return m, nil
+}
+
+// UpdateMemberRole changes a member's role. Owner only.
+func (s *SessionService) UpdateMemberRole(sessionID, userID, workspaceID, memberID uuid.UUID, role string) (*models.SessionMember, error) {
+	if !IsMemberRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+	if memberID == userID {
+		return nil, ErrOwnerMembership
+	}
+
+	m := &models.SessionMember{SessionID: sessionID, UserID: memberID, Role: role}
+	err := s.DB.QueryRowContext(ctx, `
+		UPDATE editor_session_members
+		SET role = $3, updated_at = NOW()
+		WHERE session_id = $1 AND user_id = $2
+		RETURNING invited_by, created_at, updated_at
+	`, sessionID, memberID, role).Scan(&m.InvitedBy, &m.CreatedAt, &m.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishMembersChanged(sessionID)
+	return m, nil
+}
+
+// RemoveMember revokes access. The owner can remove anyone; a member can
+// remove themselves (leave).
+func (s *SessionService) RemoveMember(sessionID, userID, workspaceID, memberID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	need := models.RoleOwner
+	if memberID == userID {
+		need = models.RoleViewer
+	}
+	role, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, need)
+	if err != nil {
+		return err
+	}
+	if role == models.RoleOwner && memberID == userID {
+		return ErrOwnerMembership
+	}
+
+	result, err := s.DB.ExecContext(ctx, `
+		DELETE FROM editor_session_members
+		WHERE session_id = $1 AND user_id = $2
+	`, sessionID, memberID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrMemberNotFound
+	}
+
+	s.publishMembersChanged(sessionID)
+	return nil
+}
+
+// TransferOwnership makes an existing member the owner. The previous owner
+// stays on as an editor. Owner only. A workspace session can only go to a
+// member of the workspace (ErrNotWorkspaceMember) — anyone else would own a
+// session they can't open.
+func (s *SessionService) TransferOwnership(sessionID, userID, workspaceID, newOwnerID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if newOwnerID == userID {
+		return ErrOwnerMembership
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the session so two concurrent transfers can't both succeed. The
+	// owner check plus canEditSQL keeps the session in the caller's workspace.
+	result, err := tx.ExecContext(ctx, `
+		UPDATE editor_sessions
+		SET user_id = $3, updated_at = NOW()
+		WHERE session_id = $1 AND user_id = $2
+		  AND `+canEditSQL("$1", "$2", "$4")+`
+		  AND EXISTS (SELECT 1 FROM editor_session_members WHERE session_id = $1 AND user_id = $3)
+		  AND ($4::uuid IS NULL OR EXISTS (
+		      SELECT 1 FROM workspace_members WHERE workspace_id = $4::uuid AND user_id = $3))
+	`, sessionID, userID, newOwnerID, workspaceArg(workspaceID))
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleOwner); err != nil {
+			return err
+		}
+		if workspaceID != uuid.Nil {
+			if _, err := workspaceRole(ctx, s.DB, workspaceID, newOwnerID); err == ErrWorkspaceAccess {
+				return ErrNotWorkspaceMember
+			} else if err != nil {
+				return err
+			}
+		}
+		return ErrMemberNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM editor_session_members WHERE session_id = $1 AND user_id = $2
+	`, sessionID, newOwnerID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO editor_session_members (session_id, user_id, role, invited_by)
+		VALUES ($1, $2, 'editor', $3)
+	`, sessionID, userID, newOwnerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.publishMembersChanged(sessionID)
+	return nil
+}
+
+func (s *SessionService) publishMembersChanged(sessionID uuid.UUID) {
+	s.Events.Publish(sessionID, events.TypeMembersChanged, nil)
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,277 @@
+// internal/service/operation_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+	"editor-backend/internal/timeline"
+	"editor-backend/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidOperation  = errors.New("invalid operation")
+	ErrOperationTooLarge = errors.New("operation too large to broadcast — save the session instead")
+	ErrOperationsPruned  = errors.New("operations since that version are no longer kept — reload the session")
+	ErrTooManyOperations = fmt.Errorf("at most %d operations per request", MaxOperationsPerRequest)
+)
+
+// MaxOperationsPerRequest caps one ApplyOperations batch.
+const MaxOperationsPerRequest = 100
+
+// defaultOperationRetention is how many versions of ops are kept for
+// reconnecting clients to catch up from.
+const defaultOperationRetention = 1000
+
+// ============================================================================
+// OPERATIONS — collaborative edits, ordered by the session version
+// ============================================================================
+//
+// Each op is applied to the stored timeline under a row lock, so ops from any
+// pod are serialized by Postgres and each gets the next version. The op.applied
+// event is NOTIFYed inside the same transaction: listeners receive ops in
+// commit order, i.e. version order. Ops are logged in editor_session_ops for
+// clients that reconnect; they are not version-history entries — saves are.
+//
+// The same path serves POST /sessions/{id}/operations, so automations and
+// other services edit with typed commands instead of whole timelines.
+
+// ApplyOperation applies op to the session's current timeline and persists
+// the result. Needs the editor role. Invalid ops return an error wrapping
+// ErrInvalidOperation, or a *validation.TimelineError if the result would be
+// an invalid timeline; a stored timeline that can't be read is
+// ErrTimelineUnreadable. clientOpID is echoed back so the sender can match it.
+func (s *SessionService) ApplyOperation(sessionID, userID, workspaceID uuid.UUID, op timeline.Op, clientOpID string) (*models.SessionOperation, error) {
+	result, err := s.ApplyOperations(sessionID, userID, workspaceID, []timeline.Op{op}, clientOpID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Operations[0], nil
+}
+
+// OperationsResult is what ApplyOperations wrote.
+type OperationsResult struct {
+	Version    int                       `json:"version"`
+	Operations []models.SessionOperation `json:"operations"`
+	Timeline   *models.Timeline          `json:"timeline"`
+}
+
+// ApplyOperations applies ops in order, all or nothing: the timeline is
+// validated once at the end, so intermediate steps may be out of range. Each
+// op still gets its own version, as if sent one at a time over the live
+// socket. expectedVersion works as in SaveSession (0 = no check).
+func (s *SessionService) ApplyOperations(sessionID, userID, workspaceID uuid.UUID, ops []timeline.Op, clientOpID string, expectedVersion int) (*OperationsResult, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: at least one operation is required", ErrInvalidOperation)
+	}
+	if len(ops) > MaxOperationsPerRequest {
+		return nil, ErrTooManyOperations
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var raw []byte
+	var status string
+	var version int
+	err = tx.QueryRowContext(ctx, `
+		SELECT timeline, status, version
+		FROM editor_sessions
+		WHERE session_id = $1 AND `+canEditSQL("$1", "$2", "$3")+`
+		FOR UPDATE
+	`, sessionID, userID, workspaceArg(workspaceID)).Scan(&raw, &status, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleEditor); err != nil {
+			return nil, err
+		}
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != 0 && expectedVersion != version {
+		tx.Rollback()
+		current, err := s.getSession(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &VersionConflictError{Current: current}
+	}
+
+	tl, err := timeline.Decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTimelineUnreadable, err)
+	}
+
+	result := &OperationsResult{Timeline: tl}
+	for i := range ops {
+		if err := timeline.Apply(tl, &ops[i]); err != nil {
+			if len(ops) > 1 {
+				return nil, fmt.Errorf("%w: operations[%d]: %v", ErrInvalidOperation, i, err)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOperation, err)
+		}
+		// Marshalled after Apply — generated clip IDs are part of the op
+		opJSON, err := json.Marshal(ops[i])
+		if err != nil {
+			return nil, err
+		}
+		result.Operations = append(result.Operations, models.SessionOperation{
+			SessionID:  sessionID,
+			Version:    version + 1 + i,
+			UserID:     userID,
+			ClientOpID: clientOpID,
+			Op:         opJSON,
+		})
+	}
+	if err := validation.ValidateTimeline(tl); err != nil {
+		return nil, err
+	}
+	tl.SchemaVersion = timeline.CurrentSchemaVersion
+
+	timelineJSON, err := json.Marshal(tl)
+	if err != nil {
+		return nil, err
+	}
+
+	var newStatus string
+	var updatedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		UPDATE editor_sessions
+		SET timeline   = $2,
+		    version    = version + $3,
+		    status     = CASE WHEN status = 'approved' THEN 'in_review' ELSE status END,
+		    updated_at = NOW()
+		WHERE session_id = $1
+		RETURNING version, status, updated_at
+	`, sessionID, timelineJSON, len(ops)).Scan(&result.Version, &newStatus, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Operations {
+		applied := &result.Operations[i]
+		applied.CreatedAt = updatedAt
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO editor_session_ops (session_id, version, user_id, client_op_id, op)
+			VALUES ($1, $2, $3, $4, $5)
+		`, sessionID, applied.Version, userID, clientOpID, applied.Op); err != nil {
+			return nil, fmt.Errorf("failed to log operation: %w", err)
+		}
+
+		// Inside the transaction — see the ordering note above
+		if err := s.Events.PublishTx(ctx, tx, sessionID, events.TypeOperation, applied); err != nil {
+			if errors.Is(err, events.ErrPayloadTooLarge) {
+				return nil, ErrOperationTooLarge
+			}
+			return nil, err
+		}
+	}
+
+	retention := s.OperationRetention
+	if retention <= 0 {
+		retention = defaultOperationRetention
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM editor_session_ops WHERE session_id = $1 AND version <= $2
+	`, sessionID, result.Version-retention); err != nil {
+		return nil, fmt.Errorf("failed to prune operations: %w", err)
+	}
+
+	// The whole batch is one undo step
+	if err := s.pushUndo(ctx, tx, sessionID, userID, version, raw); err != nil {
+		return nil, err
+	}
+
+	publish, err := s.afterTimelineWrite(ctx, tx, sessionID, userID, result.Version, status, newStatus, tl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publish()
+	return result, nil
+}
+
+// OperationsSince returns the ops that produced versions after `since`, in
+// order. ErrOperationsPruned means the log no longer reaches back that far
+// (or a full save happened in between) and the client must reload. Any role
+// may read.
+func (s *SessionService) OperationsSince(sessionID, userID, workspaceID uuid.UUID, since int) ([]models.SessionOperation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	// One snapshot for both reads, so an op landing in between can't look
+	// like a gap
+	tx, err := s.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT version FROM editor_sessions WHERE session_id = $1
+	`, sessionID).Scan(&current); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT session_id, version, user_id, client_op_id, op, created_at
+		FROM editor_session_ops
+		WHERE session_id = $1 AND version > $2
+		ORDER BY version
+	`, sessionID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ops := []models.SessionOperation{}
+	for rows.Next() {
+		var op models.SessionOperation
+		if err := rows.Scan(&op.SessionID, &op.Version, &op.UserID, &op.ClientOpID, &op.Op, &op.CreatedAt); err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Every version in (since, current] must be an op — a gap is a full save
+	// or a pruned op, which replaying can't reproduce
+	if len(ops) != current-since {
+		return nil, ErrOperationsPruned
+	}
+	for i, op := range ops {
+		if op.Version != since+1+i {
+			return nil, ErrOperationsPruned
+		}
+	}
+	return ops, nil
+}
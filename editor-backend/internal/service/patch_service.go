@@ -0,0 +1,134 @@
+// internal/service/patch_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/jsonpatch"
+	"editor-backend/internal/models"
+	"editor-backend/internal/timeline"
+	"editor-backend/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// Patch formats accepted by PatchSession.
+const (
+	PatchJSON  = "json-patch"  // RFC 6902, application/json-patch+json
+	PatchMerge = "merge-patch" // RFC 7396, application/merge-patch+json
+)
+
+var (
+	ErrUnsupportedPatch = errors.New("unsupported patch format")
+	ErrPatchedTimeline  = errors.New("the patched document is not a timeline")
+)
+
+// ============================================================================
+// PATCH SESSION — partial saves
+// ============================================================================
+
+// PatchSession applies a JSON Patch or Merge Patch to the session's timeline
+// and saves the result exactly like SaveSession (version history, undo,
+// events). The patch targets the timeline as GET /sessions/{id} returns it;
+// it is applied under a row lock, so concurrent patches never interleave.
+// expectedVersion works as in SaveSession (0 = patch whatever is current).
+//
+// Patch errors wrap jsonpatch.ErrInvalidPatch, ErrPathNotFound or
+// ErrTestFailed; a result that won't decode is ErrPatchedTimeline, and one
+// that fails validation a *validation.TimelineError. A stored timeline that
+// can't be read is ErrTimelineUnreadable — only a full save replaces it.
+// Returns the new version.
+func (s *SessionService) PatchSession(id, userID, workspaceID uuid.UUID, format string, patch []byte, expectedVersion int) (int, error) {
+	if format != PatchJSON && format != PatchMerge {
+		return 0, ErrUnsupportedPatch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var raw []byte
+	var version int
+	err = tx.QueryRowContext(ctx, `
+		SELECT timeline, version
+		FROM editor_sessions
+		WHERE session_id = $1 AND `+canEditSQL("$1", "$2", "$3")+`
+		FOR UPDATE
+	`, id, userID, workspaceArg(workspaceID)).Scan(&raw, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		if _, err := authorize(ctx, s.DB, id, userID, workspaceID, models.RoleEditor); err != nil {
+			return 0, err
+		}
+		return 0, ErrSessionNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if expectedVersion != 0 && expectedVersion != version {
+		tx.Rollback()
+		current, err := s.getSession(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return 0, &VersionConflictError{Current: current}
+	}
+
+	// Patch the current shape, not whatever schema the row was stored in
+	current, err := timeline.Decode(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrTimelineUnreadable, err)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return 0, err
+	}
+
+	var patched []byte
+	if format == PatchJSON {
+		patched, err = jsonpatch.Apply(doc, patch)
+	} else {
+		patched, err = jsonpatch.Merge(doc, patch)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	tl, err := timeline.Decode(patched)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrPatchedTimeline, err)
+	}
+	if err := validation.ValidateTimeline(tl); err != nil {
+		return 0, err
+	}
+	tl.SchemaVersion = timeline.CurrentSchemaVersion
+
+	timelineJSON, err := json.Marshal(tl)
+	if err != nil {
+		return 0, err
+	}
+
+	// The row is locked, so the version can't have moved since the SELECT
+	newVersion, publish, err := s.saveTimeline(ctx, tx, id, userID, workspaceID, tl, timelineJSON, version, "")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	publish()
+	return newVersion, nil
+}
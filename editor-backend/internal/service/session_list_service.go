@@ -0,0 +1,203 @@
+// internal/service/session_list_service.go
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	defaultSessionPageSize = 20
+	maxSessionPageSize     = 100
+)
+
+// SessionFilter narrows ListSessions. Zero values mean "no filter".
+type SessionFilter struct {
+	SourceModule string
+	Platform     string
+	ExportStatus string
+	Status       string
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// Ascending lists oldest-updated first; default is most recent first
+	Ascending bool
+
+	Limit  int
+	Cursor string // NextCursor from the previous page
+}
+
+// SessionPage is one page of ListSessions. NextCursor is empty on the last page.
+type SessionPage struct {
+	Sessions   []*models.EditorSession `json:"sessions"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+}
+
+// ============================================================================
+// LIST SESSIONS — keyset pagination on (updated_at, session_id)
+// ============================================================================
+
+// ListSessions returns the sessions the user owns or is a member of within
+// workspaceID (uuid.Nil = personal sessions), ordered by updated_at.
+//
+// Pagination is keyset, not OFFSET: the cursor encodes the last row's
+// (updated_at, session_id), so a session saved while the user pages through
+// moves to the top instead of shifting every later page by one.
+func (s *SessionService) ListSessions(userID, workspaceID uuid.UUID, f SessionFilter) (*SessionPage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	// Owned sessions plus those shared with the user
+	return s.listSessions(ctx, f,
+		"workspace_id IS NOT DISTINCT FROM $1::uuid AND (user_id = $2 OR session_id IN (SELECT session_id FROM editor_session_members WHERE user_id = $2))",
+		workspaceArg(workspaceID), userID)
+}
+
+// ListWorkspaceSessions returns every session in the workspace, whoever owns
+// it. Any workspace member may look.
+func (s *SessionService) ListWorkspaceSessions(workspaceID, userID uuid.UUID, f SessionFilter) (*SessionPage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.listSessions(ctx, f, "workspace_id = $1", workspaceID)
+}
+
+// listSessions pages through editor_sessions matching scope (whose
+// placeholders are args) plus the filters in f.
+func (s *SessionService) listSessions(ctx context.Context, f SessionFilter, scope string, args ...interface{}) (*SessionPage, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultSessionPageSize
+	}
+	if limit > maxSessionPageSize {
+		limit = maxSessionPageSize
+	}
+
+	where := []string{scope}
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	// Equality filters line up with idx_editor_sessions_source_module /
+	// idx_editor_sessions_export_status (both lead with user_id)
+	if f.SourceModule != "" {
+		add("source_module = $%d", f.SourceModule)
+	}
+	if f.Platform != "" {
+		add("platform = $%d", f.Platform)
+	}
+	if f.ExportStatus != "" {
+		add("export_status = $%d", f.ExportStatus)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.CreatedAfter != nil {
+		add("created_at >= $%d", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < $%d", *f.CreatedBefore)
+	}
+	if f.UpdatedAfter != nil {
+		add("updated_at >= $%d", *f.UpdatedAfter)
+	}
+	if f.UpdatedBefore != nil {
+		add("updated_at < $%d", *f.UpdatedBefore)
+	}
+
+	order, cmp := "DESC", "<"
+	if f.Ascending {
+		order, cmp = "ASC", ">"
+	}
+
+	if f.Cursor != "" {
+		after, afterID, err := decodeSessionCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, after, afterID)
+		where = append(where, fmt.Sprintf("(updated_at, session_id) %s ($%d, $%d)", cmp, len(args)-1, len(args)))
+	}
+
+	// Fetch one extra row to know whether another page exists
+	args = append(args, limit+1)
+	query := `
+		SELECT ` + sessionSelectColumns + `
+		FROM editor_sessions
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY updated_at ` + order + `, session_id ` + order + `
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	page := &SessionPage{Sessions: []*models.EditorSession{}}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Sessions = append(page.Sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Sessions) > limit {
+		page.Sessions = page.Sessions[:limit]
+		last := page.Sessions[limit-1]
+		page.NextCursor = encodeSessionCursor(last.UpdatedAt, last.SessionID)
+	}
+	return page, nil
+}
+
+// Cursor format (opaque to clients): base64url("<RFC3339Nano updated_at>|<session_id>")
+func encodeSessionCursor(updatedAt time.Time, id uuid.UUID) string {
+	raw := updatedAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSessionCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	updatedAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return updatedAt, sessionID, nil
+}
@@ -6,9 +6,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
+	"editor-backend/internal/events"
 	"editor-backend/internal/models"
+	"editor-backend/internal/timeline"
+	"editor-backend/internal/validation"
 
 	"github.com/google/uuid"
 )
@@ -16,11 +21,52 @@ import (
 // Sentinel errors — callers use errors.Is() instead of string matching
 var (
 	ErrSessionNotFound = errors.New("session not found")
-	ErrUnauthorized    = errors.New("unauthorized: session belongs to another user")
+	ErrUnauthorized    = errors.New("unauthorized: no access to this session")
+	ErrVersionConflict = errors.New("version conflict: session was saved by someone else")
+
+	// ErrTimelineUnreadable — the stored timeline failed to decode (see
+	// EditorSession.TimelineError); it can be replaced, not built on
+	ErrTimelineUnreadable = errors.New("the session's stored timeline could not be read")
 )
 
+// VersionConflictError carries the server's current session so the client can
+// merge or reload. errors.Is(err, ErrVersionConflict) matches it.
+type VersionConflictError struct {
+	Current *models.EditorSession
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s (current version %d)", ErrVersionConflict, e.Current.Version)
+}
+
+func (e *VersionConflictError) Unwrap() error { return ErrVersionConflict }
+
 type SessionService struct {
 	DB *sql.DB
+
+	// VersionRetention is how many unlabelled versions are kept per session
+	// (0 → 100). Labelled versions are never pruned.
+	VersionRetention int
+
+	// OperationRetention is how many versions of collaborative ops are kept
+	// for reconnecting clients (0 → 1000).
+	OperationRetention int
+
+	// UndoDepth is how many undo (and redo) steps are kept per session
+	// (0 → 50).
+	UndoDepth int
+
+	// ApprovalRole is the session role needed to approve or request changes
+	// (see approval_service.go); empty → owner.
+	ApprovalRole string
+
+	// ShareMaxFailures is how many wrong passwords lock a share link
+	// (0 → 10); ShareLockout is how long it stays locked (0 → 15m).
+	ShareMaxFailures int
+	ShareLockout     time.Duration
+
+	// Events is optional — nil disables live updates (see internal/events)
+	Events *events.Publisher
 }
 
 // ============================================================================
@@ -29,9 +75,9 @@ type SessionService struct {
 // Centralised here so adding a column means updating ONE place.
 
 const sessionSelectColumns = `
-	session_id, user_id, content_id, timeline, version, status,
+	session_id, user_id, content_id, workspace_id, timeline, version, status,
 	source_asset_id, source_job_id, source_module, platform,
-	exported_asset_id, export_status,
+	exported_asset_id, export_status, export_url, export_job_id,
 	created_at, updated_at
 `
 
@@ -41,12 +87,13 @@ func scanSession(scanner interface{ Scan(dest ...interface{}) error }) (*models.
 	session := &models.EditorSession{}
 	var timelineJSON []byte
 	var sourceJobID, sourceModule, platform sql.NullString
-	var exportStatus sql.NullString
+	var exportStatus, exportURL sql.NullString
 
 	err := scanner.Scan(
 		&session.SessionID,
 		&session.UserID,
 		&session.ContentID,
+		&session.WorkspaceID,
 		&timelineJSON,
 		&session.Version,
 		&session.Status,
@@ -56,6 +103,8 @@ func scanSession(scanner interface{ Scan(dest ...interface{}) error }) (*models.
 		&platform,
 		&session.ExportedAssetID,
 		&exportStatus,
+		&exportURL,
+		&session.ExportJobID,
 		&session.CreatedAt,
 		&session.UpdatedAt,
 	)
@@ -75,10 +124,24 @@ func scanSession(scanner interface{ Scan(dest ...interface{}) error }) (*models.
 	if exportStatus.Valid {
 		session.ExportStatus = exportStatus.String
 	}
+	if exportURL.Valid {
+		session.ExportURL = exportURL.String
+	}
 
-	if len(timelineJSON) > 0 {
-		json.Unmarshal(timelineJSON, &session.Timeline)
+	// Older rows are upgraded to the current schema here, transparently. A
+	// row that can't be read still loads — flagged, with an empty timeline —
+	// so one bad row doesn't fail GetSession or a whole ListSessions page.
+	tl, err := timeline.Decode(timelineJSON)
+	if err != nil {
+		log.Printf("session %s: unreadable timeline: %v", session.SessionID, err)
+		session.TimelineError = err.Error()
+		session.RawTimeline = timelineJSON
+		if !json.Valid(timelineJSON) {
+			session.RawTimeline, _ = json.Marshal(string(timelineJSON))
+		}
+		tl, _ = timeline.Decode(nil)
 	}
+	session.Timeline = *tl
 
 	return session, nil
 }
@@ -97,12 +160,19 @@ func scanSession(scanner interface{ Scan(dest ...interface{}) error }) (*models.
 //   - User refreshes → SAME session returned, work is not lost
 //   - User opens on another device → SAME session returned
 //   - No more accumulating trash rows in your DB
-func (s *SessionService) FindOrCreateSession(userID, contentID uuid.UUID) (*models.EditorSession, error) {
+//
+// workspaceID is the caller's active workspace (uuid.Nil = personal); the
+// lookup and the new row are both scoped to it.
+func (s *SessionService) FindOrCreateSession(userID, workspaceID, contentID uuid.UUID) (*models.EditorSession, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
 	// Step 1: Look for an existing active session for this user + content
-	existing, err := s.findExistingSession(ctx, userID, contentID)
+	existing, err := s.findExistingSession(ctx, userID, workspaceID, contentID)
 	if err == nil {
 		// Found one — return it, do not create a new row
 		return existing, nil
@@ -113,7 +183,10 @@ func (s *SessionService) FindOrCreateSession(userID, contentID uuid.UUID) (*mode
 	}
 
 	// Step 2: No existing session found — create a new one
-	return s.createSession(ctx, userID, contentID)
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+	return s.createSession(ctx, userID, workspaceID, contentID)
 }
 
 // ============================================================================
@@ -128,14 +201,18 @@ func (s *SessionService) FindOrCreateSession(userID, contentID uuid.UUID) (*mode
 // contentID — this enables the "click Edit twice on same clip → same session"
 // behaviour via findExistingSession.
 func (s *SessionService) FindOrCreateSessionWithSource(
-	userID, contentID uuid.UUID,
+	userID, workspaceID, contentID uuid.UUID,
 	sourceAssetID, sourceJobID, sourceModule, platform string,
 ) (*models.EditorSession, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
 	// Step 1: Reuse existing session (same logic as FindOrCreateSession)
-	existing, err := s.findExistingSession(ctx, userID, contentID)
+	existing, err := s.findExistingSession(ctx, userID, workspaceID, contentID)
 	if err == nil {
 		return existing, nil
 	}
@@ -144,7 +221,10 @@ func (s *SessionService) FindOrCreateSessionWithSource(
 	}
 
 	// Step 2: Create new session WITH source context
-	return s.createSessionWithSource(ctx, userID, contentID,
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+	return s.createSessionWithSource(ctx, userID, workspaceID, contentID,
 		sourceAssetID, sourceJobID, sourceModule, platform)
 }
 
@@ -152,16 +232,17 @@ func (s *SessionService) FindOrCreateSessionWithSource(
 // INTERNAL — FIND
 // ============================================================================
 
-func (s *SessionService) findExistingSession(ctx context.Context, userID, contentID uuid.UUID) (*models.EditorSession, error) {
+func (s *SessionService) findExistingSession(ctx context.Context, userID, workspaceID, contentID uuid.UUID) (*models.EditorSession, error) {
 	query := `
 		SELECT ` + sessionSelectColumns + `
 		FROM editor_sessions
 		WHERE user_id = $1 AND content_id = $2
+		  AND workspace_id IS NOT DISTINCT FROM $3::uuid
 		ORDER BY created_at DESC
 		LIMIT 1
 	`
 
-	row := s.DB.QueryRowContext(ctx, query, userID, contentID)
+	row := s.DB.QueryRowContext(ctx, query, userID, contentID, workspaceArg(workspaceID))
 	session, err := scanSession(row)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrSessionNotFound
@@ -173,13 +254,13 @@ func (s *SessionService) findExistingSession(ctx context.Context, userID, conten
 // INTERNAL — CREATE (original, no source context)
 // ============================================================================
 
-func (s *SessionService) createSession(ctx context.Context, userID, contentID uuid.UUID) (*models.EditorSession, error) {
+func (s *SessionService) createSession(ctx context.Context, userID, workspaceID, contentID uuid.UUID) (*models.EditorSession, error) {
 	query := `
-		INSERT INTO editor_sessions (user_id, content_id)
-		VALUES ($1, $2)
+		INSERT INTO editor_sessions (user_id, content_id, workspace_id)
+		VALUES ($1, $2, $3)
 		RETURNING ` + sessionSelectColumns
 
-	row := s.DB.QueryRowContext(ctx, query, userID, contentID)
+	row := s.DB.QueryRowContext(ctx, query, userID, contentID, workspaceArg(workspaceID))
 	session, err := scanSession(row)
 	if err != nil {
 		return nil, err
@@ -195,7 +276,7 @@ func (s *SessionService) createSession(ctx context.Context, userID, contentID uu
 // ============================================================================
 
 func (s *SessionService) createSessionWithSource(
-	ctx context.Context, userID, contentID uuid.UUID,
+	ctx context.Context, userID, workspaceID, contentID uuid.UUID,
 	sourceAssetID, sourceJobID, sourceModule, platform string,
 ) (*models.EditorSession, error) {
 
@@ -210,14 +291,14 @@ func (s *SessionService) createSessionWithSource(
 
 	query := `
 		INSERT INTO editor_sessions (
-			user_id, content_id,
+			user_id, content_id, workspace_id,
 			source_asset_id, source_job_id, source_module, platform
 		)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING ` + sessionSelectColumns
 
 	row := s.DB.QueryRowContext(ctx, query,
-		userID, contentID,
+		userID, contentID, workspaceArg(workspaceID),
 		srcAssetUUID, sourceJobID, sourceModule, platform,
 	)
 
@@ -235,13 +316,30 @@ func (s *SessionService) createSessionWithSource(
 // GET SESSION — Updated to scan new columns
 // ============================================================================
 
-// GetSession fetches a session and verifies ownership.
-// Passing userID ensures one user cannot read another user's session.
-// When gateway is wired up, this is the enforcement layer.
-func (s *SessionService) GetSession(id, userID uuid.UUID) (*models.EditorSession, error) {
+// GetSession fetches a session the user can at least view (owner, any member
+// role, or a member of its workspace) and reports that role on session.Role.
+// Sessions outside workspaceID answer ErrSessionNotFound.
+// This is the enforcement layer — handlers never check access themselves.
+func (s *SessionService) GetSession(id, userID, workspaceID uuid.UUID) (*models.EditorSession, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	role, err := authorize(ctx, s.DB, id, userID, workspaceID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.getSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	session.Role = role
+
+	return session, nil
+}
+
+// getSession loads a session by ID with NO ownership check — internal use only.
+func (s *SessionService) getSession(ctx context.Context, id uuid.UUID) (*models.EditorSession, error) {
 	query := `
 		SELECT ` + sessionSelectColumns + `
 		FROM editor_sessions
@@ -253,64 +351,270 @@ func (s *SessionService) GetSession(id, userID uuid.UUID) (*models.EditorSession
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrSessionNotFound
 	}
+	return session, err
+}
+
+// ============================================================================
+// SAVE SESSION — optimistic concurrency on the version column
+// ============================================================================
+
+// SaveSession persists timeline JSON, bumps the version counter and records
+// the new version in editor_session_versions — both in one transaction.
+//
+// expectedVersion is the version the client last loaded. The UPDATE only
+// applies while the row still has that version — if another tab saved in
+// between, nothing is written and a *VersionConflictError carrying the
+// current server state is returned. expectedVersion 0 skips the check
+// (server-side writers that own the whole timeline, e.g. create-from-clip).
+//
+// The timeline is validated first; a *validation.TimelineError lists every
+// offending field. userID needs the editor role (ErrUnauthorized /
+// ErrInsufficientRole otherwise) and is recorded on the version; label is
+// optional. Returns the new version.
+func (s *SessionService) SaveSession(id, userID, workspaceID uuid.UUID, tl *models.Timeline, expectedVersion int, label string) (int, error) {
+	if err := validation.ValidateTimeline(tl); err != nil {
+		return 0, err
+	}
+	tl.SchemaVersion = timeline.CurrentSchemaVersion
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	timelineJSON, err := json.Marshal(tl)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	version, publish, err := s.saveTimeline(ctx, tx, id, userID, workspaceID, tl, timelineJSON, expectedVersion, label)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	publish()
+	return version, nil
+}
+
+// saveTimeline is SaveSession's write, in the caller's transaction: the
+// versioned UPDATE, version history, undo step and afterTimelineWrite. tl must
+// already be validated and stamped. The returned func publishes the events —
+// call it after commit. On a version conflict tx is rolled back.
+func (s *SessionService) saveTimeline(
+	ctx context.Context, tx *sql.Tx,
+	id, userID, workspaceID uuid.UUID,
+	tl *models.Timeline, timelineJSON []byte,
+	expectedVersion int, label string,
+) (int, func(), error) {
+	query := `
+		UPDATE editor_sessions
+		SET timeline   = $1,
+		    version    = version + 1,
+		    status     = CASE WHEN status = 'approved' THEN 'in_review' ELSE status END,
+		    updated_at = NOW()
+		WHERE session_id = $2
+		  AND ` + canEditSQL("$2", "$3", "$5") + `
+		  AND ($4 = 0 OR version = $4)
+		RETURNING version, status,
+		          (SELECT status FROM editor_sessions WHERE session_id = $2),  -- pre-update snapshot
+		          (SELECT timeline FROM editor_sessions WHERE session_id = $2)
+	`
+
+	var version int
+	var status, previousStatus string
+	var previousTimeline []byte
+	err := tx.QueryRowContext(ctx, query, timelineJSON, id, userID, expectedVersion, workspaceArg(workspaceID)).Scan(&version, &status, &previousStatus, &previousTimeline)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Gone, not allowed, or the version moved on — find out which
+		tx.Rollback()
+		if _, authErr := authorize(ctx, s.DB, id, userID, workspaceID, models.RoleEditor); authErr != nil {
+			return 0, nil, authErr
+		}
+		current, getErr := s.getSession(ctx, id)
+		if getErr != nil {
+			return 0, nil, getErr
+		}
+		return 0, nil, &VersionConflictError{Current: current}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.recordVersion(ctx, tx, id, version, timelineJSON, userID, label); err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.pushUndo(ctx, tx, id, userID, version-1, previousTimeline); err != nil {
+		return 0, nil, err
+	}
+
+	publish, err := s.afterTimelineWrite(ctx, tx, id, userID, version, previousStatus, status, tl)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return version, func() {
+		// Other tabs / devices learn about the new version over SSE
+		s.Events.Publish(id, events.TypeSessionSaved, map[string]interface{}{"version": version})
+		publish()
+	}, nil
+}
+
+// afterTimelineWrite is the bookkeeping every timeline write shares, run in
+// the writer's transaction. previousStatus / status are the session status
+// before and after the write. The returned func publishes the resulting
+// events — call it after commit.
+func (s *SessionService) afterTimelineWrite(
+	ctx context.Context, tx *sql.Tx,
+	id, userID uuid.UUID, version int,
+	previousStatus, status string, tl *models.Timeline,
+) (func(), error) {
+	// Comments outlive the clips they point at — flag them instead
+	flagged, err := flagCommentClips(ctx, tx, id, tl)
 	if err != nil {
 		return nil, err
 	}
 
-	// Ownership check — session must belong to the requesting user
-	if session.UserID != userID {
-		return nil, ErrUnauthorized
+	// Editing an approved cut sends it back for review
+	var transition *models.SessionTransition
+	if status != previousStatus {
+		transition, err = recordTransition(ctx, tx, id, previousStatus, status, &userID, version, "edited after approval")
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return session, nil
+	return func() {
+		if flagged > 0 {
+			s.Events.Publish(id, events.TypeCommentsChanged, map[string]interface{}{"action": CommentActionClipsChanged})
+		}
+		if transition != nil {
+			s.publishStatusChanged(transition)
+		}
+	}, nil
 }
 
 // ============================================================================
-// SAVE SESSION — Unchanged logic, same as before
+// EXPORT STATUS — driven by the render pipeline
 // ============================================================================
 
-// SaveSession persists timeline JSON and bumps the version counter.
-// version acts as a basic audit trail — you can see how many times a session was saved.
-func (s *SessionService) SaveSession(id uuid.UUID, timeline map[string]interface{}) error {
+// Export pipeline states stored in editor_sessions.export_status.
+const (
+	ExportStatusQueued    = "queued"
+	ExportStatusRendering = "rendering"
+	ExportStatusUploading = "uploading"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+	ExportStatusCancelled = "cancelled"
+)
+
+// UpdateExportStatus records where a session is in the export pipeline.
+// exportURL is only written when non-empty so intermediate states keep the
+// previous successful export visible until the new one lands. userID is the
+// user the export runs for (the job's user_id) and needs the editor role;
+// workspaceID is the job's workspace.
+func (s *SessionService) UpdateExportStatus(id, userID, workspaceID uuid.UUID, status, exportURL string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	timelineJSON, err := json.Marshal(timeline)
+	query := `
+		UPDATE editor_sessions
+		SET export_status = $1,
+		    export_url    = COALESCE(NULLIF($2, ''), export_url),
+		    updated_at    = NOW()
+		WHERE session_id = $3 AND ` + canEditSQL("$3", "$4", "$5") + `
+	`
+
+	result, err := s.DB.ExecContext(ctx, query, status, exportURL, id, userID, workspaceArg(workspaceID))
 	if err != nil {
 		return err
 	}
 
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		_, err := authorize(ctx, s.DB, id, userID, workspaceID, models.RoleEditor)
+		return err
+	}
+
+	s.Events.Publish(id, events.TypeExportStatus, map[string]interface{}{
+		"status":     status,
+		"export_url": exportURL,
+	})
+
+	// An approved session that has gone out is "exported"
+	if status == ExportStatusCompleted {
+		return s.markExported(ctx, id)
+	}
+	return nil
+}
+
+// MarkExportQueued points the session at its newest export job.
+func (s *SessionService) MarkExportQueued(id, userID, workspaceID, jobID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
 	query := `
 		UPDATE editor_sessions
-		SET timeline   = $1,
-		    version    = version + 1,
-		    updated_at = NOW()
-		WHERE session_id = $2
+		SET export_status = $1,
+		    export_job_id = $2,
+		    updated_at    = NOW()
+		WHERE session_id = $3 AND ` + canEditSQL("$3", "$4", "$5") + `
 	`
 
-	result, err := s.DB.ExecContext(ctx, query, timelineJSON, id)
+	result, err := s.DB.ExecContext(ctx, query, ExportStatusQueued, jobID, id, userID, workspaceArg(workspaceID))
 	if err != nil {
 		return err
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return ErrSessionNotFound
+		_, err := authorize(ctx, s.DB, id, userID, workspaceID, models.RoleEditor)
+		return err
 	}
 
+	s.Events.Publish(id, events.TypeExportStatus, map[string]interface{}{
+		"status": ExportStatusQueued,
+		"job_id": jobID,
+	})
+
 	return nil
 }
 
 // ============================================================================
-// DELETE SESSION — Unchanged
+// DELETE SESSION — owner only
 // ============================================================================
 
-// DeleteSession permanently removes a session.
-func (s *SessionService) DeleteSession(id uuid.UUID) error {
+// DeleteSession permanently removes a session owned by userID. Members,
+// even editors, get ErrInsufficientRole.
+func (s *SessionService) DeleteSession(id, userID, workspaceID uuid.UUID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := s.DB.ExecContext(ctx,
-		`DELETE FROM editor_sessions WHERE session_id = $1`, id)
-	return err
+	// Inside a workspace the owner must still belong to it
+	result, err := s.DB.ExecContext(ctx, `
+		DELETE FROM editor_sessions
+		WHERE session_id = $1 AND user_id = $2
+		  AND workspace_id IS NOT DISTINCT FROM $3::uuid
+		  AND ($3::uuid IS NULL OR EXISTS (
+			SELECT 1 FROM workspace_members w
+			WHERE w.workspace_id = $3::uuid AND w.user_id = $2))
+	`, id, userID, workspaceArg(workspaceID))
+	if err != nil {
+		return err
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		_, err := authorize(ctx, s.DB, id, userID, workspaceID, models.RoleOwner)
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,345 @@
+// internal/service/share_service.go
+package service
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrShareNotFound          = errors.New("share link not found")
+	ErrShareExpired           = errors.New("share link has expired")
+	ErrSharePasswordRequired  = errors.New("share link requires a password")
+	ErrSharePasswordWrong     = errors.New("wrong share link password")
+	ErrInvalidSharePermission = errors.New("permission must be view or comment")
+	ErrInvalidShareExpiry     = errors.New("expires_at must be in the future")
+	ErrShareThrottled         = errors.New("too many wrong passwords — try again later")
+)
+
+// Access log outcomes (editor_share_access_log.outcome)
+const (
+	ShareOutcomeOK               = "ok"
+	ShareOutcomeExpired          = "expired"
+	ShareOutcomeRevoked          = "revoked"
+	ShareOutcomePasswordRequired = "password_required"
+	ShareOutcomeWrongPassword    = "wrong_password"
+)
+
+const sharePasswordIterations = 210000
+
+const (
+	defaultShareMaxFailures = 10
+	defaultShareLockout     = 15 * time.Minute
+)
+
+// ShareOptions configures a new share link. Zero values: view-only, never
+// expires, no password.
+type ShareOptions struct {
+	Permission string
+	ExpiresAt  *time.Time
+	Password   string
+}
+
+// ShareAccess describes who opened a link, for the access log.
+type ShareAccess struct {
+	IP        string
+	UserAgent string
+}
+
+// ============================================================================
+// SHARE LINKS — managed by editors, opened by anyone holding the token
+// ============================================================================
+
+// CreateShareLink issues a new public link to the session. Needs the editor
+// role. The returned link carries the token; it is never shown again.
+func (s *SessionService) CreateShareLink(sessionID, userID, workspaceID uuid.UUID, opts ShareOptions) (*models.ShareLink, error) {
+	if opts.Permission == "" {
+		opts.Permission = models.SharePermissionView
+	}
+	if opts.Permission != models.SharePermissionView && opts.Permission != models.SharePermissionComment {
+		return nil, ErrInvalidSharePermission
+	}
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidShareExpiry
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	var passwordHash *string
+	if opts.Password != "" {
+		hashed, err := hashSharePassword(opts.Password)
+		if err != nil {
+			return nil, err
+		}
+		passwordHash = &hashed
+	}
+
+	link := &models.ShareLink{
+		SessionID:   sessionID,
+		Token:       token,
+		Permission:  opts.Permission,
+		HasPassword: passwordHash != nil,
+		ExpiresAt:   opts.ExpiresAt,
+		CreatedBy:   userID,
+	}
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO editor_share_links (session_id, token_hash, permission, password_hash, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING share_id, created_at
+	`, sessionID, shareTokenHash(token), opts.Permission, passwordHash, opts.ExpiresAt, userID).
+		Scan(&link.ShareID, &link.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	return link, nil
+}
+
+// ListShareLinks returns the session's links, newest first, without tokens.
+// Needs the editor role.
+func (s *SessionService) ListShareLinks(sessionID, userID, workspaceID uuid.UUID) ([]models.ShareLink, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT share_id, session_id, permission, password_hash IS NOT NULL,
+		       expires_at, created_by, created_at, revoked_at
+		FROM editor_share_links
+		WHERE session_id = $1
+		ORDER BY created_at DESC
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.ShareLink{}
+	for rows.Next() {
+		var l models.ShareLink
+		if err := rows.Scan(&l.ShareID, &l.SessionID, &l.Permission, &l.HasPassword,
+			&l.ExpiresAt, &l.CreatedBy, &l.CreatedAt, &l.RevokedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// RevokeShareLink disables a link immediately. Needs the editor role.
+func (s *SessionService) RevokeShareLink(sessionID, userID, workspaceID, shareID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleEditor); err != nil {
+		return err
+	}
+
+	result, err := s.DB.ExecContext(ctx, `
+		UPDATE editor_share_links
+		SET revoked_at = NOW()
+		WHERE share_id = $1 AND session_id = $2 AND revoked_at IS NULL
+	`, shareID, sessionID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+// shareGrant is what a valid token opens.
+type shareGrant struct {
+	ShareID    uuid.UUID
+	SessionID  uuid.UUID
+	Permission string
+}
+
+// OpenShareLink resolves a public token to the session it shares.
+//
+// This is read-only by construction: a token is not an identity, so nothing
+// it opens can reach SaveSession.
+func (s *SessionService) OpenShareLink(token, password string, access ShareAccess) (*models.SharedSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	grant, err := s.openShareLink(ctx, token, password, access)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.getSession(ctx, grant.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SharedSession{
+		SessionID:  session.SessionID,
+		Permission: grant.Permission,
+		Version:    session.Version,
+		Timeline:   session.Timeline,
+		UpdatedAt:  session.UpdatedAt,
+	}, nil
+}
+
+// openShareLink checks a token (and password). Every attempt on an existing
+// link is written to editor_share_access_log, refused or not. A revoked link
+// answers ErrShareNotFound, like an unknown token.
+//
+// ShareMaxFailures wrong passwords in a row lock the link for ShareLockout:
+// while locked it answers ErrShareThrottled without hashing the password or
+// logging the attempt, so guessing costs the database almost nothing.
+func (s *SessionService) openShareLink(ctx context.Context, token, password string, access ShareAccess) (*shareGrant, error) {
+	var grant shareGrant
+	var passwordHash sql.NullString
+	var expiresAt, revokedAt, lockedUntil *time.Time
+	var failedAttempts int
+
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT share_id, session_id, permission, password_hash, expires_at, revoked_at,
+		       failed_attempts, locked_until
+		FROM editor_share_links
+		WHERE token_hash = $1
+	`, shareTokenHash(token)).Scan(&grant.ShareID, &grant.SessionID, &grant.Permission, &passwordHash, &expiresAt, &revokedAt,
+		&failedAttempts, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	outcome, refusal := ShareOutcomeOK, error(nil)
+	switch {
+	case revokedAt != nil:
+		outcome, refusal = ShareOutcomeRevoked, ErrShareNotFound
+	case expiresAt != nil && !expiresAt.After(time.Now()):
+		outcome, refusal = ShareOutcomeExpired, ErrShareExpired
+	case passwordHash.Valid && password == "":
+		outcome, refusal = ShareOutcomePasswordRequired, ErrSharePasswordRequired
+	case passwordHash.Valid && lockedUntil != nil && lockedUntil.After(time.Now()):
+		return nil, ErrShareThrottled
+	case passwordHash.Valid && !checkSharePassword(passwordHash.String, password):
+		outcome, refusal = ShareOutcomeWrongPassword, ErrSharePasswordWrong
+		if err := s.recordSharePasswordFailure(ctx, grant.ShareID); err != nil {
+			return nil, err
+		}
+	case passwordHash.Valid && failedAttempts > 0:
+		// The right password clears the count
+		if _, err := s.DB.ExecContext(ctx, `
+			UPDATE editor_share_links SET failed_attempts = 0 WHERE share_id = $1
+		`, grant.ShareID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.DB.ExecContext(ctx, `
+		INSERT INTO editor_share_access_log (share_id, session_id, outcome, ip, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+	`, grant.ShareID, grant.SessionID, outcome, access.IP, access.UserAgent); err != nil {
+		return nil, fmt.Errorf("failed to log share access: %w", err)
+	}
+	if refusal != nil {
+		return nil, refusal
+	}
+	return &grant, nil
+}
+
+// recordSharePasswordFailure counts a wrong password. The one that reaches
+// ShareMaxFailures locks the link and starts the count again.
+func (s *SessionService) recordSharePasswordFailure(ctx context.Context, shareID uuid.UUID) error {
+	maxFailures := s.ShareMaxFailures
+	if maxFailures <= 0 {
+		maxFailures = defaultShareMaxFailures
+	}
+	lockout := s.ShareLockout
+	if lockout <= 0 {
+		lockout = defaultShareLockout
+	}
+
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE editor_share_links
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+		    locked_until    = CASE WHEN failed_attempts + 1 >= $2
+		                           THEN NOW() + $3 * INTERVAL '1 second'
+		                           ELSE locked_until END
+		WHERE share_id = $1
+	`, shareID, maxFailures, int64(lockout/time.Second))
+	if err != nil {
+		return fmt.Errorf("failed to record share password failure: %w", err)
+	}
+	return nil
+}
+
+func shareTokenHash(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// hashSharePassword → "pbkdf2-sha256$<iterations>$<salt>$<hash>" (base64url)
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordIterations, 32)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s",
+		sharePasswordIterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+func checkSharePassword(stored, password string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	enc := base64.RawURLEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
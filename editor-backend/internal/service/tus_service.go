@@ -0,0 +1,437 @@
+// internal/service/tus_service.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/storage"
+	"editor-backend/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTusExpiry = 24 * time.Hour
+
+	// tusPartSize is how much of a PATCH body is buffered before it is
+	// stored as a part — also the most a dropped connection can lose.
+	tusPartSize = 8 << 20
+
+	// Abandoned uploads are purged this long after they expire, so a
+	// presigned upload finished just before expiry can still complete
+	uploadPurgeGrace = time.Hour
+)
+
+var (
+	ErrUploadExpired = errors.New("upload expired — start a new one")
+	ErrUploadOffset  = errors.New("upload offset does not match the bytes received")
+)
+
+// ============================================================================
+// RESUMABLE UPLOADS — tus 1.0
+// ============================================================================
+//
+// A tus upload is an editor_uploads row with protocol "tus". Each PATCH body
+// is stored in tusPartSize parts — objects in storage.Storage, listed in
+// editor_upload_parts — so any API pod can take the next chunk and a dropped
+// connection loses at most one part. The chunk that reaches Upload-Length
+// joins the parts into the final object, records the asset and deletes the
+// parts.
+
+// CreateTusUpload starts a resumable upload of length bytes in the caller's
+// active workspace, after the same checks as POST /upload. Uploading needs
+// the workspace member role.
+func (s *UploadService) CreateTusUpload(userID, workspaceID uuid.UUID, filename, contentType string, length int64) (*models.Upload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateUploadInfo(filename, contentType, length); err != nil {
+		return nil, err
+	}
+	contentType = validation.UploadContentType(filename, contentType)
+
+	expiry := s.TusExpiry
+	if expiry <= 0 {
+		expiry = defaultTusExpiry
+	}
+
+	upload, err := scanUpload(s.DB.QueryRowContext(ctx, `
+		INSERT INTO editor_uploads
+			(workspace_id, user_id, storage_key, filename, content_type, size_bytes, protocol, expires_at)
+		VALUES ($1, $2, '', $3, $4, $5, $6, $7)
+		RETURNING `+uploadColumns,
+		workspaceArg(workspaceID), userID, filename, contentType, length,
+		models.UploadProtocolTus, time.Now().Add(expiry)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	return upload, nil
+}
+
+// GetTusUpload returns one of the caller's tus uploads — its Offset is where
+// the client resumes. An unfinished upload past its expiry is ErrUploadExpired.
+func (s *UploadService) GetTusUpload(uploadID, userID uuid.UUID) (*models.Upload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upload, err := s.getUpload(ctx, s.DB, uploadID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Protocol != models.UploadProtocolTus {
+		return nil, ErrUploadNotFound
+	}
+	if upload.Status == models.UploadStatusPending && time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadExpired
+	}
+	return upload, nil
+}
+
+// AppendTusUpload stores body at offset, which must be the upload's current
+// Offset (ErrUploadOffset otherwise). Whatever arrives before body fails is
+// kept, so the client resumes from the returned Offset. Reaching the
+// upload's length completes it: the file is validated again, joined,
+// recorded as an asset, and the returned upload has status "completed".
+//
+// An empty body at the full length retries a completion that failed.
+func (s *UploadService) AppendTusUpload(uploadID, userID uuid.UUID, offset int64, body io.Reader) (*models.Upload, error) {
+	upload, err := s.GetTusUpload(uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch upload.Status {
+	case models.UploadStatusCompleted:
+		if offset != upload.Offset {
+			return nil, ErrUploadOffset
+		}
+		return upload, nil
+	case models.UploadStatusFailed:
+		return nil, ErrUploadFailed
+	}
+	if offset != upload.Offset {
+		return nil, ErrUploadOffset
+	}
+
+	buf := make([]byte, min(tusPartSize, upload.SizeBytes-upload.Offset))
+	for upload.Offset < upload.SizeBytes {
+		want := min(int64(len(buf)), upload.SizeBytes-upload.Offset)
+		n, readErr := io.ReadFull(body, buf[:want])
+		if n > 0 {
+			if err := s.storeTusPart(upload, buf[:n]); err != nil {
+				return nil, err
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return upload, fmt.Errorf("upload interrupted at offset %d: %w", upload.Offset, readErr)
+		}
+	}
+
+	if upload.Offset < upload.SizeBytes {
+		return upload, nil
+	}
+	return s.finishTusUpload(upload)
+}
+
+// storeTusPart writes data as the part at upload.Offset and advances it.
+// The offset only moves if nobody else moved it first.
+func (s *UploadService) storeTusPart(upload *models.Upload, data []byte) error {
+	object, err := s.Storage.Upload(bytes.NewReader(data), int64(len(data)), "part.bin", "application/octet-stream")
+	if err != nil {
+		return fmt.Errorf("failed to store chunk: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.deleteObject(object.Key)
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE editor_uploads SET upload_offset = upload_offset + $3
+		WHERE upload_id = $1 AND upload_offset = $2 AND status = $4
+	`, upload.UploadID, upload.Offset, len(data), models.UploadStatusPending)
+	if err == nil {
+		var n int64
+		if n, err = res.RowsAffected(); err == nil && n == 0 {
+			// A concurrent PATCH got there first
+			err = ErrUploadOffset
+		}
+	}
+	if err == nil {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO editor_upload_parts (upload_id, part_offset, size_bytes, storage_key)
+			VALUES ($1, $2, $3, $4)
+		`, upload.UploadID, upload.Offset, len(data), object.Key)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		s.deleteObject(object.Key)
+		return err
+	}
+
+	upload.Offset += int64(len(data))
+	return nil
+}
+
+// finishTusUpload joins the parts into the final object and records it.
+// A file that fails validation fails the upload; a storage or database
+// error leaves it pending at full length, so the client can retry.
+func (s *UploadService) finishTusUpload(upload *models.Upload) (*models.Upload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := validation.ValidateUploadInfo(upload.Filename, upload.ContentType, upload.Offset); err != nil {
+		if _, dbErr := s.DB.ExecContext(ctx, `
+			UPDATE editor_uploads SET status = $2, completed_at = NOW()
+			WHERE upload_id = $1 AND status = $3
+		`, upload.UploadID, models.UploadStatusFailed, models.UploadStatusPending); dbErr != nil {
+			return nil, dbErr
+		}
+		s.deleteParts(upload.UploadID)
+		return nil, err
+	}
+	if err := requireWorkspaceRole(ctx, s.DB, WorkspaceOf(upload.WorkspaceID), upload.UserID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.partKeys(ctx, upload.UploadID)
+	if err != nil {
+		return nil, err
+	}
+	cancel()
+
+	// Joining streams every byte once more — outside the 5s deadline
+	parts := &partsReader{storage: s.Storage, keys: keys}
+	object, err := s.Storage.Upload(parts, upload.SizeBytes, upload.Filename, upload.ContentType)
+	parts.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to assemble upload: %w", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		s.deleteObject(object.Key)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	asset, err := insertAsset(ctx, tx, &models.Asset{
+		WorkspaceID: upload.WorkspaceID,
+		UserID:      upload.UserID,
+		FileURL:     object.URL,
+		Filename:    upload.Filename,
+		ContentType: upload.ContentType,
+		SizeBytes:   object.Size,
+	})
+	if err == nil {
+		var finished *models.Upload
+		finished, err = scanUpload(tx.QueryRowContext(ctx, `
+			UPDATE editor_uploads
+			SET status = $2, storage_key = $3, asset_id = $4, completed_at = NOW()
+			WHERE upload_id = $1 AND status = $5
+			RETURNING `+uploadColumns,
+			upload.UploadID, models.UploadStatusCompleted, object.Key, asset.AssetID, models.UploadStatusPending))
+		if err == nil {
+			upload = finished
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		s.deleteObject(object.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			// A concurrent request finished it first
+			tx.Rollback()
+			return s.GetTusUpload(upload.UploadID, upload.UserID)
+		}
+		return nil, err
+	}
+
+	s.deleteParts(upload.UploadID)
+	return upload, nil
+}
+
+// TerminateTusUpload discards an upload and its stored chunks. The asset of
+// a completed upload is kept.
+func (s *UploadService) TerminateTusUpload(uploadID, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upload, err := s.getUpload(ctx, s.DB, uploadID, userID, false)
+	if err != nil {
+		return err
+	}
+	if upload.Protocol != models.UploadProtocolTus {
+		return ErrUploadNotFound
+	}
+
+	s.deleteParts(uploadID)
+	_, err = s.DB.ExecContext(ctx, `DELETE FROM editor_uploads WHERE upload_id = $1`, uploadID)
+	return err
+}
+
+// PurgeExpiredUploads deletes unfinished uploads of either protocol that
+// expired more than an hour ago, with whatever they stored. Called by
+// cmd/worker; returns how many it removed.
+func (s *UploadService) PurgeExpiredUploads(ctx context.Context) (int, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT upload_id, protocol, storage_key
+		FROM editor_uploads
+		WHERE status = $1 AND expires_at < $2
+		ORDER BY expires_at
+		LIMIT 100
+	`, models.UploadStatusPending, time.Now().Add(-uploadPurgeGrace))
+	if err != nil {
+		return 0, err
+	}
+
+	type expired struct {
+		id       uuid.UUID
+		protocol string
+		key      string
+	}
+	var uploads []expired
+	for rows.Next() {
+		var e expired
+		if err := rows.Scan(&e.id, &e.protocol, &e.key); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		uploads = append(uploads, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, e := range uploads {
+		if e.protocol == models.UploadProtocolTus {
+			s.deleteParts(e.id)
+		} else {
+			// Uploaded but never completed
+			s.deleteObject(e.key)
+		}
+		if _, err := s.DB.ExecContext(ctx, `
+			DELETE FROM editor_uploads WHERE upload_id = $1 AND status = $2
+		`, e.id, models.UploadStatusPending); err != nil {
+			return 0, err
+		}
+	}
+	return len(uploads), nil
+}
+
+func (s *UploadService) partKeys(ctx context.Context, uploadID uuid.UUID) ([]string, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT storage_key FROM editor_upload_parts
+		WHERE upload_id = $1
+		ORDER BY part_offset
+	`, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// deleteParts removes an upload's chunks from storage, then their rows.
+// Best effort — a leftover part only costs storage.
+func (s *UploadService) deleteParts(uploadID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys, err := s.partKeys(ctx, uploadID)
+	if err != nil {
+		log.Printf("Upload %s: failed to list parts: %v", uploadID, err)
+		return
+	}
+	for _, key := range keys {
+		s.deleteObject(key)
+	}
+	if _, err := s.DB.ExecContext(ctx, `DELETE FROM editor_upload_parts WHERE upload_id = $1`, uploadID); err != nil {
+		log.Printf("Upload %s: failed to delete parts: %v", uploadID, err)
+	}
+}
+
+func (s *UploadService) deleteObject(key string) {
+	if key == "" {
+		return
+	}
+	if err := s.Storage.Delete(key); err != nil {
+		log.Printf("Upload: failed to delete object %s: %v", key, err)
+	}
+}
+
+// partsReader reads the parts back to back, opening each only when the
+// previous one is done.
+type partsReader struct {
+	storage storage.Storage
+	keys    []string
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, _, err := r.storage.Open(r.keys[0], 0, 0)
+			if err != nil {
+				return 0, fmt.Errorf("part %s: %w", r.keys[0], err)
+			}
+			r.current, r.keys = rc, r.keys[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
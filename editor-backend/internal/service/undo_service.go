@@ -0,0 +1,261 @@
+// internal/service/undo_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/events"
+	"editor-backend/internal/models"
+	"editor-backend/internal/timeline"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+const defaultUndoDepth = 50
+
+// Undo stacks in editor_session_undo.stack.
+const (
+	stackUndo = "undo"
+	stackRedo = "redo"
+)
+
+// ============================================================================
+// UNDO / REDO — per session, shared by every editor and device
+// ============================================================================
+//
+// Every timeline write (SaveSession, ApplyOperations, RestoreVersion) pushes
+// the timeline it replaced onto the undo stack and clears the redo stack.
+// Undo writes the top undo entry back as a new version — history stays
+// append-only — and moves the timeline it replaced onto the redo stack; redo
+// is the mirror image. The stacks live in the database, so undo survives a
+// reload and works from another device.
+
+// UndoResult is the session after an undo or redo.
+type UndoResult struct {
+	Version   int              `json:"version"`
+	Timeline  *models.Timeline `json:"timeline"`
+	UndoDepth int              `json:"undo_depth"`
+	RedoDepth int              `json:"redo_depth"`
+}
+
+// UndoState is how many steps each stack holds.
+type UndoState struct {
+	UndoDepth int `json:"undo_depth"`
+	RedoDepth int `json:"redo_depth"`
+}
+
+// Undo reverts the session's most recent timeline write, whoever made it.
+// Needs the editor role. expectedVersion works as in SaveSession (0 = no
+// check) — pass it so a client never undoes an edit it hasn't seen yet. A
+// step whose stored timeline can't be read is ErrTimelineUnreadable.
+func (s *SessionService) Undo(sessionID, userID, workspaceID uuid.UUID, expectedVersion int) (*UndoResult, error) {
+	return s.popUndo(sessionID, userID, workspaceID, expectedVersion, stackUndo, stackRedo)
+}
+
+// Redo re-applies the most recently undone write. Any other write in between
+// clears the redo stack (ErrNothingToRedo).
+func (s *SessionService) Redo(sessionID, userID, workspaceID uuid.UUID, expectedVersion int) (*UndoResult, error) {
+	return s.popUndo(sessionID, userID, workspaceID, expectedVersion, stackRedo, stackUndo)
+}
+
+// GetUndoState reports the stack depths, so a freshly loaded editor can
+// enable its undo / redo buttons. Any role may look.
+func (s *SessionService) GetUndoState(sessionID, userID, workspaceID uuid.UUID) (*UndoState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	state := &UndoState{}
+	if err := s.DB.QueryRowContext(ctx, undoDepthSQL, sessionID).Scan(&state.UndoDepth, &state.RedoDepth); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+const undoDepthSQL = `
+	SELECT COUNT(*) FILTER (WHERE stack = 'undo'),
+	       COUNT(*) FILTER (WHERE stack = 'redo')
+	FROM editor_session_undo
+	WHERE session_id = $1
+`
+
+// popUndo takes the top entry of `from`, writes it as the new timeline and
+// pushes the replaced timeline onto `to`.
+func (s *SessionService) popUndo(sessionID, userID, workspaceID uuid.UUID, expectedVersion int, from, to string) (*UndoResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The session row lock also serializes the stacks
+	var current []byte
+	var version int
+	err = tx.QueryRowContext(ctx, `
+		SELECT timeline, version
+		FROM editor_sessions
+		WHERE session_id = $1 AND `+canEditSQL("$1", "$2", "$3")+`
+		FOR UPDATE
+	`, sessionID, userID, workspaceArg(workspaceID)).Scan(&current, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		if _, err := authorize(ctx, s.DB, sessionID, userID, workspaceID, models.RoleEditor); err != nil {
+			return nil, err
+		}
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != 0 && expectedVersion != version {
+		tx.Rollback()
+		latest, err := s.getSession(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &VersionConflictError{Current: latest}
+	}
+
+	var entryID int64
+	var raw []byte
+	err = tx.QueryRowContext(ctx, `
+		SELECT entry_id, timeline
+		FROM editor_session_undo
+		WHERE session_id = $1 AND stack = $2
+		ORDER BY entry_id DESC
+		LIMIT 1
+	`, sessionID, from).Scan(&entryID, &raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		if from == stackUndo {
+			return nil, ErrNothingToUndo
+		}
+		return nil, ErrNothingToRedo
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries may predate a schema change — upgrade like stored sessions
+	tl, err := timeline.Decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s step: %v", ErrTimelineUnreadable, from, err)
+	}
+	tl.SchemaVersion = timeline.CurrentSchemaVersion
+	timelineJSON, err := json.Marshal(tl)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UndoResult{Timeline: tl}
+	var status, previousStatus string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE editor_sessions
+		SET timeline   = $2,
+		    version    = version + 1,
+		    status     = CASE WHEN status = 'approved' THEN 'in_review' ELSE status END,
+		    updated_at = NOW()
+		WHERE session_id = $1
+		RETURNING version, status,
+		          (SELECT status FROM editor_sessions WHERE session_id = $1) -- pre-update snapshot
+	`, sessionID, timelineJSON).Scan(&result.Version, &status, &previousStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordVersion(ctx, tx, sessionID, result.Version, timelineJSON, userID, ""); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM editor_session_undo WHERE entry_id = $1
+	`, entryID); err != nil {
+		return nil, err
+	}
+	if err := s.pushStack(ctx, tx, sessionID, to, userID, version, current); err != nil {
+		return nil, err
+	}
+
+	publish, err := s.afterTimelineWrite(ctx, tx, sessionID, userID, result.Version, previousStatus, status, tl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.QueryRowContext(ctx, undoDepthSQL, sessionID).Scan(&result.UndoDepth, &result.RedoDepth); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Not an op — live editors refetch, as after any full save
+	s.Events.Publish(sessionID, events.TypeSessionSaved, map[string]interface{}{
+		"version": result.Version,
+		"action":  from,
+	})
+	publish()
+
+	return result, nil
+}
+
+// pushUndo records the timeline a write replaced (previous, at version) and
+// clears the redo stack — a new edit ends the redo branch. Runs in the
+// writer's transaction. A session that had no timeline yet has nothing to
+// go back to.
+func (s *SessionService) pushUndo(ctx context.Context, tx *sql.Tx, sessionID, userID uuid.UUID, version int, previous []byte) error {
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM editor_session_undo WHERE session_id = $1 AND stack = $2
+	`, sessionID, stackRedo); err != nil {
+		return fmt.Errorf("failed to clear redo stack: %w", err)
+	}
+	if len(previous) == 0 {
+		return nil
+	}
+	return s.pushStack(ctx, tx, sessionID, stackUndo, userID, version, previous)
+}
+
+// pushStack adds an entry to one stack and trims it to UndoDepth.
+func (s *SessionService) pushStack(ctx context.Context, tx *sql.Tx, sessionID uuid.UUID, stack string, userID uuid.UUID, version int, tl []byte) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO editor_session_undo (session_id, stack, version, timeline, user_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, sessionID, stack, version, tl, userID); err != nil {
+		return fmt.Errorf("failed to record %s step: %w", stack, err)
+	}
+
+	depth := s.UndoDepth
+	if depth <= 0 {
+		depth = defaultUndoDepth
+	}
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM editor_session_undo
+		WHERE session_id = $1 AND stack = $2
+		  AND entry_id NOT IN (
+		      SELECT entry_id FROM editor_session_undo
+		      WHERE session_id = $1 AND stack = $2
+		      ORDER BY entry_id DESC
+		      LIMIT $3
+		  )
+	`, sessionID, stack, depth)
+	if err != nil {
+		return fmt.Errorf("failed to prune %s stack: %w", stack, err)
+	}
+	return nil
+}
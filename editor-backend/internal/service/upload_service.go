@@ -0,0 +1,278 @@
+// internal/service/upload_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/storage"
+	"editor-backend/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+const defaultUploadURLExpiry = 15 * time.Minute
+
+var (
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadNotReceived = errors.New("the file has not been uploaded yet")
+	ErrUploadMismatch    = errors.New("the uploaded file does not match the upload request")
+	ErrUploadFailed      = errors.New("upload failed — start a new one")
+)
+
+// ============================================================================
+// DIRECT UPLOADS
+// ============================================================================
+//
+// Media goes straight from the browser to storage; the API only hands out
+// the URL and checks the result:
+//
+//	POST /uploads                        → slot + presigned PUT URL
+//	PUT  <upload_url>                    → S3 (or the signed local endpoint)
+//	POST /uploads/{upload_id}/complete   → object verified, asset recorded
+
+// UploadService issues direct upload slots and completes them, and runs
+// resumable tus uploads (tus_service.go).
+type UploadService struct {
+	DB      *sql.DB
+	Storage storage.Storage
+
+	// URLExpiry is how long a presigned upload URL works (default 15 minutes)
+	URLExpiry time.Duration
+	// TusExpiry is how long a resumable upload may take (default 24 hours)
+	TusExpiry time.Duration
+}
+
+// UploadSlot is a new upload and where to send the file.
+type UploadSlot struct {
+	models.Upload
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+}
+
+const uploadColumns = `
+	upload_id, workspace_id, user_id, storage_key, filename, content_type,
+	size_bytes, protocol, upload_offset, status, asset_id,
+	expires_at, created_at, completed_at
+`
+
+func scanUpload(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*models.Upload, error) {
+	u := &models.Upload{}
+	err := scanner.Scan(
+		&u.UploadID,
+		&u.WorkspaceID,
+		&u.UserID,
+		&u.StorageKey,
+		&u.Filename,
+		&u.ContentType,
+		&u.SizeBytes,
+		&u.Protocol,
+		&u.Offset,
+		&u.Status,
+		&u.AssetID,
+		&u.ExpiresAt,
+		&u.CreatedAt,
+		&u.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// CreateUpload validates the file the client is about to send — the same
+// rules as POST /upload — and returns a presigned URL for it in the
+// caller's active workspace. Uploading needs the workspace member role.
+// Validation failures are the validation.Err* upload errors.
+func (s *UploadService) CreateUpload(userID, workspaceID uuid.UUID, filename, contentType string, size int64) (*UploadSlot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateUploadInfo(filename, contentType, size); err != nil {
+		return nil, err
+	}
+	contentType = validation.UploadContentType(filename, contentType)
+
+	expiry := s.URLExpiry
+	if expiry <= 0 {
+		expiry = defaultUploadURLExpiry
+	}
+	presigned, err := s.Storage.PresignUpload(filename, contentType, size, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	upload, err := scanUpload(s.DB.QueryRowContext(ctx, `
+		INSERT INTO editor_uploads
+			(workspace_id, user_id, storage_key, filename, content_type, size_bytes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+uploadColumns,
+		workspaceArg(workspaceID), userID, presigned.Key, filename, contentType, size, presigned.ExpiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+
+	return &UploadSlot{
+		Upload:    *upload,
+		UploadURL: presigned.URL,
+		Method:    presigned.Method,
+		Headers:   presigned.Headers,
+	}, nil
+}
+
+// CompleteUpload checks that the file arrived in storage with the size the
+// slot was issued for and records it as an asset in the slot's workspace.
+// Only the user who created the upload can complete it, and they must
+// still be a workspace member. Completing twice returns the same asset.
+//
+// ErrUploadNotReceived leaves the slot open — upload, then try again. A
+// size or content type mismatch deletes the object and fails the slot
+// (ErrUploadMismatch).
+func (s *UploadService) CompleteUpload(uploadID, userID uuid.UUID) (*models.Asset, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upload, err := s.getUpload(ctx, s.DB, uploadID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	switch upload.Status {
+	case models.UploadStatusCompleted:
+		return s.getAsset(ctx, *upload.AssetID)
+	case models.UploadStatusFailed:
+		return nil, ErrUploadFailed
+	}
+	// A tus upload completes itself with its last chunk
+	if upload.Protocol == models.UploadProtocolTus {
+		return nil, ErrUploadNotReceived
+	}
+
+	if err := requireWorkspaceRole(ctx, s.DB, WorkspaceOf(upload.WorkspaceID), userID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+
+	// Storage is checked before the row is locked — it's a network call
+	object, err := s.Storage.Stat(upload.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrUploadNotReceived
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check upload: %w", err)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Re-read under the lock — a concurrent complete may have won
+	upload, err = s.getUpload(ctx, tx, uploadID, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	switch upload.Status {
+	case models.UploadStatusCompleted:
+		tx.Rollback()
+		return s.getAsset(ctx, *upload.AssetID)
+	case models.UploadStatusFailed:
+		return nil, ErrUploadFailed
+	}
+
+	if object.Size != upload.SizeBytes || !sameContentType(object.ContentType, upload.ContentType) {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE editor_uploads SET status = $2, completed_at = NOW()
+			WHERE upload_id = $1
+		`, uploadID, models.UploadStatusFailed); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		if err := s.Storage.Delete(upload.StorageKey); err != nil {
+			log.Println("CompleteUpload: failed to delete mismatched upload:", err)
+		}
+		return nil, ErrUploadMismatch
+	}
+
+	asset, err := insertAsset(ctx, tx, &models.Asset{
+		WorkspaceID: upload.WorkspaceID,
+		UserID:      userID,
+		FileURL:     object.URL,
+		Filename:    upload.Filename,
+		ContentType: upload.ContentType,
+		SizeBytes:   object.Size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE editor_uploads SET status = $2, asset_id = $3, completed_at = NOW()
+		WHERE upload_id = $1
+	`, uploadID, models.UploadStatusCompleted, asset.AssetID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// sameContentType compares the type storage recorded with the one the slot
+// was issued for, ignoring case and parameters. A store that records none
+// ("") enforced the type when the file was put.
+func sameContentType(stored, declared string) bool {
+	if stored == "" {
+		return true
+	}
+	storedType, _, err := mime.ParseMediaType(stored)
+	if err != nil {
+		return false
+	}
+	declaredType, _, err := mime.ParseMediaType(declared)
+	return err == nil && storedType == declaredType
+}
+
+// getUpload loads one of the user's uploads; forUpdate locks the row.
+func (s *UploadService) getUpload(ctx context.Context, db interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, uploadID, userID uuid.UUID, forUpdate bool) (*models.Upload, error) {
+	query := `SELECT ` + uploadColumns + ` FROM editor_uploads WHERE upload_id = $1 AND user_id = $2`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+	upload, err := scanUpload(db.QueryRowContext(ctx, query, uploadID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUploadNotFound
+	}
+	return upload, err
+}
+
+func (s *UploadService) getAsset(ctx context.Context, assetID uuid.UUID) (*models.Asset, error) {
+	var a models.Asset
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT asset_id, workspace_id, user_id, file_url, filename, content_type, size_bytes, created_at
+		FROM editor_assets
+		WHERE asset_id = $1
+	`, assetID).Scan(&a.AssetID, &a.WorkspaceID, &a.UserID, &a.FileURL,
+		&a.Filename, &a.ContentType, &a.SizeBytes, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,25 @@
+// internal/service/upload_service_test.go
+package service
+
+import "testing"
+
+func TestSameContentType(t *testing.T) {
+	cases := []struct {
+		stored, declared string
+		want             bool
+	}{
+		{"video/mp4", "video/mp4", true},
+		{"Video/MP4", "video/mp4", true},
+		{"audio/ogg; codecs=vorbis", "audio/ogg", true},
+		// Local storage records no type — LocalUpload enforced it on the PUT
+		{"", "video/mp4", true},
+		{"text/html", "video/mp4", false},
+		{"video/webm", "video/mp4", false},
+		{"not a type;;", "video/mp4", false},
+	}
+	for _, tc := range cases {
+		if got := sameContentType(tc.stored, tc.declared); got != tc.want {
+			t.Errorf("sameContentType(%q, %q) = %v, want %v", tc.stored, tc.declared, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// internal/service/version_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/timeline"
+
+	"github.com/google/uuid"
+)
+
+var ErrVersionNotFound = errors.New("session version not found")
+
+const defaultVersionRetention = 100
+
+// ============================================================================
+// RECORD — called inside SaveSession's transaction
+// ============================================================================
+
+// recordVersion stores the freshly saved timeline and prunes old history.
+func (s *SessionService) recordVersion(
+	ctx context.Context, tx *sql.Tx,
+	sessionID uuid.UUID, version int, timelineJSON []byte,
+	userID uuid.UUID, label string,
+) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO editor_session_versions (session_id, version, timeline, user_id, label)
+		VALUES ($1, $2, $3, $4, $5)
+	`, sessionID, version, timelineJSON, userID, label)
+	if err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+
+	retention := s.VersionRetention
+	if retention <= 0 {
+		retention = defaultVersionRetention
+	}
+
+	// Keep the newest N unlabelled versions; labelled ones are milestones
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM editor_session_versions
+		WHERE session_id = $1 AND label = '' AND version <= $2
+	`, sessionID, version-retention)
+	if err != nil {
+		return fmt.Errorf("failed to prune versions: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// LIST / GET
+// ============================================================================
+
+// ListVersions returns a session's history, newest first, without timelines.
+func (s *SessionService) ListVersions(sessionID, userID, workspaceID uuid.UUID, limit int) ([]models.SessionVersion, error) {
+	if _, err := s.GetSession(sessionID, userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT session_id, version, user_id, label, created_at
+		FROM editor_session_versions
+		WHERE session_id = $1
+		ORDER BY version DESC
+		LIMIT $2
+	`, sessionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []models.SessionVersion{}
+	for rows.Next() {
+		var v models.SessionVersion
+		if err := rows.Scan(&v.SessionID, &v.Version, &v.UserID, &v.Label, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetVersion returns one stored version including its timeline.
+func (s *SessionService) GetVersion(sessionID, userID, workspaceID uuid.UUID, version int) (*models.SessionVersion, error) {
+	if _, err := s.GetSession(sessionID, userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.getVersion(ctx, sessionID, version)
+}
+
+func (s *SessionService) getVersion(ctx context.Context, sessionID uuid.UUID, version int) (*models.SessionVersion, error) {
+	v := &models.SessionVersion{}
+	var timelineJSON []byte
+
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT session_id, version, timeline, user_id, label, created_at
+		FROM editor_session_versions
+		WHERE session_id = $1 AND version = $2
+	`, sessionID, version).Scan(&v.SessionID, &v.Version, &timelineJSON, &v.UserID, &v.Label, &v.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v.Timeline, err = timeline.Decode(timelineJSON)
+	if err != nil {
+		return nil, fmt.Errorf("stored version %d: %w", version, err)
+	}
+	return v, nil
+}
+
+// ============================================================================
+// RESTORE
+// ============================================================================
+
+// RestoreVersion saves an old timeline as a NEW version — history is append-only,
+// so a restore can itself be undone by restoring the version before it.
+// expectedVersion works exactly as in SaveSession (0 = no check).
+func (s *SessionService) RestoreVersion(sessionID, userID, workspaceID uuid.UUID, version, expectedVersion int) (int, error) {
+	old, err := s.GetVersion(sessionID, userID, workspaceID, version)
+	if err != nil {
+		return 0, err
+	}
+
+	label := fmt.Sprintf("restored from v%d", version)
+	return s.SaveSession(sessionID, userID, workspaceID, old.Timeline, expectedVersion, label)
+}
+
+// ============================================================================
+// DIFF
+// ============================================================================
+
+// DiffVersions compares two stored versions structurally (clips added,
+// removed, moved, trimmed, modified).
+func (s *SessionService) DiffVersions(sessionID, userID, workspaceID uuid.UUID, from, to int) (*timeline.Diff, error) {
+	if _, err := s.GetSession(sessionID, userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	fromVersion, err := s.getVersion(ctx, sessionID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.getVersion(ctx, sessionID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := timeline.Compare(fromVersion.Timeline, toVersion.Timeline)
+	diff.FromVersion = from
+	diff.ToVersion = to
+	return diff, nil
+}
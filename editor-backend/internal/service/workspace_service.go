@@ -0,0 +1,416 @@
+// internal/service/workspace_service.go
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"editor-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkspaceAccess    = errors.New("not a member of this workspace")
+	ErrWorkspaceRole      = errors.New("your workspace role does not allow this")
+	ErrNotWorkspaceMember = errors.New("user is not a member of this workspace")
+	ErrInvalidWorkspace   = errors.New("workspace name is required")
+	ErrLastWorkspaceAdmin = errors.New("a workspace needs at least one admin")
+)
+
+// ============================================================================
+// WORKSPACES
+// ============================================================================
+//
+// A workspace is a shared project space — one agency or team. The active
+// workspace comes with every request (token claim or X-Workspace-ID, see
+// auth.Middleware) and SessionService scopes every query by it:
+//
+//   - uuid.Nil means the caller's personal space: sessions with no workspace
+//   - otherwise the caller must be a workspace member, and only sessions in
+//     that workspace are visible — others answer ErrSessionNotFound
+//
+// New sessions, export jobs and uploads are stamped with the active workspace.
+
+var workspaceRank = map[string]int{
+	models.WorkspaceRoleViewer: 1,
+	models.WorkspaceRoleMember: 2,
+	models.WorkspaceRoleAdmin:  3,
+}
+
+// IsWorkspaceRole reports whether role is a valid workspace role.
+func IsWorkspaceRole(role string) bool {
+	_, ok := workspaceRank[role]
+	return ok
+}
+
+// workspaceArg turns the active workspace into a query argument — NULL for
+// the personal space.
+func workspaceArg(workspaceID uuid.UUID) *uuid.UUID {
+	if workspaceID == uuid.Nil {
+		return nil
+	}
+	return &workspaceID
+}
+
+// WorkspaceOf is the inverse of workspaceArg, for rows that carry a nullable
+// workspace_id (sessions, export jobs).
+func WorkspaceOf(workspaceID *uuid.UUID) uuid.UUID {
+	if workspaceID == nil {
+		return uuid.Nil
+	}
+	return *workspaceID
+}
+
+// workspaceRole returns userID's role in a workspace, or ErrWorkspaceAccess.
+// A workspace that doesn't exist looks the same as one the user isn't in.
+func workspaceRole(ctx context.Context, db *sql.DB, workspaceID, userID uuid.UUID) (string, error) {
+	var role string
+	err := db.QueryRowContext(ctx, `
+		SELECT role FROM workspace_members
+		WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrWorkspaceAccess
+	}
+	return role, err
+}
+
+// requireWorkspaceRole checks userID holds at least need in the workspace.
+// The personal space (uuid.Nil) always passes.
+func requireWorkspaceRole(ctx context.Context, db *sql.DB, workspaceID, userID uuid.UUID, need string) error {
+	if workspaceID == uuid.Nil {
+		return nil
+	}
+	role, err := workspaceRole(ctx, db, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if workspaceRank[role] < workspaceRank[need] {
+		return ErrWorkspaceRole
+	}
+	return nil
+}
+
+// WorkspaceService manages workspaces, their members and uploaded assets.
+// Sessions in a workspace stay with SessionService.
+type WorkspaceService struct {
+	DB *sql.DB
+}
+
+// RequireRole checks userID holds at least role in the workspace — for
+// handlers that must reject a request before doing any work.
+func (s *WorkspaceService) RequireRole(workspaceID, userID uuid.UUID, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return requireWorkspaceRole(ctx, s.DB, workspaceID, userID, role)
+}
+
+// CreateWorkspace creates a workspace with userID as its first admin.
+func (s *WorkspaceService) CreateWorkspace(userID uuid.UUID, name string) (*models.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidWorkspace
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ws := &models.Workspace{Name: name, CreatedBy: userID, Role: models.WorkspaceRoleAdmin}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO workspaces (name, created_by)
+		VALUES ($1, $2)
+		RETURNING workspace_id, created_at
+	`, name, userID).Scan(&ws.WorkspaceID, &ws.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		VALUES ($1, $2, 'admin')
+	`, ws.WorkspaceID, userID); err != nil {
+		return nil, fmt.Errorf("failed to add workspace admin: %w", err)
+	}
+
+	return ws, tx.Commit()
+}
+
+// ListWorkspaces returns the workspaces userID belongs to, with their role.
+func (s *WorkspaceService) ListWorkspaces(userID uuid.UUID) ([]models.Workspace, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT w.workspace_id, w.name, w.created_by, w.created_at, m.role
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.workspace_id
+		WHERE m.user_id = $1
+		ORDER BY w.name, w.workspace_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		var ws models.Workspace
+		if err := rows.Scan(&ws.WorkspaceID, &ws.Name, &ws.CreatedBy, &ws.CreatedAt, &ws.Role); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+	return workspaces, rows.Err()
+}
+
+// ============================================================================
+// WORKSPACE MEMBERS — admins manage, any member may look
+// ============================================================================
+
+// ListWorkspaceMembers returns every member of the workspace.
+func (s *WorkspaceService) ListWorkspaceMembers(workspaceID, userID uuid.UUID) ([]models.WorkspaceMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT workspace_id, user_id, role, created_at, updated_at
+		FROM workspace_members
+		WHERE workspace_id = $1
+		ORDER BY created_at, user_id
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMember{}
+	for rows.Next() {
+		var m models.WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetWorkspaceMember adds memberID to the workspace or changes their role.
+// Admin only; the last admin cannot demote themselves.
+func (s *WorkspaceService) SetWorkspaceMember(workspaceID, userID, memberID uuid.UUID, role string) (*models.WorkspaceMember, error) {
+	if !IsWorkspaceRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	m := &models.WorkspaceMember{WorkspaceID: workspaceID, UserID: memberID, Role: role}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id)
+		DO UPDATE SET role = EXCLUDED.role, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`, workspaceID, memberID, role).Scan(&m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set workspace member: %w", err)
+	}
+
+	if err := ensureWorkspaceAdmin(ctx, tx, workspaceID); err != nil {
+		return nil, err
+	}
+	return m, tx.Commit()
+}
+
+// RemoveWorkspaceMember removes memberID from the workspace. Admins can
+// remove anyone; members can remove themselves. Their sessions stay in the
+// workspace.
+func (s *WorkspaceService) RemoveWorkspaceMember(workspaceID, userID, memberID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	need := models.WorkspaceRoleAdmin
+	if memberID == userID {
+		need = models.WorkspaceRoleViewer
+	}
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, need); err != nil {
+		return err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM workspace_members
+		WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrMemberNotFound
+	}
+
+	if err := ensureWorkspaceAdmin(ctx, tx, workspaceID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ensureWorkspaceAdmin fails when a membership change left no admin. The
+// workspace row is locked so two concurrent demotions can't both pass.
+func ensureWorkspaceAdmin(ctx context.Context, tx *sql.Tx, workspaceID uuid.UUID) error {
+	if _, err := tx.ExecContext(ctx,
+		`SELECT 1 FROM workspaces WHERE workspace_id = $1 FOR UPDATE`, workspaceID); err != nil {
+		return err
+	}
+
+	var admins int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM workspace_members
+		WHERE workspace_id = $1 AND role = 'admin'
+	`, workspaceID).Scan(&admins); err != nil {
+		return err
+	}
+	if admins == 0 {
+		return ErrLastWorkspaceAdmin
+	}
+	return nil
+}
+
+// ============================================================================
+// ASSETS — uploaded media
+// ============================================================================
+
+// RecordAsset stores an upload's metadata in the caller's active workspace.
+// Uploading into a workspace needs the member role.
+func (s *WorkspaceService) RecordAsset(userID, workspaceID uuid.UUID, fileURL, filename, contentType string, size int64) (*models.Asset, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+
+	return insertAsset(ctx, s.DB, &models.Asset{
+		WorkspaceID: workspaceArg(workspaceID),
+		UserID:      userID,
+		FileURL:     fileURL,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   size,
+	})
+}
+
+// insertAsset writes asset and fills in its ID and created_at. db is the
+// *sql.DB or, to record it atomically with something else, a *sql.Tx.
+func insertAsset(ctx context.Context, db interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, asset *models.Asset) (*models.Asset, error) {
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO editor_assets (workspace_id, user_id, file_url, filename, content_type, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING asset_id, created_at
+	`, asset.WorkspaceID, asset.UserID, asset.FileURL, asset.Filename, asset.ContentType, asset.SizeBytes,
+	).Scan(&asset.AssetID, &asset.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record asset: %w", err)
+	}
+	return asset, nil
+}
+
+// AssetPage is one page of ListWorkspaceAssets.
+type AssetPage struct {
+	Assets     []models.Asset `json:"assets"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// ListWorkspaceAssets returns the workspace's uploads, newest first, keyset
+// paginated like ListSessions. Any workspace member may look.
+func (s *WorkspaceService) ListWorkspaceAssets(workspaceID, userID uuid.UUID, limit int, cursor string) (*AssetPage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := requireWorkspaceRole(ctx, s.DB, workspaceID, userID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultSessionPageSize
+	}
+	if limit > maxSessionPageSize {
+		limit = maxSessionPageSize
+	}
+
+	where := "workspace_id = $1"
+	args := []interface{}{workspaceID}
+	if cursor != "" {
+		after, afterID, err := decodeSessionCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, after, afterID)
+		where += " AND (created_at, asset_id) < ($2, $3)"
+	}
+	args = append(args, limit+1)
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT asset_id, workspace_id, user_id, file_url, filename, content_type, size_bytes, created_at
+		FROM editor_assets
+		WHERE `+where+`
+		ORDER BY created_at DESC, asset_id DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assets: %w", err)
+	}
+	defer rows.Close()
+
+	page := &AssetPage{Assets: []models.Asset{}}
+	for rows.Next() {
+		var a models.Asset
+		if err := rows.Scan(&a.AssetID, &a.WorkspaceID, &a.UserID, &a.FileURL,
+			&a.Filename, &a.ContentType, &a.SizeBytes, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		page.Assets = append(page.Assets, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Assets) > limit {
+		page.Assets = page.Assets[:limit]
+		last := page.Assets[limit-1]
+		page.NextCursor = encodeSessionCursor(last.CreatedAt, last.AssetID)
+	}
+	return page, nil
+}
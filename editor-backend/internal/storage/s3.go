@@ -0,0 +1,657 @@
+// internal/storage/s3.go
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── S3 Storage ────────────────────────────────────────────────────────────────
+
+// S3Storage stores uploads in an S3 bucket — AWS or any S3-compatible store
+// (MinIO for local runs). Requests are signed with SigV4 over net/http; files
+// above MultipartThreshold go up as a multipart upload, PartSize at a time.
+type S3Storage struct {
+	Bucket string
+	Region string
+
+	// Endpoint is the S3 API base URL; empty → https://s3.<region>.amazonaws.com.
+	// MinIO: http://localhost:9000 with PathStyle.
+	Endpoint string
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key> instead of
+	// <bucket>.<endpoint host>/<key>.
+	PathStyle bool
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // optional, for temporary credentials
+
+	// PublicURL is the base returned URLs are built on (CloudFront, a public
+	// bucket domain, ...); empty → the object's S3 URL.
+	PublicURL string
+	// KeyPrefix is prepended to every object key, e.g. "editor/uploads/".
+	KeyPrefix string
+	// CacheControl is sent with every object. Keys are random UUIDs, so
+	// objects never change and may be cached forever.
+	CacheControl string
+
+	PartSize           int64 // default 16 MiB (S3 minimum 5 MiB)
+	MultipartThreshold int64 // default 64 MiB
+	Concurrency        int   // parts in flight, default 4
+
+	Client *http.Client
+}
+
+const (
+	defaultPartSize           = 16 << 20
+	minPartSize               = 5 << 20
+	defaultMultipartThreshold = 64 << 20
+	defaultConcurrency        = 4
+	maxParts                  = 10000
+	s3Attempts                = 3
+	s3RequestTimeout          = 10 * time.Minute
+	defaultCacheControl       = "public, max-age=31536000, immutable"
+)
+
+// S3Config collects what NewS3Storage needs. See S3ConfigFromEnv.
+type S3Config struct {
+	Bucket, Region, Endpoint string
+	PathStyle                bool
+	AccessKeyID              string
+	SecretAccessKey          string
+	SessionToken             string
+	PublicURL, KeyPrefix     string
+	CacheControl             string
+	PartSize                 int64
+}
+
+// S3ConfigFromEnv reads the S3 settings shared by the API and the worker:
+//
+//	S3_BUCKET (or AWS_BUCKET), AWS_REGION
+//	AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN
+//	S3_ENDPOINT, S3_FORCE_PATH_STYLE=true       — MinIO / S3-compatible stores
+//	S3_PUBLIC_URL (or CLOUDFRONT_DOMAIN)        — base of returned file URLs
+//	S3_KEY_PREFIX, S3_CACHE_CONTROL, S3_PART_SIZE_MB
+func S3ConfigFromEnv() S3Config {
+	cfg := S3Config{
+		Bucket:          os.Getenv("S3_BUCKET"),
+		Region:          os.Getenv("AWS_REGION"),
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		KeyPrefix:       os.Getenv("S3_KEY_PREFIX"),
+		CacheControl:    os.Getenv("S3_CACHE_CONTROL"),
+	}
+	if cfg.Bucket == "" {
+		cfg.Bucket = os.Getenv("AWS_BUCKET")
+	}
+	cfg.PathStyle, _ = strconv.ParseBool(os.Getenv("S3_FORCE_PATH_STYLE"))
+	if cfg.PublicURL == "" {
+		if domain := os.Getenv("CLOUDFRONT_DOMAIN"); domain != "" {
+			cfg.PublicURL = "https://" + strings.TrimPrefix(strings.TrimPrefix(domain, "https://"), "http://")
+		}
+	}
+	if mb, err := strconv.ParseInt(os.Getenv("S3_PART_SIZE_MB"), 10, 64); err == nil && mb > 0 {
+		cfg.PartSize = mb << 20
+	}
+	return cfg
+}
+
+// NewS3Storage checks cfg and fills in defaults.
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, errors.New("s3: bucket and region are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3: access key ID and secret access key are required")
+	}
+	if cfg.PartSize != 0 && cfg.PartSize < minPartSize {
+		return nil, fmt.Errorf("s3: part size must be at least %d MiB", minPartSize>>20)
+	}
+
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", cfg.Endpoint)
+	}
+
+	s := &S3Storage{
+		Bucket:             cfg.Bucket,
+		Region:             cfg.Region,
+		Endpoint:           endpoint,
+		PathStyle:          cfg.PathStyle,
+		AccessKeyID:        cfg.AccessKeyID,
+		SecretAccessKey:    cfg.SecretAccessKey,
+		SessionToken:       cfg.SessionToken,
+		PublicURL:          strings.TrimRight(cfg.PublicURL, "/"),
+		KeyPrefix:          cfg.KeyPrefix,
+		CacheControl:       cfg.CacheControl,
+		PartSize:           cfg.PartSize,
+		MultipartThreshold: defaultMultipartThreshold,
+		Concurrency:        defaultConcurrency,
+		Client:             &http.Client{},
+	}
+	if s.CacheControl == "" {
+		s.CacheControl = defaultCacheControl
+	}
+	if s.PartSize == 0 {
+		s.PartSize = defaultPartSize
+	}
+	return s, nil
+}
+
+func (s *S3Storage) Upload(r io.Reader, size int64, filename string, contentType string) (*ObjectInfo, error) {
+	key := newKey(filename)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	body, cleanup, err := seekable(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("s3: read upload: %w", err)
+	}
+	defer cleanup()
+
+	if body.Size() <= s.MultipartThreshold {
+		err = s.putObject(s.KeyPrefix+key, body, body.Size(), contentType)
+	} else {
+		err = s.multipartUpload(s.KeyPrefix+key, body, body.Size(), contentType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{
+		Key:          key,
+		URL:          s.fileURL(s.KeyPrefix + key),
+		Size:         body.Size(),
+		ContentType:  contentType,
+		LastModified: time.Now().UTC(),
+	}, nil
+}
+
+// ── Reading and managing objects ──────────────────────────────────────────────
+
+func (s *S3Storage) Open(key string, offset, length int64) (io.ReadCloser, *ObjectInfo, error) {
+	if err := checkKey(key); err != nil {
+		return nil, nil, err
+	}
+	if offset < 0 {
+		return nil, nil, fmt.Errorf("%w: offset %d", ErrInvalidRange, offset)
+	}
+
+	resp, err := s.do(http.MethodGet, s.KeyPrefix+key, nil, nil, 0, func(h http.Header) {
+		// No Range header for the whole object — S3 answers bytes=0- on an
+		// empty object with 416
+		switch {
+		case length > 0:
+			h.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+		case offset > 0:
+			h.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	})
+	if err != nil {
+		return nil, nil, s3ObjectError(err)
+	}
+
+	info := s.headerInfo(key, resp.Header)
+	// A ranged response carries the object's full size in Content-Range
+	if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+		if n, err := strconv.ParseInt(total, 10, 64); err == nil {
+			info.Size = n
+		}
+	}
+	return resp.Body, info, nil
+}
+
+func (s *S3Storage) Stat(key string) (*ObjectInfo, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(http.MethodHead, s.KeyPrefix+key, nil, nil, 0, nil)
+	if err != nil {
+		return nil, s3ObjectError(err)
+	}
+	resp.Body.Close()
+	return s.headerInfo(key, resp.Header), nil
+}
+
+func (s *S3Storage) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	// S3 answers 204 whether or not the key existed
+	resp, err := s.do(http.MethodDelete, s.KeyPrefix+key, nil, nil, 0, nil)
+	if err != nil {
+		return s3ObjectError(err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) List(prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.KeyPrefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(http.MethodGet, "", query, nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: list objects: unexpected response: %w", err)
+		}
+
+		for _, c := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          strings.TrimPrefix(c.Key, s.KeyPrefix),
+				URL:          s.fileURL(c.Key),
+				Size:         c.Size,
+				ETag:         c.ETag,
+				LastModified: c.LastModified.UTC(),
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *S3Storage) Exists(key string) (bool, error) {
+	_, err := s.Stat(key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// PresignUpload returns a SigV4 presigned PUT URL. Content-Type,
+// Content-Length and Cache-Control are signed, so S3 rejects a body of any
+// other size or type; the client sends Headers as given (browsers set
+// Content-Length themselves). The bucket's CORS rules must allow PUT from
+// the editor's origin with those headers.
+func (s *S3Storage) PresignUpload(filename string, contentType string, size int64, ttl time.Duration) (*PresignedUpload, error) {
+	key := newKey(filename)
+	now := time.Now()
+
+	u := s.objectURL(s.KeyPrefix+key, nil)
+	presignV4(http.MethodPut, u, map[string]string{
+		"content-type":   contentType,
+		"content-length": strconv.FormatInt(size, 10),
+		"cache-control":  s.CacheControl,
+	}, s.AccessKeyID, s.SecretAccessKey, s.SessionToken, s.Region, now, ttl)
+
+	return &PresignedUpload{
+		Key:    key,
+		URL:    u.String(),
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":  contentType,
+			"Cache-Control": s.CacheControl,
+		},
+		ExpiresAt: now.Add(ttl).UTC().Truncate(time.Second),
+	}, nil
+}
+
+// headerInfo builds ObjectInfo from a HEAD / GET response.
+func (s *S3Storage) headerInfo(key string, h http.Header) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         key,
+		URL:         s.fileURL(s.KeyPrefix + key),
+		ContentType: h.Get("Content-Type"),
+		ETag:        h.Get("ETag"),
+	}
+	info.Size, _ = strconv.ParseInt(h.Get("Content-Length"), 10, 64)
+	if t, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+		info.LastModified = t.UTC()
+	}
+	return info
+}
+
+// s3ObjectError maps S3 status codes onto the storage sentinels.
+func s3ObjectError(err error) error {
+	var s3Err *S3Error
+	if errors.As(err, &s3Err) {
+		switch s3Err.StatusCode {
+		case http.StatusNotFound:
+			return ErrNotFound
+		case http.StatusRequestedRangeNotSatisfiable:
+			return fmt.Errorf("%w: %v", ErrInvalidRange, err)
+		}
+	}
+	return err
+}
+
+// fileURL is where clients fetch key from.
+func (s *S3Storage) fileURL(key string) string {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + uriEncode(key, false)
+	}
+	return s.objectURL(key, nil).String()
+}
+
+// FilesURL is the origin stored files are served from — PublicURL, or the
+// bucket itself.
+func (s *S3Storage) FilesURL() string {
+	return s.fileURL("")
+}
+
+// objectURL addresses key in the bucket, path-style or virtual-hosted.
+// key is the full object key, KeyPrefix included.
+func (s *S3Storage) objectURL(key string, query url.Values) *url.URL {
+	u, _ := url.Parse(s.Endpoint) // checked in NewS3Storage
+	path := "/" + key
+	if s.PathStyle {
+		// key "" addresses the bucket itself
+		path = strings.TrimSuffix("/"+s.Bucket+path, "/")
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + path
+	u.RawPath = uriEncode(u.Path, false)
+	if query != nil {
+		// SigV4 and S3 both want %20, not +
+		u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+	}
+	return u
+}
+
+// ── Single PUT ────────────────────────────────────────────────────────────────
+
+func (s *S3Storage) putObject(key string, body *io.SectionReader, size int64, contentType string) error {
+	resp, err := s.do(http.MethodPut, key, nil, body, size, func(h http.Header) {
+		h.Set("Content-Type", contentType)
+		h.Set("Cache-Control", s.CacheControl)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ── Multipart upload ──────────────────────────────────────────────────────────
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *S3Storage) multipartUpload(key string, file io.ReaderAt, size int64, contentType string) error {
+	partSize := s.PartSize
+	// S3 allows at most 10,000 parts — grow the parts for huge files
+	if need := (size + maxParts - 1) / maxParts; need > partSize {
+		partSize = need
+	}
+	count := int((size + partSize - 1) / partSize)
+
+	uploadID, err := s.createMultipartUpload(key, contentType)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]completedPart, count)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, max(s.Concurrency, 1))
+
+	for i := 0; i < count; i++ {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+
+		offset := int64(i) * partSize
+		length := min(partSize, size-offset)
+		number := i + 1
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			etag, err := s.uploadPart(key, uploadID, number, io.NewSectionReader(file, offset, length), length)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			parts[number-1] = completedPart{PartNumber: number, ETag: etag}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = s.completeMultipartUpload(key, uploadID, parts)
+	}
+	if firstErr != nil {
+		// Parts of an abandoned upload are billed until aborted
+		if err := s.abortMultipartUpload(key, uploadID); err != nil {
+			return fmt.Errorf("%w (abort also failed: %v)", firstErr, err)
+		}
+		return firstErr
+	}
+	return nil
+}
+
+func (s *S3Storage) createMultipartUpload(key, contentType string) (string, error) {
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0, func(h http.Header) {
+		h.Set("Content-Type", contentType)
+		h.Set("Cache-Control", s.CacheControl)
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&out); err != nil || out.UploadID == "" {
+		return "", fmt.Errorf("s3: create multipart upload: unexpected response: %v", err)
+	}
+	return out.UploadID, nil
+}
+
+func (s *S3Storage) uploadPart(key, uploadID string, number int, body *io.SectionReader, size int64) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	resp, err := s.do(http.MethodPut, key, query, body, size, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("s3: part %d: no ETag in response", number)
+	}
+	return etag, nil
+}
+
+func (s *S3Storage) completeMultipartUpload(key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	reader := io.NewSectionReader(bytes.NewReader(body), 0, int64(len(body)))
+	resp, err := s.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, reader, int64(len(body)), func(h http.Header) {
+		h.Set("Content-Type", "application/xml")
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 can answer 200 and still fail the completion in the body
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if s3Err := parseS3Error(raw); s3Err != nil {
+		return fmt.Errorf("s3: complete multipart upload: %w", s3Err)
+	}
+	return nil
+}
+
+func (s *S3Storage) abortMultipartUpload(key, uploadID string) error {
+	resp, err := s.do(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ── Requests ──────────────────────────────────────────────────────────────────
+
+// S3Error is an error response from the S3 API.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3: %s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+func parseS3Error(body []byte) *S3Error {
+	if !bytes.Contains(body, []byte("<Error>")) {
+		return nil
+	}
+	e := &S3Error{StatusCode: http.StatusOK}
+	xml.Unmarshal(body, e)
+	return e
+}
+
+// do signs and sends one request, retrying network errors, 5xx and 429.
+// body may be nil; it is re-read from the start on each attempt.
+func (s *S3Storage) do(method, key string, query url.Values, body *io.SectionReader, size int64, setHeaders func(http.Header)) (*http.Response, error) {
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(body, 0, size)); err != nil {
+			return nil, fmt.Errorf("s3: read upload: %w", err)
+		}
+		payloadHash = hex.EncodeToString(h.Sum(nil))
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < s3Attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+
+		resp, err := s.send(method, key, query, body, size, setHeaders, payloadHash)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		s3Err := parseS3Error(raw)
+		if s3Err == nil {
+			s3Err = &S3Error{Code: http.StatusText(resp.StatusCode)}
+		}
+		s3Err.StatusCode = resp.StatusCode
+		lastErr = s3Err
+
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (s *S3Storage) send(method, key string, query url.Values, body *io.SectionReader, size int64, setHeaders func(http.Header), payloadHash string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+
+	var reader io.Reader
+	if body != nil {
+		reader = io.NewSectionReader(body, 0, size)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query).String(), reader)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.ContentLength = size
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	if setHeaders != nil {
+		setHeaders(req.Header)
+	}
+	signV4(req, s.AccessKeyID, s.SecretAccessKey, s.SessionToken, s.Region, payloadHash, time.Now())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request context once the body is done with.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,536 @@
+// internal/storage/s3_test.go
+package storage
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an httptest stand-in for the parts of the S3 API S3Storage
+// uses, path-style: PUT / GET (Range) / HEAD / DELETE object, ListObjectsV2
+// (pageSize keys per page) and multipart create / part / complete / abort.
+// Every request must carry a SigV4 Authorization header and a payload hash
+// that matches the body.
+type fakeS3 struct {
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	uploads map[string]*fakeUpload // by upload ID
+	aborted []string
+	nextID  int
+
+	// failPart answers that part number with 403
+	failPart int
+}
+
+type fakeUpload struct {
+	contentType string
+	parts       map[int][]byte
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3Storage) {
+	f := &fakeS3{
+		bucket:   "media",
+		pageSize: 1000,
+		objects:  map[string]fakeObject{},
+		uploads:  map[string]*fakeUpload{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	s, err := NewS3Storage(S3Config{
+		Bucket:          f.bucket,
+		Region:          "us-east-1",
+		Endpoint:        server.URL,
+		PathStyle:       true,
+		AccessKeyID:     exampleAccessKey,
+		SecretAccessKey: exampleSecretKey,
+		KeyPrefix:       "editor/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, s
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+
+	presigned := query.Get("X-Amz-Signature") != ""
+	if !presigned {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+exampleAccessKey+"/") {
+			s3Fail(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		if sum := sha256.Sum256(body); r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			s3Fail(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+			return
+		}
+	}
+
+	bucketPath := "/" + f.bucket
+	if r.URL.Path == bucketPath && r.Method == http.MethodGet && query.Get("list-type") == "2" {
+		f.list(w, query)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, bucketPath+"/")
+	if key == r.URL.Path || key == "" {
+		s3Fail(w, http.StatusBadRequest, "InvalidRequest")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = &fakeUpload{contentType: r.Header.Get("Content-Type"), parts: map[int][]byte{}}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			s3Fail(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			s3Fail(w, http.StatusForbidden, "AccessDenied")
+			return
+		}
+		upload.parts[number] = body
+		w.Header().Set("ETag", etagOf(body))
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		upload, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			s3Fail(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var complete struct {
+			Parts []completedPart `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			s3Fail(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data []byte
+		for i, p := range complete.Parts {
+			if p.PartNumber != i+1 || p.ETag != etagOf(upload.parts[p.PartNumber]) {
+				s3Fail(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			data = append(data, upload.parts[p.PartNumber]...)
+		}
+		delete(f.uploads, query.Get("uploadId"))
+		f.objects[key] = fakeObject{data: data, contentType: upload.contentType, modified: time.Now()}
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		f.aborted = append(f.aborted, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		f.objects[key] = fakeObject{data: body, contentType: r.Header.Get("Content-Type"), modified: time.Now()}
+		w.Header().Set("ETag", etagOf(body))
+
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			s3Fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("ETag", etagOf(obj.data))
+		w.Header().Set("Last-Modified", obj.modified.UTC().Format(http.TimeFormat))
+		data, status := obj.data, http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			var start, end int64
+			end = -1
+			spec := strings.TrimPrefix(rng, "bytes=")
+			from, to, _ := strings.Cut(spec, "-")
+			start, _ = strconv.ParseInt(from, 10, 64)
+			if to != "" {
+				end, _ = strconv.ParseInt(to, 10, 64)
+			}
+			size := int64(len(obj.data))
+			if start >= size {
+				s3Fail(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			if end < 0 || end >= size {
+				end = size - 1
+			}
+			data, status = obj.data[start:end+1], http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s3Fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, query map[string][]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	prefix := first(query["prefix"])
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start := 0
+	if token := first(query["continuation-token"]); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	end := min(start+f.pageSize, len(keys))
+
+	var b strings.Builder
+	b.WriteString("<ListBucketResult>")
+	for _, key := range keys[start:end] {
+		obj := f.objects[key]
+		fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>%d</Size><ETag>%s</ETag><LastModified>%s</LastModified></Contents>",
+			key, len(obj.data), etagOf(obj.data), obj.modified.UTC().Format(time.RFC3339))
+	}
+	if end < len(keys) {
+		fmt.Fprintf(&b, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", end)
+	}
+	b.WriteString("</ListBucketResult>")
+	fmt.Fprint(w, b.String())
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func etagOf(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func s3Fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestS3UploadSinglePut(t *testing.T) {
+	f, s := newFakeS3(t)
+
+	info, err := s.Upload(strings.NewReader("hello world"), 11, "clip.MP4", "video/mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(info.Key, ".mp4") || info.Size != 11 {
+		t.Fatalf("info = %+v", info)
+	}
+	if want := s.Endpoint + "/media/editor/" + info.Key; info.URL != want {
+		t.Fatalf("URL = %s, want %s", info.URL, want)
+	}
+
+	obj, ok := f.objects["editor/"+info.Key]
+	if !ok || string(obj.data) != "hello world" || obj.contentType != "video/mp4" {
+		t.Fatalf("stored object = %+v (found %v)", obj, ok)
+	}
+	if len(f.uploads) != 0 || f.nextID != 0 {
+		t.Fatal("a small file must not use multipart")
+	}
+}
+
+func TestS3UploadUnknownSize(t *testing.T) {
+	f, s := newFakeS3(t)
+
+	// Not a ReaderAt — spooled to find the size
+	info, err := s.Upload(io.MultiReader(strings.NewReader("abc"), strings.NewReader("def")), -1, "a.mp3", "audio/mpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 6 || string(f.objects["editor/"+info.Key].data) != "abcdef" {
+		t.Fatalf("info = %+v", info)
+	}
+
+	if _, err := s.Upload(strings.NewReader("abc"), 4, "a.mp3", "audio/mpeg"); !errors.Is(err, ErrSizeMismatch) {
+		t.Fatalf("short body: err = %v, want ErrSizeMismatch", err)
+	}
+}
+
+func TestS3UploadMultipart(t *testing.T) {
+	f, s := newFakeS3(t)
+	s.MultipartThreshold = 8
+	s.PartSize = 4
+
+	data := []byte("0123456789abcdefghij-")
+	info, err := s.Upload(bytes.NewReader(data), int64(len(data)), "big.mp4", "video/mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.nextID != 1 {
+		t.Fatalf("multipart uploads started = %d, want 1", f.nextID)
+	}
+	obj := f.objects["editor/"+info.Key]
+	if !bytes.Equal(obj.data, data) || obj.contentType != "video/mp4" {
+		t.Fatalf("assembled object = %q (%s), want %q", obj.data, obj.contentType, data)
+	}
+	if len(f.uploads) != 0 || len(f.aborted) != 0 {
+		t.Fatalf("pending uploads %v, aborted %v", f.uploads, f.aborted)
+	}
+}
+
+func TestS3UploadMultipartAbortsOnError(t *testing.T) {
+	f, s := newFakeS3(t)
+	s.MultipartThreshold = 8
+	s.PartSize = 4
+	f.failPart = 3
+
+	data := []byte("0123456789abcdefghij")
+	_, err := s.Upload(bytes.NewReader(data), int64(len(data)), "big.mp4", "video/mp4")
+	var s3Err *S3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusForbidden {
+		t.Fatalf("err = %v, want the part's 403", err)
+	}
+	if len(f.aborted) != 1 || len(f.uploads) != 0 {
+		t.Fatalf("aborted %v, still pending %v — the upload must be aborted", f.aborted, f.uploads)
+	}
+	if len(f.objects) != 0 {
+		t.Fatal("nothing should be stored after a failed upload")
+	}
+}
+
+func TestS3OpenRanges(t *testing.T) {
+	_, s := newFakeS3(t)
+	info, err := s.Upload(strings.NewReader("0123456789"), 10, "a.mp4", "video/mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, 0, "0123456789"},
+		{2, 3, "234"},
+		{7, 0, "789"},
+		{8, 100, "89"},
+	}
+	for _, tc := range cases {
+		rc, obj, err := s.Open(info.Key, tc.offset, tc.length)
+		if err != nil {
+			t.Fatalf("Open(%d, %d): %v", tc.offset, tc.length, err)
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(got) != tc.want {
+			t.Errorf("Open(%d, %d) = %q, want %q", tc.offset, tc.length, got, tc.want)
+		}
+		// Info describes the whole object, not the range
+		if obj.Size != 10 || obj.ContentType != "video/mp4" || obj.Key != info.Key {
+			t.Errorf("Open(%d, %d) info = %+v", tc.offset, tc.length, obj)
+		}
+	}
+
+	if _, _, err := s.Open(info.Key, 10, 0); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("offset past the end: err = %v, want ErrInvalidRange", err)
+	}
+	if _, _, err := s.Open("missing.mp4", 0, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: err = %v, want ErrNotFound", err)
+	}
+	if _, _, err := s.Open("../etc/passwd", 0, 0); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("traversal key: err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestS3StatExistsDelete(t *testing.T) {
+	_, s := newFakeS3(t)
+	info, err := s.Upload(strings.NewReader("abc"), 3, "a.wav", "audio/wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := s.Stat(info.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Size != 3 || stat.ContentType != "audio/wav" || stat.ETag == "" || stat.LastModified.IsZero() {
+		t.Fatalf("Stat = %+v", stat)
+	}
+	if ok, err := s.Exists(info.Key); !ok || err != nil {
+		t.Fatalf("Exists = %v, %v, want true", ok, err)
+	}
+
+	if err := s.Delete(info.Key); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Exists(info.Key); ok || err != nil {
+		t.Fatalf("Exists after delete = %v, %v, want false", ok, err)
+	}
+	if _, err := s.Stat(info.Key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Stat after delete: err = %v, want ErrNotFound", err)
+	}
+	// Deleting again is not an error
+	if err := s.Delete(info.Key); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func TestS3ListPages(t *testing.T) {
+	f, s := newFakeS3(t)
+	f.pageSize = 2
+
+	var keys []string
+	for i := 0; i < 5; i++ {
+		info, err := s.Upload(strings.NewReader("x"), 1, "a.mp4", "video/mp4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, info.Key)
+	}
+	// Outside the key prefix — never listed
+	f.objects["other/stray.mp4"] = fakeObject{data: []byte("y")}
+
+	objects, err := s.List("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, o := range objects {
+		got = append(got, o.Key)
+		if o.Size != 1 || !strings.HasSuffix(o.URL, "/media/editor/"+o.Key) {
+			t.Errorf("listed %+v", o)
+		}
+	}
+	sort.Strings(keys)
+	if strings.Join(got, ",") != strings.Join(keys, ",") {
+		t.Fatalf("List = %v, want %v", got, keys)
+	}
+
+	objects, err = s.List(keys[0][:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Key != keys[0] {
+		t.Fatalf("List(prefix) = %+v, want only %s", objects, keys[0])
+	}
+}
+
+func TestS3PresignUpload(t *testing.T) {
+	f, s := newFakeS3(t)
+
+	slot, err := s.PresignUpload("clip.webm", "video/webm", 5, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slot.Method != http.MethodPut || !strings.HasSuffix(slot.Key, ".webm") {
+		t.Fatalf("slot = %+v", slot)
+	}
+	if until := time.Until(slot.ExpiresAt); until < 14*time.Minute || until > 15*time.Minute {
+		t.Fatalf("ExpiresAt in %v, want ~15m", until)
+	}
+
+	req, err := http.NewRequest(slot.Method, slot.URL, strings.NewReader("abcde"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := req.URL.Query()
+	if got := query.Get("X-Amz-SignedHeaders"); got != "cache-control;content-length;content-type;host" {
+		t.Fatalf("signed headers = %s", got)
+	}
+	if query.Get("X-Amz-Expires") != "900" || query.Get("X-Amz-Signature") == "" {
+		t.Fatalf("presigned query = %s", req.URL.RawQuery)
+	}
+	if !strings.HasPrefix(req.URL.Path, "/media/editor/"+slot.Key) {
+		t.Fatalf("presigned path = %s", req.URL.Path)
+	}
+
+	// The browser sends the returned headers as-is
+	for name, value := range slot.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("presigned PUT status = %d", resp.StatusCode)
+	}
+
+	stat, err := s.Stat(slot.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Size != 5 || stat.ContentType != "video/webm" {
+		t.Fatalf("uploaded object = %+v", stat)
+	}
+	if _, ok := f.objects["editor/"+slot.Key]; !ok {
+		t.Fatal("object not stored under the key prefix")
+	}
+}
+
+func TestS3ErrorsAreNotRetried(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		s3Fail(w, http.StatusForbidden, "AccessDenied")
+	}))
+	defer server.Close()
+
+	s, err := NewS3Storage(S3Config{
+		Bucket: "media", Region: "us-east-1", Endpoint: server.URL, PathStyle: true,
+		AccessKeyID: exampleAccessKey, SecretAccessKey: exampleSecretKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Stat("a.mp4")
+	var s3Err *S3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusForbidden {
+		t.Fatalf("err = %v, want a 403 S3Error", err)
+	}
+	if calls != 1 {
+		t.Fatalf("requests = %d, want 1 — 4xx is final", calls)
+	}
+}
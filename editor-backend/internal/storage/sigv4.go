@@ -0,0 +1,182 @@
+// internal/storage/sigv4.go
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 for S3 requests (header auth). Works against AWS
+// and S3-compatible stores (MinIO, R2, Spaces, ...).
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// emptyPayloadHash is sha256("") — for requests without a body.
+var emptyPayloadHash = hashHex(nil)
+
+// signV4 adds x-amz-date, x-amz-content-sha256 and Authorization to req.
+// payloadHash is the hex SHA-256 of the body, or unsignedPayload. The host,
+// content-type and every x-amz-* header are signed.
+func signV4(req *http.Request, accessKey, secretKey, sessionToken, region, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.Join(values, ",")
+		}
+	}
+
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+	signature, signedHeaders := sign(req.Method, req.URL, headers, payloadHash, secretKey, region, amzDate)
+
+	req.Header.Set("Authorization", sigV4Algorithm+
+		" Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+}
+
+// presignV4 signs u in its query string so it can be used without
+// credentials until expires has passed — a presigned URL. The payload is
+// unsigned; headers (lowercase name → value) are signed along with host,
+// so the client must send exactly those values.
+func presignV4(method string, u *url.URL, headers map[string]string, accessKey, secretKey, sessionToken, region string, now time.Time, expires time.Duration) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+
+	signed := map[string]string{"host": u.Host}
+	for name, value := range headers {
+		signed[strings.ToLower(name)] = value
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", strings.Join(names, ";"))
+	if sessionToken != "" {
+		query.Set("X-Amz-Security-Token", sessionToken)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	signature, _ := sign(method, u, signed, unsignedPayload, secretKey, region, amzDate)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+}
+
+// sign builds the canonical request and returns its signature and the
+// signed header list. headers are keyed by lowercase name.
+func sign(method string, u *url.URL, headers map[string]string, payloadHash, secretKey, region, amzDate string) (string, string) {
+	// Canonical headers: lowercase names, trimmed values, sorted
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.Join(strings.Fields(headers[name]), " ") + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery(u.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := amzDate[:8]
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		date + "/" + region + "/s3/aws4_request",
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign)), signedHeaders
+}
+
+// canonicalQuery sorts parameters and encodes them the SigV4 way.
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything but RFC 3986 unreserved characters
+// (and "/" unless encodeSlash) — stricter than net/url, as SigV4 requires.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
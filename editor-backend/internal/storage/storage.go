@@ -2,11 +2,22 @@
 package storage
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"io/fs"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,83 +25,389 @@ import (
 // Storage is the only interface your handler depends on.
 // Swap the implementation in main.go — handler and service code never changes.
 //
-//	Today:    fileStorage = storage.NewLocalStorage(...)
-//	Tomorrow: fileStorage = storage.NewS3Storage(...)   ← one line change
+//	STORAGE_TYPE=local:  fileStorage = storage.NewLocalStorage(...)
+//	STORAGE_TYPE=s3:     fileStorage, err = storage.NewS3Storage(...)   (s3.go)
+//
+// Objects are addressed by key — the "<uuid><ext>" name Upload picks,
+// relative to the upload dir or the S3 key prefix. Keys use "/" separators
+// and never contain "..".
 type Storage interface {
-	Upload(file multipart.File, filename string, contentType string) (string, error)
+	// Upload stores r under a fresh key and returns where it landed. size is
+	// the byte count, or -1 if unknown; a known size that doesn't match the
+	// data is ErrSizeMismatch. filename only contributes its extension.
+	Upload(r io.Reader, size int64, filename string, contentType string) (*ObjectInfo, error)
+
+	// Open reads length bytes of key starting at offset; length <= 0 reads
+	// to the end. The returned info describes the whole object. An offset
+	// past the end is ErrInvalidRange. The caller closes the reader.
+	Open(key string, offset, length int64) (io.ReadCloser, *ObjectInfo, error)
+
+	// Stat describes key, or returns ErrNotFound.
+	Stat(key string) (*ObjectInfo, error)
+
+	// Delete removes key. Deleting a key that doesn't exist is not an error.
+	Delete(key string) error
+
+	// List returns every object whose key starts with prefix ("" = all).
+	List(prefix string) ([]ObjectInfo, error)
+
+	// Exists reports whether key is stored.
+	Exists(key string) (bool, error)
+
+	// PresignUpload reserves a fresh key and returns a URL the client can
+	// PUT exactly size bytes of contentType to, directly, until ttl passes.
+	// Nothing is stored until the client uploads — check with Stat.
+	PresignUpload(filename string, contentType string, size int64, ttl time.Duration) (*PresignedUpload, error)
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	URL          string    `json:"url"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type,omitempty"` // "" when the store doesn't record one
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// PresignedUpload is a direct-to-storage upload slot: send Method to URL
+// with Headers and the file as the body.
+type PresignedUpload struct {
+	Key       string            `json:"key"`
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrInvalidKey   = errors.New("invalid object key")
+	ErrInvalidRange = errors.New("range not satisfiable")
+	ErrSizeMismatch = errors.New("upload size does not match the data")
+	ErrUploadURL    = errors.New("upload URL is invalid or expired")
+)
+
+// newKey names a new object: a UUID, never the user's filename. Prevents:
+//  1. Path traversal attacks (../../etc/passwd)
+//  2. Filename collisions between users
+//  3. Information leakage (original filenames)
+func newKey(filename string) string {
+	// Only a plain extension survives — keys go into paths and URLs as-is
+	ext := strings.ToLower(filepath.Ext(filename))
+	if len(ext) < 2 || len(ext) > 10 || strings.Trim(ext[1:], "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+		ext = ""
+	}
+	return uuid.New().String() + ext
+}
+
+// checkKey rejects keys that could escape the storage root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) ||
+		path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
 }
 
 // ── Local Storage ─────────────────────────────────────────────────────────────
 
 // local.go
 
+// LocalUploadPath is where the API accepts direct uploads for LocalStorage
+// (PresignUpload URLs point here; cmd/api registers the route).
+const LocalUploadPath = "/api/v1/uploads/local/"
+
 type LocalStorage struct {
 	UploadDir string
 	BaseURL   string // e.g. "http://localhost:8083"
+
+	// SigningKey signs direct upload URLs. NewLocalStorage picks a random
+	// one — URLs then only work on this process, which is fine for dev.
+	// Anything else sets it from LocalSigningKeyFromEnv.
+	SigningKey []byte
+}
+
+// minSigningKeyLen is the shortest UPLOAD_SIGNING_SECRET accepted — the
+// HMAC-SHA256 block of entropy, not a password.
+const minSigningKeyLen = 32
+
+// LocalSigningKeyFromEnv reads UPLOAD_SIGNING_SECRET for LocalStorage.SigningKey.
+// A URL signed by one API pod is verified by whichever pod the PUT lands on,
+// so with more than one process the key must be shared: APP_ENV=production
+// refuses to start without it. In dev an empty secret returns nil and the
+// random per-process key stays.
+func LocalSigningKeyFromEnv() ([]byte, error) {
+	secret := os.Getenv("UPLOAD_SIGNING_SECRET")
+	if secret == "" {
+		if os.Getenv("APP_ENV") == "production" {
+			return nil, errors.New("UPLOAD_SIGNING_SECRET is required with STORAGE_TYPE=local in production")
+		}
+		return nil, nil
+	}
+	if len(secret) < minSigningKeyLen {
+		return nil, fmt.Errorf("UPLOAD_SIGNING_SECRET must be at least %d bytes", minSigningKeyLen)
+	}
+	return []byte(secret), nil
 }
 
 func NewLocalStorage(uploadDir, baseURL string) *LocalStorage {
 	// Create upload directory if it doesn't exist
 	os.MkdirAll(uploadDir, 0755)
-	return &LocalStorage{UploadDir: uploadDir, BaseURL: baseURL}
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &LocalStorage{UploadDir: uploadDir, BaseURL: baseURL, SigningKey: key}
+}
+
+func (s *LocalStorage) Upload(r io.Reader, size int64, filename string, contentType string) (*ObjectInfo, error) {
+	return s.Put(newKey(filename), r, size)
+}
+
+// Put writes r to key, replacing whatever is there. The file appears only
+// once it is complete, so a Stat mid-upload never sees a partial file.
+func (s *LocalStorage) Put(key string, r io.Reader, size int64) (*ObjectInfo, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	filePath := s.path(key)
+
+	dst, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	written, err := io.Copy(dst, r)
+	if err == nil && size >= 0 && written != size {
+		err = fmt.Errorf("%w: got %d bytes, expected %d", ErrSizeMismatch, written, size)
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp files are 0600 — match os.Create so the file server can read it
+		if err = os.Chmod(dst.Name(), 0644); err == nil {
+			err = os.Rename(dst.Name(), filePath)
+		}
+	}
+	if err != nil {
+		// Never leave a partial file behind
+		os.Remove(dst.Name())
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return s.Stat(key)
+}
+
+// PresignUpload returns an HMAC-signed URL on this API (LocalUploadPath).
+// The signature covers the key, size, content type and expiry.
+func (s *LocalStorage) PresignUpload(filename string, contentType string, size int64, ttl time.Duration) (*PresignedUpload, error) {
+	key := newKey(filename)
+	expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+
+	query := url.Values{
+		"size":         {strconv.FormatInt(size, 10)},
+		"content_type": {contentType},
+		"expires":      {strconv.FormatInt(expiresAt.Unix(), 10)},
+	}
+	query.Set("signature", s.uploadSignature(key, query))
+
+	return &PresignedUpload{
+		Key:       key,
+		URL:       s.BaseURL + LocalUploadPath + key + "?" + query.Encode(),
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expiresAt,
+	}, nil
 }
 
-func (s *LocalStorage) Upload(file multipart.File, filename string, contentType string) (string, error) {
-	// Use UUID as filename — prevents:
-	//   1. Path traversal attacks (../../etc/passwd)
-	//   2. Filename collisions between users
-	//   3. Information leakage (original filenames)
-	ext := filepath.Ext(filename)
-	safeFilename := uuid.New().String() + ext
+// VerifyUpload checks a PresignUpload URL's signature and expiry and
+// returns the size and content type it was issued for, or ErrUploadURL.
+func (s *LocalStorage) VerifyUpload(key string, query url.Values) (int64, string, error) {
+	if err := checkKey(key); err != nil {
+		return 0, "", err
+	}
+	want := s.uploadSignature(key, query)
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(want)) {
+		return 0, "", ErrUploadURL
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return 0, "", ErrUploadURL
+	}
+	size, err := strconv.ParseInt(query.Get("size"), 10, 64)
+	if err != nil {
+		return 0, "", ErrUploadURL
+	}
+	return size, query.Get("content_type"), nil
+}
 
-	filePath := filepath.Join(s.UploadDir, safeFilename)
+func (s *LocalStorage) uploadSignature(key string, query url.Values) string {
+	mac := hmac.New(sha256.New, s.SigningKey)
+	mac.Write([]byte(strings.Join([]string{
+		key, query.Get("size"), query.Get("content_type"), query.Get("expires"),
+	}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
 
-	dst, err := os.Create(filePath)
+func (s *LocalStorage) Open(key string, offset, length int64) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := s.Stat(key)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+		return nil, nil, err
+	}
+	if offset < 0 || (offset > 0 && offset >= info.Size) {
+		return nil, nil, fmt.Errorf("%w: offset %d, size %d", ErrInvalidRange, offset, info.Size)
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		return nil, nil, localError(err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, err
 	}
+	if length <= 0 {
+		return f, info, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, info, nil
+}
 
-	// BaseURL comes from env — works in any environment without code changes
-	// Dev:  BASE_URL=http://localhost:8083
-	// Prod: BASE_URL=https://api.yourproduct.com
-	fileURL := fmt.Sprintf("%s/uploads/%s", s.BaseURL, safeFilename)
-	return fileURL, nil
+func (s *LocalStorage) Stat(key string) (*ObjectInfo, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(s.path(key))
+	if err != nil {
+		return nil, localError(err)
+	}
+	if fi.IsDir() {
+		return nil, ErrNotFound
+	}
+	return s.objectInfo(key, fi), nil
 }
 
-// ── S3 Storage stub ───────────────────────────────────────────────────────────
+func (s *LocalStorage) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
 
-// s3.go
-// Infra team fills this in when moving off local storage.
-// Handler and service code require zero changes.
+func (s *LocalStorage) List(prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	err := filepath.WalkDir(s.UploadDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		// Skip uploads still being written by Put
+		if strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.UploadDir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.objectInfo(key, fi))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
 
-type S3Storage struct {
-	Bucket string
-	Region string
+func (s *LocalStorage) Exists(key string) (bool, error) {
+	_, err := s.Stat(key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
-func NewS3Storage(bucket, region string) *S3Storage {
-	return &S3Storage{Bucket: bucket, Region: region}
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.UploadDir, filepath.FromSlash(key))
+}
+
+// objectInfo leaves ContentType empty: files on disk don't record one, and
+// LocalUpload already refuses a PUT whose type differs from the signed one.
+func (s *LocalStorage) objectInfo(key string, fi fs.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key: key,
+		// BaseURL comes from env — works in any environment without code changes
+		// Dev:  BASE_URL=http://localhost:8083
+		// Prod: BASE_URL=https://api.yourproduct.com
+		URL:          fmt.Sprintf("%s/uploads/%s", s.BaseURL, key),
+		Size:         fi.Size(),
+		LastModified: fi.ModTime().UTC(),
+	}
+}
+
+func localError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
 }
 
-func (s *S3Storage) Upload(file multipart.File, filename string, contentType string) (string, error) {
-	// TODO (infra team): implement with AWS SDK v2
-	//
-	// cfg, _ := config.LoadDefaultConfig(context.TODO(), config.WithRegion(s.Region))
-	// client := s3.NewFromConfig(cfg)
-	// uploader := manager.NewUploader(client)
-	//
-	// result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
-	//     Bucket:      aws.String(s.Bucket),
-	//     Key:         aws.String(filename),
-	//     Body:        file,
-	//     ContentType: aws.String(contentType),
-	// })
-	// return result.Location, err
+// ── Helpers ───────────────────────────────────────────────────────────────────
 
-	return "", fmt.Errorf("S3 storage not yet configured — set STORAGE_TYPE=local or implement S3")
+// seekable turns r into something that can be re-read from the start — S3
+// signs the payload before sending it and may retry. Files and in-memory
+// uploads already are; anything else is spooled to a temp file. cleanup
+// removes that file.
+func seekable(r io.Reader, size int64) (*io.SectionReader, func(), error) {
+	noop := func() {}
+
+	if ra, ok := r.(io.ReaderAt); ok {
+		if seeker, ok := r.(io.Seeker); ok {
+			end, err := seeker.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, noop, err
+			}
+			if size >= 0 && end != size {
+				return nil, noop, fmt.Errorf("%w: got %d bytes, expected %d", ErrSizeMismatch, end, size)
+			}
+			return io.NewSectionReader(ra, 0, end), noop, nil
+		}
+		if size >= 0 {
+			return io.NewSectionReader(ra, 0, size), noop, nil
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "editor-upload-*")
+	if err != nil {
+		return nil, noop, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	written, err := io.Copy(tmp, r)
+	if err == nil && size >= 0 && written != size {
+		err = fmt.Errorf("%w: got %d bytes, expected %d", ErrSizeMismatch, written, size)
+	}
+	if err != nil {
+		cleanup()
+		return nil, noop, err
+	}
+	return io.NewSectionReader(tmp, 0, written), cleanup, nil
 }
@@ -0,0 +1,238 @@
+// internal/timeline/diff.go
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"editor-backend/internal/models"
+)
+
+// Diff is a structural comparison of two timelines, keyed by clip ID.
+//
+// A clip present in both versions can show up in several buckets — e.g. a clip
+// dragged to a new spot AND shortened is both moved and trimmed.
+type Diff struct {
+	FromVersion int `json:"from_version"`
+	ToVersion   int `json:"to_version"`
+
+	Added    []ClipRef    `json:"added"`
+	Removed  []ClipRef    `json:"removed"`
+	Moved    []ClipMove   `json:"moved"`
+	Trimmed  []ClipTrim   `json:"trimmed"`
+	Modified []ClipChange `json:"modified"` // any other field (text, style, src, ...)
+
+	TransitionsAdded   []TransitionRef `json:"transitions_added"`
+	TransitionsRemoved []TransitionRef `json:"transitions_removed"`
+}
+
+// ClipRef identifies a clip and where it sits.
+type ClipRef struct {
+	ClipID  string  `json:"clip_id"`
+	TrackID string  `json:"track_id"`
+	Type    string  `json:"type,omitempty"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+}
+
+// ClipMove — a clip changed track or its start time.
+type ClipMove struct {
+	ClipID string  `json:"clip_id"`
+	From   ClipRef `json:"from"`
+	To     ClipRef `json:"to"`
+}
+
+// ClipTrim — the source in/out points or the clip length changed.
+type ClipTrim struct {
+	ClipID        string  `json:"clip_id"`
+	FromTrimStart float64 `json:"from_trim_start"`
+	FromTrimEnd   float64 `json:"from_trim_end"`
+	ToTrimStart   float64 `json:"to_trim_start"`
+	ToTrimEnd     float64 `json:"to_trim_end"`
+	FromDuration  float64 `json:"from_duration"`
+	ToDuration    float64 `json:"to_duration"`
+}
+
+// ClipChange lists which other clip fields differ.
+type ClipChange struct {
+	ClipID string   `json:"clip_id"`
+	Fields []string `json:"fields"`
+}
+
+// TransitionRef identifies a transition between two clips.
+type TransitionRef struct {
+	FromClipID string `json:"fromClipId"`
+	ToClipID   string `json:"toClipId"`
+	Type       string `json:"type"`
+}
+
+// Fields compared by dedicated buckets (or UI-only state) — excluded from Modified.
+var positionalFields = map[string]bool{
+	"clip_id": true, "track_id": true,
+	"start": true, "end": true, "duration": true,
+	"trim_start": true, "trim_end": true,
+}
+
+type located struct {
+	ref  ClipRef
+	clip models.Clip
+}
+
+// Compare diffs two stored timelines.
+func Compare(from, to *models.Timeline) *Diff {
+	diff := &Diff{
+		Added:              []ClipRef{},
+		Removed:            []ClipRef{},
+		Moved:              []ClipMove{},
+		Trimmed:            []ClipTrim{},
+		Modified:           []ClipChange{},
+		TransitionsAdded:   []TransitionRef{},
+		TransitionsRemoved: []TransitionRef{},
+	}
+
+	before := indexClips(from)
+	after := indexClips(to)
+
+	for _, id := range sortedKeys(after) {
+		a := after[id]
+		b, existed := before[id]
+		if !existed {
+			diff.Added = append(diff.Added, a.ref)
+			continue
+		}
+
+		if a.ref.TrackID != b.ref.TrackID || a.ref.Start != b.ref.Start {
+			diff.Moved = append(diff.Moved, ClipMove{ClipID: id, From: b.ref, To: a.ref})
+		}
+
+		if b.clip.Length() != a.clip.Length() || b.clip.TrimStart != a.clip.TrimStart || b.clip.TrimEnd != a.clip.TrimEnd {
+			diff.Trimmed = append(diff.Trimmed, ClipTrim{
+				ClipID:        id,
+				FromTrimStart: b.clip.TrimStart, FromTrimEnd: b.clip.TrimEnd,
+				ToTrimStart: a.clip.TrimStart, ToTrimEnd: a.clip.TrimEnd,
+				FromDuration: b.clip.Length(), ToDuration: a.clip.Length(),
+			})
+		}
+
+		if fields := changedFields(b.clip, a.clip); len(fields) > 0 {
+			diff.Modified = append(diff.Modified, ClipChange{ClipID: id, Fields: fields})
+		}
+	}
+
+	for _, id := range sortedKeys(before) {
+		if _, still := after[id]; !still {
+			diff.Removed = append(diff.Removed, before[id].ref)
+		}
+	}
+
+	beforeT := indexTransitions(from)
+	afterT := indexTransitions(to)
+	for _, key := range sortedKeys(afterT) {
+		if prev, ok := beforeT[key]; !ok || prev.Type != afterT[key].Type {
+			diff.TransitionsAdded = append(diff.TransitionsAdded, afterT[key])
+		}
+	}
+	for _, key := range sortedKeys(beforeT) {
+		if next, ok := afterT[key]; !ok || next.Type != beforeT[key].Type {
+			diff.TransitionsRemoved = append(diff.TransitionsRemoved, beforeT[key])
+		}
+	}
+
+	return diff
+}
+
+// indexClips flattens tracks → clips keyed by clip ID. Tracks without a
+// track_id get the same fallback the UI uses in editorStore.loadTimeline.
+func indexClips(tl *models.Timeline) map[string]located {
+	out := map[string]located{}
+	if tl == nil {
+		return out
+	}
+
+	for tIdx, track := range tl.Tracks {
+		trackID := track.TrackID
+		if trackID == "" {
+			trackType := track.Type
+			if trackType == "" {
+				trackType = models.TrackTypeVideo
+			}
+			trackID = fmt.Sprintf("track_%s_%d", trackType, tIdx)
+		}
+
+		for cIdx, clip := range track.Clips {
+			id := clip.ClipID
+			if id == "" {
+				id = fmt.Sprintf("%s#%d", trackID, cIdx)
+			}
+			out[id] = located{
+				ref: ClipRef{
+					ClipID:  id,
+					TrackID: trackID,
+					Type:    clip.Type,
+					Start:   clip.Start,
+					End:     clip.End,
+				},
+				clip: clip,
+			}
+		}
+	}
+	return out
+}
+
+func indexTransitions(tl *models.Timeline) map[string]TransitionRef {
+	out := map[string]TransitionRef{}
+	if tl == nil {
+		return out
+	}
+	for _, t := range tl.Transitions {
+		if t.Type == "" || t.Type == "none" {
+			continue
+		}
+		out[t.FromClipID+"→"+t.ToClipID] = TransitionRef{
+			FromClipID: t.FromClipID,
+			ToClipID:   t.ToClipID,
+			Type:       t.Type,
+		}
+	}
+	return out
+}
+
+// changedFields compares clips by their JSON form so field names in the diff
+// match what the UI sends.
+func changedFields(before, after models.Clip) []string {
+	b, a := clipFields(before), clipFields(after)
+
+	var fields []string
+	seen := map[string]bool{}
+	for _, m := range []map[string]interface{}{b, a} {
+		for k := range m {
+			if seen[k] || positionalFields[k] {
+				continue
+			}
+			seen[k] = true
+			if !reflect.DeepEqual(b[k], a[k]) {
+				fields = append(fields, k)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func clipFields(c models.Clip) map[string]interface{} {
+	raw, _ := json.Marshal(c)
+	m := map[string]interface{}{}
+	json.Unmarshal(raw, &m)
+	return m
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,357 @@
+// internal/timeline/ops.go
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"editor-backend/internal/models"
+)
+
+// Operation types sent by collaborating clients and POST .../operations.
+const (
+	OpAddClip       = "add_clip"
+	OpMoveClip      = "move_clip"
+	OpTrimClip      = "trim_clip"
+	OpDeleteClip    = "delete_clip"
+	OpUpdateClip    = "update_clip"
+	OpSplitClip     = "split_clip"
+	OpTrimIn        = "trim_in"
+	OpTrimOut       = "trim_out"
+	OpRippleDelete  = "ripple_delete"
+	OpInsertGap     = "insert_gap"
+	OpDuplicateClip = "duplicate_clip"
+)
+
+var (
+	ErrUnknownOp     = errors.New("unknown operation type")
+	ErrClipNotFound  = errors.New("clip not found")
+	ErrClipExists    = errors.New("clip_id already exists")
+	ErrTrackNotFound = errors.New("track not found")
+	ErrTrackType     = errors.New("clip type does not match the track")
+	ErrSplitOutside  = errors.New("split point must be strictly inside the clip")
+)
+
+// Op is one edit to a timeline. Clips are addressed by clip_id and tracks by
+// track_id, never by index, so ops from different users commute unless they
+// touch the same clip.
+//
+//	add_clip     track_id, clip
+//	move_clip    clip_id, start, [track_id]      — keeps the clip's length
+//	trim_clip    clip_id, any of start / end / trim_start / trim_end
+//	delete_clip  clip_id                         — drops its transitions too
+//	update_clip  clip_id, changes                — text, textStyle, position, size, transition, ...
+//
+//	split_clip      clip_id, at, [new_clip_id]     — left half keeps clip_id
+//	trim_in         clip_id, start                 — in point; the source stays in sync
+//	trim_out        clip_id, end                   — out point
+//	ripple_delete   clip_id, [all_tracks]          — later clips close the gap
+//	insert_gap      at, duration, [track_id]       — no track_id → every track
+//	duplicate_clip  clip_id, [new_clip_id, start, track_id] — default: right after the original
+//
+// Ops that create a clip fill in new_clip_id when it is omitted, so the op
+// as stored replays to the same timeline.
+type Op struct {
+	Type    string       `json:"type"`
+	ClipID  string       `json:"clip_id,omitempty"`
+	TrackID string       `json:"track_id,omitempty"`
+	Clip    *models.Clip `json:"clip,omitempty"`
+
+	Start     *float64 `json:"start,omitempty"`
+	End       *float64 `json:"end,omitempty"`
+	TrimStart *float64 `json:"trim_start,omitempty"`
+	TrimEnd   *float64 `json:"trim_end,omitempty"`
+
+	NewClipID string   `json:"new_clip_id,omitempty"`
+	At        *float64 `json:"at,omitempty"`
+	Duration  *float64 `json:"duration,omitempty"`
+	AllTracks bool     `json:"all_tracks,omitempty"`
+
+	// Clip fields to overwrite, in the clip's own JSON shape. Nested objects
+	// (textStyle, position, size) are merged field by field.
+	Changes json.RawMessage `json:"changes,omitempty"`
+}
+
+// Apply performs op on tl in place. The result is not validated — callers run
+// validation.ValidateTimeline before persisting. Generated clip IDs are
+// written back into op.
+func Apply(tl *models.Timeline, op *Op) error {
+	switch op.Type {
+	case OpAddClip:
+		if op.Clip == nil || op.Clip.ClipID == "" {
+			return fmt.Errorf("%s: clip with a clip_id is required", op.Type)
+		}
+		if existing, _ := tl.FindClip(op.Clip.ClipID); existing != nil {
+			return ErrClipExists
+		}
+		track := findTrack(tl, op.TrackID)
+		if track == nil {
+			return ErrTrackNotFound
+		}
+		if op.Clip.Type != "" && op.Clip.Type != track.Type {
+			return ErrTrackType
+		}
+		track.Clips = append(track.Clips, *op.Clip)
+
+	case OpMoveClip:
+		if op.Start == nil {
+			return fmt.Errorf("%s: start is required", op.Type)
+		}
+		clip, ti := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		length := clip.Length()
+		clip.Start, clip.End = *op.Start, *op.Start+length
+
+		if op.TrackID != "" && op.TrackID != tl.Tracks[ti].TrackID {
+			dest := findTrack(tl, op.TrackID)
+			if dest == nil {
+				return ErrTrackNotFound
+			}
+			if clip.KindIn(tl.Tracks[ti]) != dest.Type {
+				return ErrTrackType
+			}
+			moved := *clip
+			removeClip(&tl.Tracks[ti], op.ClipID)
+			dest.Clips = append(dest.Clips, moved)
+		}
+
+	case OpTrimClip:
+		clip, _ := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		if op.Start == nil && op.End == nil && op.TrimStart == nil && op.TrimEnd == nil {
+			return fmt.Errorf("%s: one of start, end, trim_start, trim_end is required", op.Type)
+		}
+		setIf(&clip.Start, op.Start)
+		setIf(&clip.End, op.End)
+		setIf(&clip.TrimStart, op.TrimStart)
+		setIf(&clip.TrimEnd, op.TrimEnd)
+
+	case OpDeleteClip:
+		_, ti := tl.FindClip(op.ClipID)
+		if ti < 0 {
+			return ErrClipNotFound
+		}
+		deleteClip(tl, ti, op.ClipID)
+
+	case OpUpdateClip:
+		clip, _ := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		if len(op.Changes) == 0 {
+			return fmt.Errorf("%s: changes is required", op.Type)
+		}
+		id := clip.ClipID
+		if err := json.Unmarshal(op.Changes, clip); err != nil {
+			return fmt.Errorf("%s: invalid changes: %w", op.Type, err)
+		}
+		clip.ClipID = id // identity is not editable
+
+	case OpSplitClip:
+		if op.At == nil {
+			return fmt.Errorf("%s: at is required", op.Type)
+		}
+		clip, ti := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		at := *op.At
+		if at <= clip.Start || at >= clip.End {
+			return ErrSplitOutside
+		}
+		if err := claimClipID(tl, op); err != nil {
+			return err
+		}
+
+		// Same arithmetic as splitClip in editorStore.js
+		offset := at - clip.Start
+		right := *clip
+		right.ClipID = op.NewClipID
+		right.Start = at
+		right.TrimStart = clip.TrimStart + offset
+		clip.End = at
+		clip.TrimEnd = clip.TrimStart + offset
+
+		// The outgoing transition now leaves from the right half
+		for i := range tl.Transitions {
+			if tl.Transitions[i].FromClipID == op.ClipID {
+				tl.Transitions[i].FromClipID = right.ClipID
+			}
+		}
+		insertClipAfter(&tl.Tracks[ti], op.ClipID, right)
+
+	case OpTrimIn:
+		if op.Start == nil {
+			return fmt.Errorf("%s: start is required", op.Type)
+		}
+		clip, _ := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		clip.TrimStart += *op.Start - clip.Start
+		clip.Start = *op.Start
+
+	case OpTrimOut:
+		if op.End == nil {
+			return fmt.Errorf("%s: end is required", op.Type)
+		}
+		clip, _ := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		clip.End = *op.End
+		clip.TrimEnd = clip.TrimStart + clip.Length()
+
+	case OpRippleDelete:
+		clip, ti := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		end, length := clip.End, clip.Length()
+		deleteClip(tl, ti, op.ClipID)
+		for i := range tl.Tracks {
+			if i == ti || op.AllTracks {
+				shiftClips(&tl.Tracks[i], end, -length)
+			}
+		}
+
+	case OpInsertGap:
+		if op.At == nil || op.Duration == nil {
+			return fmt.Errorf("%s: at and duration are required", op.Type)
+		}
+		if *op.At < 0 || *op.Duration <= 0 {
+			return fmt.Errorf("%s: at must be >= 0 and duration > 0", op.Type)
+		}
+		if op.TrackID != "" {
+			track := findTrack(tl, op.TrackID)
+			if track == nil {
+				return ErrTrackNotFound
+			}
+			shiftClips(track, *op.At, *op.Duration)
+		} else {
+			for i := range tl.Tracks {
+				shiftClips(&tl.Tracks[i], *op.At, *op.Duration)
+			}
+		}
+
+	case OpDuplicateClip:
+		clip, ti := tl.FindClip(op.ClipID)
+		if clip == nil {
+			return ErrClipNotFound
+		}
+		dest := &tl.Tracks[ti]
+		if op.TrackID != "" && op.TrackID != dest.TrackID {
+			dest = findTrack(tl, op.TrackID)
+			if dest == nil {
+				return ErrTrackNotFound
+			}
+			if clip.KindIn(tl.Tracks[ti]) != dest.Type {
+				return ErrTrackType
+			}
+		}
+		if err := claimClipID(tl, op); err != nil {
+			return err
+		}
+
+		dup := *clip
+		dup.ClipID = op.NewClipID
+		start := clip.End
+		if op.Start != nil {
+			start = *op.Start
+		}
+		dup.Start, dup.End = start, start+clip.Length()
+		dest.Clips = append(dest.Clips, dup)
+
+	default:
+		return ErrUnknownOp
+	}
+
+	// Growing the timeline is implicit; shrinking is left to the UI
+	if end := tl.End(); end > tl.Duration {
+		tl.Duration = end
+	}
+	return nil
+}
+
+func findTrack(tl *models.Timeline, trackID string) *models.Track {
+	for i := range tl.Tracks {
+		if tl.Tracks[i].TrackID == trackID {
+			return &tl.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// claimClipID checks op.NewClipID is free, or generates one the way
+// editorStore.js does (<clip_id>_<ms>).
+func claimClipID(tl *models.Timeline, op *Op) error {
+	if op.NewClipID != "" {
+		if existing, _ := tl.FindClip(op.NewClipID); existing != nil {
+			return ErrClipExists
+		}
+		return nil
+	}
+	for n := time.Now().UnixMilli(); ; n++ {
+		id := op.ClipID + "_" + strconv.FormatInt(n, 10)
+		if existing, _ := tl.FindClip(id); existing == nil {
+			op.NewClipID = id
+			return nil
+		}
+	}
+}
+
+// deleteClip removes a clip and the transitions that reference it.
+func deleteClip(tl *models.Timeline, ti int, clipID string) {
+	removeClip(&tl.Tracks[ti], clipID)
+	transitions := tl.Transitions[:0]
+	for _, t := range tl.Transitions {
+		if t.FromClipID != clipID && t.ToClipID != clipID {
+			transitions = append(transitions, t)
+		}
+	}
+	tl.Transitions = transitions
+}
+
+// shiftClips moves every clip starting at or after `from` by delta seconds.
+// A clip spanning `from` stays put — split it first.
+func shiftClips(track *models.Track, from, delta float64) {
+	for i := range track.Clips {
+		if track.Clips[i].Start >= from {
+			track.Clips[i].Start += delta
+			track.Clips[i].End += delta
+		}
+	}
+}
+
+func insertClipAfter(track *models.Track, clipID string, clip models.Clip) {
+	for i := range track.Clips {
+		if track.Clips[i].ClipID == clipID {
+			track.Clips = append(track.Clips[:i+1], append([]models.Clip{clip}, track.Clips[i+1:]...)...)
+			return
+		}
+	}
+	track.Clips = append(track.Clips, clip)
+}
+
+func removeClip(track *models.Track, clipID string) {
+	clips := track.Clips[:0]
+	for _, c := range track.Clips {
+		if c.ClipID != clipID {
+			clips = append(clips, c)
+		}
+	}
+	track.Clips = clips
+}
+
+func setIf(dst *float64, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
@@ -0,0 +1,178 @@
+// internal/timeline/ops_test.go
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"editor-backend/internal/models"
+)
+
+// opsBase: three cut video clips joined by transitions, music under them,
+// a title and an outro, and an empty second audio track.
+const opsBase = `{
+	"duration": 12,
+	"tracks": [
+		{"track_id": "v1", "type": "video", "clips": [
+			{"clip_id": "a", "src": "/uploads/a.mp4", "start": 0, "end": 4, "trim_start": 0, "trim_end": 4},
+			{"clip_id": "b", "src": "/uploads/b.mp4", "start": 4, "end": 10, "trim_start": 2, "trim_end": 8},
+			{"clip_id": "c", "src": "/uploads/c.mp4", "start": 10, "end": 12, "trim_start": 0, "trim_end": 2}
+		]},
+		{"track_id": "m1", "type": "audio", "clips": [
+			{"clip_id": "m", "src": "/uploads/m.mp3", "start": 0, "end": 12}
+		]},
+		{"track_id": "m2", "type": "audio", "clips": []},
+		{"track_id": "t1", "type": "text", "clips": [
+			{"clip_id": "title", "start": 1, "end": 3, "text": "Hi"},
+			{"clip_id": "outro", "start": 11, "end": 12, "text": "Bye"}
+		]}
+	],
+	"transitions": [
+		{"fromClipId": "a", "toClipId": "b", "type": "fade", "duration": 0.5},
+		{"fromClipId": "b", "toClipId": "c", "type": "crossfade", "duration": 0.5}
+	]
+}`
+
+// layout summarizes a timeline as one line per track plus transitions:
+// "v1: a 0-4/0-4, ..." where a/b after the slash is trim_start-trim_end.
+func layout(tl *models.Timeline) string {
+	var lines []string
+	for _, track := range tl.Tracks {
+		clips := make([]string, len(track.Clips))
+		for i, c := range track.Clips {
+			clips[i] = fmt.Sprintf("%s %g-%g/%g-%g", c.ClipID, c.Start, c.End, c.TrimStart, c.TrimEnd)
+		}
+		lines = append(lines, track.TrackID+": "+strings.Join(clips, ", "))
+	}
+	transitions := make([]string, len(tl.Transitions))
+	for i, t := range tl.Transitions {
+		transitions[i] = t.FromClipID + ">" + t.ToClipID
+	}
+	lines = append(lines, "transitions: "+strings.Join(transitions, " "), fmt.Sprintf("duration: %g", tl.Duration))
+	return strings.Join(lines, "\n")
+}
+
+func TestApply(t *testing.T) {
+	const (
+		v1  = "v1: a 0-4/0-4, b 4-10/2-8, c 10-12/0-2"
+		m1  = "m1: m 0-12/0-12"
+		m2  = "m2: "
+		t1  = "t1: title 1-3/0-0, outro 11-12/0-0"
+		trs = "transitions: a>b b>c"
+		dur = "duration: 12"
+	)
+	lines := func(l ...string) string { return strings.Join(l, "\n") }
+
+	cases := []struct {
+		name string
+		op   string
+		want string // layout after the op
+		err  error  // or the error it must fail with
+	}{
+		// split_clip — the left half keeps the id, trims follow the cut, and
+		// the outgoing transition moves to the right half
+		{"split", `{"type":"split_clip","clip_id":"b","at":6,"new_clip_id":"b2"}`,
+			lines("v1: a 0-4/0-4, b 4-6/2-4, b2 6-10/4-8, c 10-12/0-2", m1, m2, t1, "transitions: a>b b2>c", dur), nil},
+		{"split untrimmed clip", `{"type":"split_clip","clip_id":"m","at":5,"new_clip_id":"m_r"}`,
+			lines(v1, "m1: m 0-5/0-5, m_r 5-12/5-12", m2, t1, trs, dur), nil},
+		{"split at the start", `{"type":"split_clip","clip_id":"b","at":4}`, "", ErrSplitOutside},
+		{"split at the end", `{"type":"split_clip","clip_id":"b","at":10}`, "", ErrSplitOutside},
+		{"split onto a taken id", `{"type":"split_clip","clip_id":"b","at":6,"new_clip_id":"c"}`, "", ErrClipExists},
+
+		// trim_in / trim_out — the source in/out points move with the edge
+		{"trim_in", `{"type":"trim_in","clip_id":"b","start":5}`,
+			lines("v1: a 0-4/0-4, b 5-10/3-8, c 10-12/0-2", m1, m2, t1, trs, dur), nil},
+		{"trim_in extends", `{"type":"trim_in","clip_id":"b","start":3}`,
+			lines("v1: a 0-4/0-4, b 3-10/1-8, c 10-12/0-2", m1, m2, t1, trs, dur), nil},
+		{"trim_out", `{"type":"trim_out","clip_id":"b","end":9}`,
+			lines("v1: a 0-4/0-4, b 4-9/2-7, c 10-12/0-2", m1, m2, t1, trs, dur), nil},
+		{"trim_out untrimmed clip", `{"type":"trim_out","clip_id":"m","end":8}`,
+			lines(v1, "m1: m 0-8/0-8", m2, t1, trs, dur), nil},
+		{"trim_in without start", `{"type":"trim_in","clip_id":"b"}`, "", nil},
+
+		// ripple_delete — later clips close the gap, on every track with all_tracks
+		{"ripple_delete", `{"type":"ripple_delete","clip_id":"b"}`,
+			lines("v1: a 0-4/0-4, c 4-6/0-2", m1, m2, t1, "transitions: ", dur), nil},
+		{"ripple_delete all_tracks", `{"type":"ripple_delete","clip_id":"b","all_tracks":true}`,
+			lines("v1: a 0-4/0-4, c 4-6/0-2", m1, m2, "t1: title 1-3/0-0, outro 5-6/0-0", "transitions: ", dur), nil},
+		{"ripple_delete unknown clip", `{"type":"ripple_delete","clip_id":"zz"}`, "", ErrClipNotFound},
+
+		// insert_gap — clips starting at or after `at` move; one spanning it stays
+		{"insert_gap", `{"type":"insert_gap","at":4,"duration":2}`,
+			lines("v1: a 0-4/0-4, b 6-12/2-8, c 12-14/0-2", m1, m2, "t1: title 1-3/0-0, outro 13-14/0-0", trs, "duration: 14"), nil},
+		{"insert_gap one track", `{"type":"insert_gap","at":10,"duration":1,"track_id":"v1"}`,
+			lines("v1: a 0-4/0-4, b 4-10/2-8, c 11-13/0-2", m1, m2, t1, trs, "duration: 13"), nil},
+		{"insert_gap unknown track", `{"type":"insert_gap","at":1,"duration":1,"track_id":"zz"}`, "", ErrTrackNotFound},
+		{"insert_gap without duration", `{"type":"insert_gap","at":1,"duration":0}`, "", nil},
+
+		// duplicate_clip and move_clip — across tracks only of the same type
+		{"duplicate", `{"type":"duplicate_clip","clip_id":"a","new_clip_id":"a2"}`,
+			lines("v1: a 0-4/0-4, b 4-10/2-8, c 10-12/0-2, a2 4-8/0-4", m1, m2, t1, trs, dur), nil},
+		{"duplicate to another track", `{"type":"duplicate_clip","clip_id":"m","new_clip_id":"m2x","track_id":"m2","start":3}`,
+			lines(v1, m1, "m2: m2x 3-15/0-12", t1, trs, "duration: 15"), nil},
+		{"duplicate across types", `{"type":"duplicate_clip","clip_id":"title","track_id":"m1"}`, "", ErrTrackType},
+		{"duplicate onto a taken id", `{"type":"duplicate_clip","clip_id":"a","new_clip_id":"b"}`, "", ErrClipExists},
+		{"move to another track", `{"type":"move_clip","clip_id":"m","start":2,"track_id":"m2"}`,
+			lines(v1, "m1: ", "m2: m 2-14/0-12", t1, trs, "duration: 14"), nil},
+		{"move across types", `{"type":"move_clip","clip_id":"title","start":0,"track_id":"v1"}`, "", ErrTrackType},
+		{"move to an unknown track", `{"type":"move_clip","clip_id":"a","start":0,"track_id":"zz"}`, "", ErrTrackNotFound},
+
+		{"unknown op", `{"type":"explode","clip_id":"a"}`, "", ErrUnknownOp},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tl, err := Decode([]byte(opsBase))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var op Op
+			if err := json.Unmarshal([]byte(tc.op), &op); err != nil {
+				t.Fatal(err)
+			}
+
+			err = Apply(tl, &op)
+			if tc.want == "" {
+				if err == nil || tc.err != nil && !errors.Is(err, tc.err) {
+					t.Fatalf("err = %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := layout(tl); got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+// Ops that create a clip without new_clip_id get one generated and written
+// back into the op, so replaying the stored op creates the same clip.
+func TestApplyGeneratesClipIDs(t *testing.T) {
+	for _, raw := range []string{
+		`{"type":"split_clip","clip_id":"b","at":6}`,
+		`{"type":"duplicate_clip","clip_id":"b"}`,
+	} {
+		tl, _ := Decode([]byte(opsBase))
+		var op Op
+		json.Unmarshal([]byte(raw), &op)
+		if err := Apply(tl, &op); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(op.NewClipID, "b_") {
+			t.Fatalf("%s: new_clip_id = %q, want b_<ms>", op.Type, op.NewClipID)
+		}
+
+		replayed, _ := Decode([]byte(opsBase))
+		if err := Apply(replayed, &op); err != nil {
+			t.Fatal(err)
+		}
+		if layout(replayed) != layout(tl) {
+			t.Fatalf("%s: replay differs:\n%s\nvs\n%s", op.Type, layout(replayed), layout(tl))
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// internal/timeline/schema.go
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"editor-backend/internal/models"
+)
+
+// CurrentSchemaVersion is the timeline shape this server writes.
+//
+//	0 — unversioned: whatever the UI or the backend handlers happened to write
+//	1 — UI shape: every clip has clip_id / trim_start / trim_end, every track
+//	    has track_id, highlight reels are laid out back-to-back
+const CurrentSchemaVersion = 1
+
+var ErrSchemaTooNew = errors.New("timeline was written by a newer server")
+
+// upgrade rewrites a raw timeline from version N to N+1 in place.
+type upgrade func(tl map[string]interface{}) error
+
+// upgrades is keyed by the version an upgrade starts FROM. Adding a format
+// change = bump CurrentSchemaVersion and register the step here; old rows
+// are upgraded on read and rewritten in the new shape on their next save.
+var upgrades = map[int]upgrade{
+	0: upgradeV0,
+}
+
+// Decode parses a stored or submitted timeline, upgrading it to
+// CurrentSchemaVersion first. Empty input yields an empty timeline.
+func Decode(raw []byte) (*models.Timeline, error) {
+	tl := &models.Timeline{SchemaVersion: CurrentSchemaVersion, Tracks: []models.Track{}}
+	if len(raw) == 0 || string(raw) == "null" {
+		return tl, nil
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("invalid timeline JSON: %w", err)
+	}
+
+	if err := Upgrade(doc); err != nil {
+		return nil, err
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(upgraded, tl); err != nil {
+		return nil, fmt.Errorf("invalid timeline: %w", err)
+	}
+	if tl.Tracks == nil {
+		tl.Tracks = []models.Track{}
+	}
+	return tl, nil
+}
+
+// Upgrade runs every registered step between the document's schema_version
+// and CurrentSchemaVersion.
+func Upgrade(doc map[string]interface{}) error {
+	version := int(num(doc["schema_version"]))
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("%w (schema_version %d, supported %d)", ErrSchemaTooNew, version, CurrentSchemaVersion)
+	}
+
+	for ; version < CurrentSchemaVersion; version++ {
+		step, ok := upgrades[version]
+		if !ok {
+			return fmt.Errorf("no timeline upgrade registered from schema_version %d", version)
+		}
+		if err := step(doc); err != nil {
+			return fmt.Errorf("timeline upgrade from schema_version %d: %w", version, err)
+		}
+		doc["schema_version"] = version + 1
+	}
+	return nil
+}
+
+// ── v0 → v1 ──────────────────────────────────────────────────────────────────
+
+// upgradeV0 normalizes the two backend-written shapes into what the UI store
+// writes. UI-written documents already match and pass through unchanged.
+//
+//	CreateSessionFromClip:  { duration, tracks: [{ type, clips: [{ id, src, start, end, duration }] }] }
+//	CreateHighlightSession: { session_type, target_duration,
+//	                          tracks: [{ type, clips: [{ id, src, start, end, ... }] }] }
+//
+// In highlight reels start/end were positions in the SOURCE video, so those
+// clips become trim_start/trim_end and are laid out back-to-back.
+func upgradeV0(doc map[string]interface{}) error {
+	highlight := str(doc["session_type"]) == "highlight_reel"
+
+	tracks, _ := doc["tracks"].([]interface{})
+	for tIdx, rawTrack := range tracks {
+		track, ok := rawTrack.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("tracks[%d] is not an object", tIdx)
+		}
+
+		trackType := str(track["type"])
+		if trackType == "" {
+			trackType = models.TrackTypeVideo
+			track["type"] = trackType
+		}
+		if str(track["track_id"]) == "" {
+			// Same fallback the UI uses in editorStore.loadTimeline
+			track["track_id"] = fmt.Sprintf("track_%s_%d", trackType, tIdx)
+		}
+
+		clips, _ := track["clips"].([]interface{})
+		var cursor float64
+		for cIdx, rawClip := range clips {
+			clip, ok := rawClip.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("tracks[%d].clips[%d] is not an object", tIdx, cIdx)
+			}
+
+			// Backend-created clips carry "id" instead of "clip_id"
+			legacy := str(clip["clip_id"]) == "" && str(clip["id"]) != ""
+			if legacy {
+				clip["clip_id"] = clip["id"]
+			}
+			delete(clip, "id")
+			if str(clip["clip_id"]) == "" {
+				clip["clip_id"] = fmt.Sprintf("%s_clip_%d", track["track_id"], cIdx)
+			}
+			if str(clip["original_clip_id"]) == "" {
+				clip["original_clip_id"] = clip["clip_id"]
+			}
+
+			start, end := num(clip["start"]), num(clip["end"])
+			if legacy && highlight {
+				clip["trim_start"] = start
+				clip["trim_end"] = end
+				clip["start"] = cursor
+				clip["end"] = cursor + (end - start)
+				cursor += end - start
+				continue
+			}
+
+			if _, ok := clip["trim_start"]; !ok {
+				clip["trim_start"] = 0.0
+			}
+			if _, ok := clip["trim_end"]; !ok && trackType != models.TrackTypeText {
+				clip["trim_end"] = num(clip["trim_start"]) + (end - start)
+			}
+		}
+	}
+
+	// Highlight reels had no top-level duration; never let it be shorter
+	// than the content
+	var maxEnd float64
+	for _, rawTrack := range tracks {
+		track, _ := rawTrack.(map[string]interface{})
+		clips, _ := track["clips"].([]interface{})
+		for _, rawClip := range clips {
+			if clip, ok := rawClip.(map[string]interface{}); ok && num(clip["end"]) > maxEnd {
+				maxEnd = num(clip["end"])
+			}
+		}
+	}
+	if num(doc["duration"]) < maxEnd {
+		doc["duration"] = maxEnd
+	}
+
+	if _, ok := doc["tracks"]; !ok {
+		doc["tracks"] = []interface{}{}
+	}
+	return nil
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// num reads a JSON number — float64 after json.Unmarshal, json.Number if a
+// decoder used UseNumber.
+func num(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	case int:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+// internal/timeline/schema_test.go
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/validation"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/v1 from the v0 fixtures")
+
+// testdata/v0 holds timelines as the old code stored them: the backend's
+// CreateSessionFromClip and CreateHighlightSession documents and what
+// editorStore.js saved before schema_version existed. Each must upgrade to
+// the matching testdata/v1 document.
+func TestDecodeUpgradesV0Fixtures(t *testing.T) {
+	validation.AllowMediaHosts("cdn.example.com")
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "v0", "*.json"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no v0 fixtures: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		t.Run(strings.TrimSuffix(name, ".json"), func(t *testing.T) {
+			raw, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tl, err := Decode(raw)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+
+			got, err := json.MarshalIndent(tl, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join("testdata", "v1", name)
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("upgraded timeline differs from %s:\n%s", golden, got)
+			}
+
+			// The upgraded document is what SaveSession accepts…
+			if err := validation.ValidateTimeline(tl); err != nil {
+				t.Fatalf("upgraded timeline does not validate: %v", err)
+			}
+			// …and reading it back changes nothing
+			again, err := Decode(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if regot, _ := json.MarshalIndent(again, "", "  "); !bytes.Equal(append(regot, '\n'), got) {
+				t.Fatalf("decoding a v1 document changed it:\n%s", regot)
+			}
+		})
+	}
+}
+
+// Highlight reels stored positions in the source video; after the upgrade
+// those are trim points and the clips sit back-to-back from zero.
+func TestDecodeHighlightReelLayout(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "v0", "highlight_reel.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl, err := Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clips := tl.Tracks[0].Clips
+	want := []models.Clip{
+		{ClipID: "clip_a", Start: 0, End: 8, TrimStart: 42, TrimEnd: 50},
+		{ClipID: "clip_b", Start: 8, End: 18.5, TrimStart: 120.5, TrimEnd: 131},
+	}
+	for i, w := range want {
+		c := clips[i]
+		if c.ClipID != w.ClipID || c.Start != w.Start || c.End != w.End || c.TrimStart != w.TrimStart || c.TrimEnd != w.TrimEnd {
+			t.Errorf("clip %d = %s %v–%v trim %v–%v, want %s %v–%v trim %v–%v", i,
+				c.ClipID, c.Start, c.End, c.TrimStart, c.TrimEnd,
+				w.ClipID, w.Start, w.End, w.TrimStart, w.TrimEnd)
+		}
+	}
+	if tl.Duration != 18.5 {
+		t.Errorf("duration = %v, want 18.5 (the laid-out content)", tl.Duration)
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{"newer schema", `{"schema_version": 99, "tracks": []}`, ErrSchemaTooNew},
+		{"not JSON", `{"tracks": [`, nil},
+		{"track not an object", `{"tracks": ["video"]}`, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decode([]byte(tc.raw))
+			if err == nil {
+				t.Fatal("Decode succeeded, want an error")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	for _, raw := range []string{"", "null"} {
+		tl, err := Decode([]byte(raw))
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", raw, err)
+		}
+		if tl.SchemaVersion != CurrentSchemaVersion || tl.Tracks == nil || len(tl.Tracks) != 0 {
+			t.Fatalf("Decode(%q) = %+v, want an empty current timeline", raw, tl)
+		}
+	}
+}
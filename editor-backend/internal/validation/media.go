@@ -0,0 +1,81 @@
+// internal/validation/media.go
+package validation
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// CLIP SOURCES
+// ============================================================================
+//
+// A clip's src ends up as an ffmpeg input on the render worker, so it may
+// only name media this deployment serves: a path on the API's own origin
+// (e.g. /uploads/x.mp4) or an http(s) URL on an allow-listed host — storage,
+// the CDN, MEDIA_ALLOWED_HOSTS. Anything else (file:, internal services,
+// other schemes) would let a saved timeline read it into an export.
+
+var ErrMediaSource = errors.New("must be an /uploads path or an http(s) URL on an allowed media host")
+
+var (
+	mediaHostsMu sync.RWMutex
+	mediaHosts   = map[string]bool{}
+)
+
+// AllowMediaHosts adds hosts clip sources may point at. Entries are URLs
+// ("https://cdn.example.com") or bare hosts, with or without a port; empty
+// entries are ignored. Called once at startup by the API and the worker.
+func AllowMediaHosts(entries ...string) {
+	mediaHostsMu.Lock()
+	defer mediaHostsMu.Unlock()
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "://") {
+			entry = "https://" + entry
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		mediaHosts[strings.ToLower(u.Host)] = true
+	}
+}
+
+// ValidateMediaSrc checks a clip source against the rules above. Returns
+// nil or ErrMediaSource.
+func ValidateMediaSrc(src string) error {
+	u, err := url.Parse(src)
+	if err != nil || u.User != nil {
+		return ErrMediaSource
+	}
+
+	// Relative: a path on our own origin, resolved against BASE_URL
+	if u.Scheme == "" && u.Host == "" {
+		if !strings.HasPrefix(u.Path, "/uploads/") || strings.Contains(u.Path, "..") {
+			return ErrMediaSource
+		}
+		return nil
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrMediaSource
+	}
+	if !mediaHostAllowed(u) {
+		return ErrMediaSource
+	}
+	return nil
+}
+
+// mediaHostAllowed matches host:port first, then the bare hostname — an
+// entry without a port allows any port.
+func mediaHostAllowed(u *url.URL) bool {
+	mediaHostsMu.RLock()
+	defer mediaHostsMu.RUnlock()
+	return mediaHosts[strings.ToLower(u.Host)] || mediaHosts[strings.ToLower(u.Hostname())]
+}
@@ -0,0 +1,53 @@
+// internal/validation/text_style.go
+package validation
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ============================================================================
+// TEXT STYLE
+// ============================================================================
+//
+// textStyle values end up as drawtext options in the render worker's
+// filtergraph. Only values the editor can produce are accepted: the fonts
+// TextPropertiesPanel.jsx offers and plain colors — nothing that could carry
+// filter syntax.
+
+var (
+	ErrFontFamily = errors.New("must be one of the editor's fonts")
+	ErrColor      = errors.New("must be #RRGGBB, #RRGGBBAA, 0xRRGGBB[AA] or a color name")
+)
+
+// AllowedFontFamilies are the fonts the text panel offers.
+var AllowedFontFamilies = map[string]bool{
+	"Arial":           true,
+	"Helvetica":       true,
+	"Times New Roman": true,
+	"Georgia":         true,
+	"Courier New":     true,
+	"Verdana":         true,
+	"Impact":          true,
+}
+
+var (
+	hexColor   = regexp.MustCompile(`^(#|0x)([0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+	colorNamed = regexp.MustCompile(`^[A-Za-z]{3,30}$`) // "white", "transparent" …
+)
+
+// ValidateFontFamily returns nil or ErrFontFamily.
+func ValidateFontFamily(font string) error {
+	if !AllowedFontFamilies[font] {
+		return ErrFontFamily
+	}
+	return nil
+}
+
+// ValidateColor returns nil or ErrColor.
+func ValidateColor(color string) error {
+	if !hexColor.MatchString(color) && !colorNamed.MatchString(color) {
+		return ErrColor
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"editor-backend/internal/models"
+)
+
+var KnownTrackTypes = map[string]bool{
+	models.TrackTypeVideo: true,
+	models.TrackTypeAudio: true,
+	models.TrackTypeText:  true,
+	models.TrackTypeImage: true,
+}
+
+// FieldError points at one invalid value using a JSON-path-like field name,
+// e.g. "tracks[0].clips[2].trim_end".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// TimelineError collects every problem in a timeline — the UI gets the full
+// list in one 422 instead of fixing issues one round-trip at a time.
+type TimelineError struct {
+	Fields []FieldError
+}
+
+func (e *TimelineError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "invalid timeline: " + strings.Join(parts, "; ")
+}
+
+func (e *TimelineError) add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateTimeline checks the structural rules the renderer relies on.
+// Returns nil or a *TimelineError.
+func ValidateTimeline(tl *models.Timeline) error {
+	if tl == nil {
+		return &TimelineError{Fields: []FieldError{{Field: "timeline", Message: "is required"}}}
+	}
+
+	e := &TimelineError{}
+
+	if tl.Duration < 0 {
+		e.add("duration", "must be a non-negative number")
+	}
+
+	clipIDs := map[string]string{} // clip key → field path of first occurrence
+
+	for ti, track := range tl.Tracks {
+		tPath := fmt.Sprintf("tracks[%d]", ti)
+
+		if !KnownTrackTypes[track.Type] {
+			e.add(tPath+".type", "unknown track type %q (allowed: video, audio, text, image)", track.Type)
+		}
+
+		for ci, clip := range track.Clips {
+			cPath := fmt.Sprintf("%s.clips[%d]", tPath, ci)
+
+			key := clip.ClipID
+			if key == "" {
+				e.add(cPath+".clip_id", "is required")
+			} else if first, dup := clipIDs[key]; dup {
+				e.add(cPath+".clip_id", "duplicates %s (%q)", first, key)
+			} else {
+				clipIDs[key] = cPath
+			}
+
+			kind := clip.KindIn(track)
+			if clip.Type != "" && !KnownTrackTypes[clip.Type] {
+				e.add(cPath+".type", "unknown clip type %q", clip.Type)
+			}
+
+			validateClipTiming(e, cPath, clip)
+
+			switch kind {
+			case models.TrackTypeVideo, models.TrackTypeAudio, models.TrackTypeImage:
+				if clip.Src == "" {
+					e.add(cPath+".src", "is required for %s clips", kind)
+				} else if err := ValidateMediaSrc(clip.Src); err != nil {
+					e.add(cPath+".src", "%v", err)
+				}
+			case models.TrackTypeText:
+				validateTextClip(e, cPath, clip)
+			}
+		}
+	}
+
+	for i, t := range tl.Transitions {
+		path := fmt.Sprintf("transitions[%d]", i)
+
+		if !models.TransitionTypes[t.Type] {
+			e.add(path+".type", "unknown transition type %q", t.Type)
+		}
+		if t.Duration < 0 {
+			e.add(path+".duration", "must be a non-negative number")
+		}
+		if _, ok := clipIDs[t.FromClipID]; !ok {
+			e.add(path+".fromClipId", "references unknown clip %q", t.FromClipID)
+		}
+		if _, ok := clipIDs[t.ToClipID]; !ok {
+			e.add(path+".toClipId", "references unknown clip %q", t.ToClipID)
+		}
+	}
+
+	if len(e.Fields) > 0 {
+		return e
+	}
+	return nil
+}
+
+func validateClipTiming(e *TimelineError, path string, clip models.Clip) {
+	if clip.Start < 0 {
+		e.add(path+".start", "must be >= 0")
+	}
+	if clip.End <= clip.Start {
+		e.add(path+".end", "must be greater than start (%g)", clip.Start)
+	}
+	if clip.Duration < 0 {
+		e.add(path+".duration", "must be >= 0")
+	}
+	if clip.TrimStart < 0 {
+		e.add(path+".trim_start", "must be >= 0")
+	}
+	// trim_end is optional — 0 means "not trimmed"
+	if clip.TrimEnd != 0 && clip.TrimEnd <= clip.TrimStart {
+		e.add(path+".trim_end", "must be greater than trim_start (%g)", clip.TrimStart)
+	}
+}
+
+func validateTextClip(e *TimelineError, path string, clip models.Clip) {
+	if len(clip.Text) > 5000 {
+		e.add(path+".text", "too long - maximum 5000 characters")
+	}
+	if s := clip.TextStyle; s != nil {
+		if s.FontSize < 0 || s.FontSize > 1000 {
+			e.add(path+".textStyle.fontSize", "must be between 0 and 1000")
+		}
+		// These become drawtext options at render time — see text_style.go
+		if s.FontFamily != "" && ValidateFontFamily(s.FontFamily) != nil {
+			e.add(path+".textStyle.fontFamily", "%s", ErrFontFamily)
+		}
+		if s.Color != "" && ValidateColor(s.Color) != nil {
+			e.add(path+".textStyle.color", "%s", ErrColor)
+		}
+		if s.BackgroundColor != "" && ValidateColor(s.BackgroundColor) != nil {
+			e.add(path+".textStyle.backgroundColor", "%s", ErrColor)
+		}
+	}
+	if s := clip.Size; s != nil {
+		if s.Width < 0 || s.Height < 0 {
+			e.add(path+".size", "width and height must be >= 0")
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// internal/validation/timeline_test.go
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"editor-backend/internal/models"
+)
+
+func styledTimeline(style *models.TextStyle) *models.Timeline {
+	return &models.Timeline{
+		Duration: 5,
+		Tracks: []models.Track{{
+			Type: models.TrackTypeText,
+			Clips: []models.Clip{{
+				ClipID: "title", Start: 0, End: 5,
+				Text:      "Hello",
+				TextStyle: style,
+			}},
+		}},
+	}
+}
+
+func TestValidateTimelineTextStyle(t *testing.T) {
+	const base = "tracks[0].clips[0].textStyle."
+
+	valid := []*models.TextStyle{
+		nil,
+		{},
+		{FontSize: 48, FontFamily: "Arial", Color: "#FFFFFF", BackgroundColor: "transparent"},
+		{FontFamily: "Times New Roman", Color: "0xFF000080", BackgroundColor: "#00000080"},
+		{Color: "white", BackgroundColor: "black"},
+	}
+	for _, style := range valid {
+		if err := ValidateTimeline(styledTimeline(style)); err != nil {
+			t.Errorf("style %+v: %v", style, err)
+		}
+	}
+
+	invalid := []struct {
+		style *models.TextStyle
+		field string
+	}{
+		{&models.TextStyle{FontFamily: "Comic Sans MS"}, "fontFamily"},
+		{&models.TextStyle{FontFamily: `Arial\:textfile=/proc/self/environ`}, "fontFamily"},
+		{&models.TextStyle{Color: "white:textfile=/etc/passwd"}, "color"},
+		{&models.TextStyle{Color: "#FFF"}, "color"},
+		{&models.TextStyle{Color: "#FFFFFF'"}, "color"},
+		{&models.TextStyle{BackgroundColor: "rgba(0,0,0,0.5)"}, "backgroundColor"},
+		{&models.TextStyle{BackgroundColor: "0x000000,drawbox"}, "backgroundColor"},
+		{&models.TextStyle{FontSize: 2000}, "fontSize"},
+	}
+	for _, tc := range invalid {
+		err := ValidateTimeline(styledTimeline(tc.style))
+		var te *TimelineError
+		if !errors.As(err, &te) {
+			t.Errorf("style %+v: err = %v, want *TimelineError", tc.style, err)
+			continue
+		}
+		if len(te.Fields) != 1 || te.Fields[0].Field != base+tc.field {
+			t.Errorf("style %+v: fields = %+v, want only %s", tc.style, te.Fields, base+tc.field)
+		}
+	}
+}
@@ -33,32 +33,41 @@ var AllowedMimeTypes = map[string]bool{
 }
 
 func ValidateUpload(fileHeader *multipart.FileHeader) error {
+	return ValidateUploadInfo(fileHeader.Filename, fileHeader.Header.Get("Content-Type"), fileHeader.Size)
+}
+
+// ValidateUploadInfo applies the upload rules to a file described rather
+// than received — direct uploads are checked before a byte is sent.
+func ValidateUploadInfo(filename, contentType string, size int64) error {
 
-	if fileHeader.Size == 0 {
+	if size <= 0 {
 		return ErrEmptyFile
 	}
 
-	if fileHeader.Size > MaxFileSize {
+	if size > MaxFileSize {
 		return ErrFileTooLarge
 	}
 
-	if len(fileHeader.Filename) > 255 {
+	if len(filename) > 255 {
 		return ErrFilenameTooLong
 	}
 
-	contentType := fileHeader.Header.Get("Content-Type")
-
-	if contentType == "" {
-		contentType = guessContentType(fileHeader.Filename)
-	}
-
-	if !AllowedMimeTypes[contentType] {
+	if !AllowedMimeTypes[UploadContentType(filename, contentType)] {
 		return ErrInvalidFileType
 	}
 
 	return nil
 }
 
+// UploadContentType is the type an upload is stored as: the declared one,
+// or a guess from the extension when the client sent none.
+func UploadContentType(filename, contentType string) string {
+	if contentType == "" {
+		return guessContentType(filename)
+	}
+	return contentType
+}
+
 func guessContentType(filename string) string {
 
 	idx := strings.LastIndex(filename, ".")
@@ -106,3 +115,12 @@ func ValidateSessionName(name string) error {
 
 	return nil
 }
+
+func ValidateVersionLabel(label string) error {
+
+	if len(label) > 255 {
+		return errors.New("version label too long - maximum 255 characters")
+	}
+
+	return nil
+}
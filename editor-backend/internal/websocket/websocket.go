@@ -0,0 +1,360 @@
+// internal/websocket/websocket.go
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// A minimal RFC 6455 server: the handshake, framing, fragmentation and the
+// close / ping / pong control frames. No extensions (permessage-deflate is
+// never negotiated) and no subprotocols — enough for JSON text messages
+// between the editor UI and this service.
+
+// Message types (frame opcodes).
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes used by this service.
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseInvalidData   = 1007
+	ClosePolicy        = 1008
+	CloseTooBig        = 1009
+	CloseInternalError = 1011
+)
+
+// DefaultReadLimit caps one (reassembled) message.
+const DefaultReadLimit = 64 << 10
+
+var (
+	ErrNotWebSocket   = errors.New("websocket: not a websocket handshake")
+	ErrBadOrigin      = errors.New("websocket: origin not allowed")
+	ErrBadVersion     = errors.New("websocket: unsupported version (want 13)")
+	ErrMessageTooBig  = errors.New("websocket: message exceeds read limit")
+	ErrProtocol       = errors.New("websocket: protocol error")
+	ErrInvalidUTF8    = errors.New("websocket: text message is not valid UTF-8")
+	errControlTooLong = errors.New("websocket: control frame payload over 125 bytes")
+)
+
+// CloseError is returned by ReadMessage once the peer has closed.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed (%d %s)", e.Code, e.Reason)
+}
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrade completes the opening handshake and takes over the connection.
+// On failure an HTTP error has already been written.
+//
+// Browsers send cookies and let any page open a socket to any host, so the
+// Origin header is checked against allowedOrigins ("*" allows all) — a page
+// on another site is refused with 403. Requests without Origin don't come
+// from a browser page and are accepted.
+func Upgrade(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, ErrNotWebSocket
+	}
+	if !originAllowed(r.Header.Get("Origin"), allowedOrigins) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, ErrBadOrigin
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, ErrBadVersion
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, ErrNotWebSocket
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket upgrade not supported", http.StatusInternalServerError)
+		return nil, err
+	}
+	// The server's Read/WriteTimeout deadlines would otherwise still apply
+	netConn.SetDeadline(time.Time{})
+
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: netConn, br: rw.Reader, readLimit: DefaultReadLimit}, nil
+}
+
+func originAllowed(origin string, allowed []string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(strings.TrimRight(a, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conn is one server-side WebSocket connection. One goroutine may read while
+// any number write — writes are serialized internally.
+type Conn struct {
+	conn      net.Conn
+	br        *bufio.Reader
+	readLimit int64
+	idle      time.Duration
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+// SetReadLimit caps the size of one message (DefaultReadLimit if unset).
+func (c *Conn) SetReadLimit(n int64) { c.readLimit = n }
+
+// SetIdleTimeout makes reads fail once nothing — not even a pong — has
+// arrived for d. Pair it with periodic Ping calls to detect dead peers.
+func (c *Conn) SetIdleTimeout(d time.Duration) { c.idle = d }
+
+// ReadMessage returns the next text or binary message, answering pings and
+// reassembling fragments on the way. After the peer closes it returns a
+// *CloseError; protocol violations close the connection with the right code.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var msgType int
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			switch err {
+			case ErrMessageTooBig:
+				c.CloseWith(CloseTooBig, "message too big")
+			case ErrProtocol, errControlTooLong:
+				c.CloseWith(CloseProtocolError, "protocol error")
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			ce := &CloseError{Code: 1005}
+			if len(payload) >= 2 {
+				ce.Code = int(binary.BigEndian.Uint16(payload))
+				ce.Reason = string(payload[2:])
+			}
+			c.CloseWith(CloseNormal, "")
+			return 0, nil, ce
+		case TextMessage, BinaryMessage:
+			if msgType != 0 {
+				c.CloseWith(CloseProtocolError, "expected continuation frame")
+				return 0, nil, ErrProtocol
+			}
+			msgType = opcode
+		case continuationFrame:
+			if msgType == 0 {
+				c.CloseWith(CloseProtocolError, "unexpected continuation frame")
+				return 0, nil, ErrProtocol
+			}
+		default:
+			c.CloseWith(CloseProtocolError, "unknown opcode")
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(message)+len(payload)) > c.readLimit {
+			c.CloseWith(CloseTooBig, "message too big")
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, payload...)
+
+		if fin {
+			if msgType == TextMessage && !utf8.Valid(message) {
+				c.CloseWith(CloseInvalidData, "invalid UTF-8")
+				return 0, nil, ErrInvalidUTF8
+			}
+			return msgType, message, nil
+		}
+	}
+}
+
+// readFrame reads and unmasks one frame.
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	if c.idle > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.idle))
+	}
+
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		// RSV bits — no extension was negotiated
+		return false, 0, nil, ErrProtocol
+	}
+	opcode = int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+
+	if !masked {
+		// Client frames must be masked (RFC 6455 §5.1)
+		return false, 0, nil, ErrProtocol
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	if opcode >= CloseMessage {
+		if !fin || length > 125 {
+			return false, 0, nil, errControlTooLong
+		}
+	} else if length < 0 || length > c.readLimit {
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends one unfragmented text or binary message.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	return c.writeFrame(messageType, data)
+}
+
+// Ping sends a ping; the client's pong only refreshes the read deadline.
+func (c *Conn) Ping() error {
+	return c.writeFrame(PingMessage, nil)
+}
+
+// writeTimeout bounds one frame write so a stalled client can't pin a goroutine.
+const writeTimeout = 10 * time.Second
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|byte(opcode))
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CloseWith sends a close frame with code and reason, then closes the
+// connection. Safe to call more than once.
+func (c *Conn) CloseWith(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload = append(payload, reason...)
+
+	err := c.writeFrame(CloseMessage, payload)
+	if cerr := c.conn.Close(); err == nil && !errors.Is(cerr, net.ErrClosed) {
+		err = cerr
+	}
+	return err
+}
+
+// Close closes the connection with a normal close frame.
+func (c *Conn) Close() error {
+	return c.CloseWith(CloseNormal, "")
+}
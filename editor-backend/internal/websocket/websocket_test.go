@@ -0,0 +1,369 @@
+// internal/websocket/websocket_test.go
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testOrigin = "https://editor.example.com"
+
+// echoServer upgrades every request and echoes messages back. The error that
+// ended each connection's read loop is sent on the returned channel.
+func echoServer(t *testing.T, readLimit int64) (*httptest.Server, <-chan error) {
+	t.Helper()
+	done := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, []string{testOrigin})
+		if err != nil {
+			return
+		}
+		if readLimit > 0 {
+			conn.SetReadLimit(readLimit)
+		}
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				conn.Close()
+				return
+			}
+			conn.WriteMessage(msgType, msg)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, done
+}
+
+// testClient is the client end of a connection, speaking raw frames.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func handshake(srv *httptest.Server, header http.Header) (net.Conn, *bufio.Reader, *http.Response, error) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/live", nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", testOrigin)
+	for name, values := range header {
+		req.Header.Del(name)
+		for _, v := range values {
+			if v != "" {
+				req.Header.Add(name, v)
+			}
+		}
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+	return conn, br, resp, nil
+}
+
+func dial(t *testing.T, srv *httptest.Server) *testClient {
+	t.Helper()
+	conn, br, resp, err := handshake(srv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d", resp.StatusCode)
+	}
+	return &testClient{t: t, conn: conn, br: br}
+}
+
+// writeFrame sends one frame, masked unless unmasked is set.
+func (c *testClient) writeFrame(fin bool, opcode int, payload []byte, unmasked bool) {
+	c.t.Helper()
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	maskBit := byte(0x80)
+	if unmasked {
+		maskBit = 0
+	}
+
+	frame := []byte{first}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	body := append([]byte(nil), payload...)
+	if !unmasked {
+		mask := []byte{0x12, 0x34, 0x56, 0x78}
+		frame = append(frame, mask...)
+		for i := range body {
+			body[i] ^= mask[i%4]
+		}
+	}
+	if _, err := c.conn.Write(append(frame, body...)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// readFrame reads one server frame, which must be unmasked and final.
+func (c *testClient) readFrame() (int, []byte) {
+	c.t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		c.t.Fatalf("reading frame: %v", err)
+	}
+	if header[0]&0x80 == 0 || header[1]&0x80 != 0 {
+		c.t.Fatalf("server frame header %x: want FIN set and no mask", header)
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatalf("reading payload: %v", err)
+	}
+	return int(header[0] & 0x0f), payload
+}
+
+// expectClose reads the server's close frame and checks its code.
+func (c *testClient) expectClose(code int) {
+	c.t.Helper()
+	opcode, payload := c.readFrame()
+	if opcode != CloseMessage || len(payload) < 2 {
+		c.t.Fatalf("got opcode %d %q, want a close frame", opcode, payload)
+	}
+	if got := int(binary.BigEndian.Uint16(payload)); got != code {
+		c.t.Fatalf("close code = %d (%s), want %d", got, payload[2:], code)
+	}
+}
+
+func TestUpgradeHandshake(t *testing.T) {
+	srv, _ := echoServer(t, 0)
+
+	cases := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"valid", nil, http.StatusSwitchingProtocols},
+		{"no Origin", http.Header{"Origin": {""}}, http.StatusSwitchingProtocols},
+		{"other site", http.Header{"Origin": {"https://evil.example.net"}}, http.StatusForbidden},
+		{"lookalike origin", http.Header{"Origin": {testOrigin + ".evil.example.net"}}, http.StatusForbidden},
+		{"not an upgrade", http.Header{"Upgrade": {""}}, http.StatusBadRequest},
+		{"old version", http.Header{"Sec-WebSocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"short key", http.Header{"Sec-WebSocket-Key": {"c2hvcnQ="}}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, _, resp, err := handshake(srv, tc.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.want)
+			}
+			if tc.want != http.StatusSwitchingProtocols {
+				return
+			}
+			// RFC 6455 §1.3 sample key and accept value
+			if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+				t.Errorf("Sec-WebSocket-Accept = %q", got)
+			}
+			if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+				t.Errorf("Upgrade = %q", resp.Header.Get("Upgrade"))
+			}
+		})
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	cases := []struct {
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"", nil, true},
+		{testOrigin, []string{testOrigin}, true},
+		{"HTTPS://EDITOR.EXAMPLE.COM", []string{testOrigin}, true},
+		{testOrigin, []string{testOrigin + "/"}, true},
+		{"https://evil.example.net", []string{"*"}, true},
+		{testOrigin, nil, false},
+		{"http://editor.example.com", []string{testOrigin}, false},
+		{"null", []string{testOrigin}, false},
+	}
+	for _, tc := range cases {
+		if got := originAllowed(tc.origin, tc.allowed); got != tc.want {
+			t.Errorf("originAllowed(%q, %q) = %v, want %v", tc.origin, tc.allowed, got, tc.want)
+		}
+	}
+}
+
+func TestFraming(t *testing.T) {
+	srv, _ := echoServer(t, 0)
+	c := dial(t, srv)
+
+	// Payload lengths around each length encoding
+	for _, n := range []int{0, 125, 126, 0xffff, 0x10000} {
+		payload := bytes.Repeat([]byte("a"), n)
+		c.writeFrame(true, TextMessage, payload, false)
+		opcode, got := c.readFrame()
+		if opcode != TextMessage || !bytes.Equal(got, payload) {
+			t.Fatalf("echo of %d bytes: opcode %d, %d bytes", n, opcode, len(got))
+		}
+	}
+
+	// Fragments are reassembled, with a ping answered in between
+	c.writeFrame(false, BinaryMessage, []byte("frag"), false)
+	c.writeFrame(true, PingMessage, []byte("hi"), false)
+	c.writeFrame(false, continuationFrame, []byte("men"), false)
+	c.writeFrame(true, continuationFrame, []byte("ted"), false)
+	if opcode, got := c.readFrame(); opcode != PongMessage || string(got) != "hi" {
+		t.Fatalf("got opcode %d %q, want pong \"hi\"", opcode, got)
+	}
+	if opcode, got := c.readFrame(); opcode != BinaryMessage || string(got) != "fragmented" {
+		t.Fatalf("got opcode %d %q, want binary \"fragmented\"", opcode, got)
+	}
+
+	// Pongs are swallowed
+	c.writeFrame(true, PongMessage, nil, false)
+	c.writeFrame(true, TextMessage, []byte("after pong"), false)
+	if _, got := c.readFrame(); string(got) != "after pong" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCloseCodes(t *testing.T) {
+	cases := []struct {
+		name  string
+		send  func(c *testClient)
+		code  int
+		ended error
+	}{
+		{"unmasked frame", func(c *testClient) {
+			c.writeFrame(true, TextMessage, []byte("x"), true)
+		}, CloseProtocolError, ErrProtocol},
+		{"unknown opcode", func(c *testClient) {
+			c.writeFrame(true, 3, []byte("x"), false)
+		}, CloseProtocolError, ErrProtocol},
+		{"continuation without a start", func(c *testClient) {
+			c.writeFrame(true, continuationFrame, []byte("x"), false)
+		}, CloseProtocolError, ErrProtocol},
+		{"new message inside a fragmented one", func(c *testClient) {
+			c.writeFrame(false, TextMessage, []byte("x"), false)
+			c.writeFrame(true, TextMessage, []byte("y"), false)
+		}, CloseProtocolError, ErrProtocol},
+		{"fragmented control frame", func(c *testClient) {
+			c.writeFrame(false, PingMessage, []byte("x"), false)
+		}, CloseProtocolError, errControlTooLong},
+		{"control frame over 125 bytes", func(c *testClient) {
+			c.writeFrame(true, PingMessage, bytes.Repeat([]byte("x"), 126), false)
+		}, CloseProtocolError, errControlTooLong},
+		{"frame over the read limit", func(c *testClient) {
+			c.writeFrame(true, TextMessage, bytes.Repeat([]byte("x"), 1025), false)
+		}, CloseTooBig, ErrMessageTooBig},
+		{"fragments over the read limit", func(c *testClient) {
+			c.writeFrame(false, TextMessage, bytes.Repeat([]byte("x"), 1000), false)
+			c.writeFrame(true, continuationFrame, bytes.Repeat([]byte("x"), 100), false)
+		}, CloseTooBig, ErrMessageTooBig},
+		{"invalid UTF-8", func(c *testClient) {
+			c.writeFrame(true, TextMessage, []byte{0xff, 0xfe}, false)
+		}, CloseInvalidData, ErrInvalidUTF8},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, done := echoServer(t, 1024)
+			c := dial(t, srv)
+			tc.send(c)
+			c.expectClose(tc.code)
+			if err := <-done; !errors.Is(err, tc.ended) {
+				t.Fatalf("ReadMessage err = %v, want %v", err, tc.ended)
+			}
+		})
+	}
+}
+
+// A client close is answered with 1000 and surfaces as a *CloseError.
+func TestClientClose(t *testing.T) {
+	srv, done := echoServer(t, 0)
+	c := dial(t, srv)
+
+	c.writeFrame(true, CloseMessage, append(binary.BigEndian.AppendUint16(nil, CloseGoingAway), "bye"...), false)
+	c.expectClose(CloseNormal)
+
+	var ce *CloseError
+	if err := <-done; !errors.As(err, &ce) || ce.Code != CloseGoingAway || ce.Reason != "bye" {
+		t.Fatalf("ReadMessage err = %v, want CloseError 1001 bye", err)
+	}
+
+	// The server closes the TCP connection after its close frame
+	if _, err := c.br.ReadByte(); err != io.EOF {
+		t.Fatalf("after close: err = %v, want EOF", err)
+	}
+}
+
+// CloseWith sends its code once; later writes fail instead of sending frames.
+func TestCloseWith(t *testing.T) {
+	conns := make(chan *Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r, []string{testOrigin})
+		if err == nil {
+			conns <- conn
+		}
+	}))
+	defer srv.Close()
+	c := dial(t, srv)
+	conn := <-conns
+
+	if err := conn.CloseWith(ClosePolicy, "access revoked"); err != nil {
+		t.Fatal(err)
+	}
+	_, payload := c.readFrame()
+	if code := binary.BigEndian.Uint16(payload); code != ClosePolicy || string(payload[2:]) != "access revoked" {
+		t.Fatalf("close frame = %d %q", code, payload[2:])
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("late")); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("write after close: err = %v, want net.ErrClosed", err)
+	}
+	if err := conn.CloseWith(CloseNormal, ""); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("second close: err = %v, want net.ErrClosed", err)
+	}
+}
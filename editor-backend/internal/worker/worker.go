@@ -0,0 +1,343 @@
+// internal/worker/worker.go
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"editor-backend/internal/models"
+	"editor-backend/internal/render"
+	"editor-backend/internal/service"
+)
+
+// Worker pulls export jobs off the Postgres queue and renders them.
+//
+// Lifecycle of one job:
+//
+//	Claim (lease) → heartbeat every Lease/3 + progress while rendering
+//	              → Complete | Fail (retry w/ backoff) | Cancelled (DELETE /exports/{id})
+//
+// If this process dies mid-render, the lease simply expires and another worker
+// picks the job up — nothing is lost with the pod.
+type Worker struct {
+	ID       string
+	Jobs     *service.ExportJobService
+	Sessions *service.SessionService
+	Exporter *service.ExportService
+
+	Concurrency   int           // parallel renders (ffmpeg is CPU-bound — keep this small)
+	Lease         time.Duration // how long a claim is valid without a heartbeat
+	PollInterval  time.Duration // idle wait when the queue is empty
+	RenderTimeout time.Duration // hard cap on a single render
+
+	// Uploads, if set, has expired uploads purged every uploadPurgeInterval
+	Uploads *service.UploadService
+}
+
+const uploadPurgeInterval = 10 * time.Minute
+
+// Run blocks until ctx is cancelled, then waits for in-flight jobs to wind down.
+func (w *Worker) Run(ctx context.Context) {
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.reapLoop(ctx)
+	}()
+
+	if w.Uploads != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.purgeUploadsLoop(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := w.Jobs.Claim(ctx, w.ID, w.Lease)
+		if err != nil {
+			if !errors.Is(err, service.ErrJobNotFound) && ctx.Err() == nil {
+				log.Println("Worker: claim failed:", err)
+			}
+			sleep(ctx, w.PollInterval)
+			continue
+		}
+
+		w.process(ctx, job)
+	}
+}
+
+// run tracks why a render was stopped. Each stop reason is a channel closed at
+// most once; the render context is cancelled whenever any of them fires.
+type run struct {
+	job          *models.ExportJob
+	cancelRender context.CancelFunc
+
+	leaseLost     chan struct{}
+	leaseLostOnce sync.Once
+	cancelled     chan struct{}
+	cancelledOnce sync.Once
+}
+
+func (r *run) loseLease() {
+	r.leaseLostOnce.Do(func() { close(r.leaseLost); r.cancelRender() })
+}
+
+func (r *run) cancel() {
+	r.cancelledOnce.Do(func() { close(r.cancelled); r.cancelRender() })
+}
+
+func closed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// process renders one claimed job. shutdown is the worker's lifetime context:
+// when it is cancelled the render is stopped and the job released for another worker.
+func (w *Worker) process(shutdown context.Context, job *models.ExportJob) {
+	log.Printf("Worker: job=%s session=%s attempt=%d/%d", job.JobID, job.SessionID, job.Attempts, job.MaxAttempts)
+
+	// Render context — independent of shutdown so we can tell the two apart below
+	renderCtx, cancelRender := context.WithTimeout(context.Background(), w.RenderTimeout)
+	defer cancelRender()
+
+	r := &run{
+		job:          job,
+		cancelRender: cancelRender,
+		leaseLost:    make(chan struct{}),
+		cancelled:    make(chan struct{}),
+	}
+
+	stopHeartbeat := make(chan struct{})
+	go w.heartbeat(r, stopHeartbeat)
+	defer close(stopHeartbeat)
+
+	go func() {
+		select {
+		case <-shutdown.Done():
+			cancelRender()
+		case <-renderCtx.Done():
+		}
+	}()
+
+	// Short DB calls after the render must not be cut off by a cancelled renderCtx
+	dbCtx := func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 5*time.Second)
+	}
+
+	session, err := w.Sessions.GetSession(job.SessionID, job.UserID, service.WorkspaceOf(job.WorkspaceID))
+	if err == nil && w.Jobs.RequireApproval {
+		// The session may have been edited (and so un-approved) since it was
+		// queued, or approved again at a version other than the snapshot's
+		if !service.ExportAllowed(session.Status) || job.Timeline != nil && session.Version != job.Version {
+			err = service.ErrApprovalRequired
+		}
+	}
+	if err == nil {
+		// Render what was enqueued, not what has been saved since
+		session, err = service.SnapshotSession(session, job)
+	}
+	if err == nil {
+		var outputURL string
+		outputURL, err = w.Exporter.Export(renderCtx, session, w.progressReporter(r))
+		if err == nil {
+			ctx, cancel := dbCtx()
+			defer cancel()
+			if err := w.Jobs.Complete(ctx, job.JobID, w.ID, outputURL); err != nil {
+				// Lease lost or the row couldn't be written — either way the job
+				// isn't ours to finish; whoever runs it next sets the session status
+				log.Printf("Worker: job=%s complete failed: %v", job.JobID, err)
+				return
+			}
+			w.setSessionStatus(job, service.ExportStatusCompleted, outputURL)
+			log.Printf("Worker: job=%s completed → %s", job.JobID, outputURL)
+			return
+		}
+	}
+
+	if closed(r.leaseLost) {
+		// Another worker owns the job now — touching it would clobber their state
+		log.Printf("Worker: job=%s abandoned, lease lost", job.JobID)
+		return
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	if closed(r.cancelled) {
+		if err := w.Jobs.MarkCancelled(ctx, job.JobID, w.ID); err != nil {
+			log.Printf("Worker: job=%s cancel bookkeeping failed: %v", job.JobID, err)
+		}
+		w.setSessionStatus(job, service.ExportStatusCancelled, "")
+		log.Printf("Worker: job=%s cancelled, ffmpeg stopped", job.JobID)
+		return
+	}
+
+	if shutdown.Err() != nil {
+		if err := w.Jobs.Release(ctx, job.JobID, w.ID); err != nil {
+			log.Printf("Worker: job=%s release failed: %v", job.JobID, err)
+		}
+		w.setSessionStatus(job, service.ExportStatusQueued, "")
+		log.Printf("Worker: job=%s released on shutdown", job.JobID)
+		return
+	}
+
+	requeued, failErr := w.Jobs.Fail(ctx, job, w.ID, err)
+	if failErr != nil {
+		log.Printf("Worker: job=%s fail bookkeeping failed: %v", job.JobID, failErr)
+		return
+	}
+	if requeued {
+		w.setSessionStatus(job, service.ExportStatusQueued, "")
+		log.Printf("Worker: job=%s attempt %d failed, will retry: %v", job.JobID, job.Attempts, err)
+		return
+	}
+	w.setSessionStatus(job, service.ExportStatusFailed, "")
+	log.Printf("Worker: job=%s failed permanently: %v", job.JobID, err)
+}
+
+// progressReporter writes ffmpeg progress to the job row, at most once per
+// second, and doubles as the fast path for noticing cancel requests.
+func (w *Worker) progressReporter(r *run) render.ProgressFunc {
+	var last time.Time
+	return func(p render.Progress) {
+		if time.Since(last) < time.Second && p.Percent < 100 {
+			return
+		}
+		last = time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		cancelRequested, err := w.Jobs.ReportProgress(ctx, r.job, w.ID, p.Percent, p.ETA)
+		switch {
+		case errors.Is(err, service.ErrLeaseLost):
+			r.loseLease()
+		case err != nil:
+			log.Printf("Worker: job=%s progress update failed: %v", r.job.JobID, err)
+		case cancelRequested:
+			r.cancel()
+		}
+	}
+}
+
+// heartbeat keeps the lease alive while rendering. If the lease is lost the
+// render is cancelled — some other worker is already redoing it.
+func (w *Worker) heartbeat(r *run, stop chan struct{}) {
+	ticker := time.NewTicker(w.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			cancelRequested, err := w.Jobs.Heartbeat(ctx, r.job.JobID, w.ID, w.Lease)
+			cancel()
+
+			switch {
+			case errors.Is(err, service.ErrLeaseLost):
+				r.loseLease()
+				return
+			case err != nil:
+				log.Printf("Worker: job=%s heartbeat failed: %v", r.job.JobID, err)
+			case cancelRequested:
+				r.cancel()
+			}
+		}
+	}
+}
+
+// reapLoop settles jobs abandoned by dead workers so they don't sit in "running".
+func (w *Worker) reapLoop(ctx context.Context) {
+	ticker := time.NewTicker(w.Lease)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			jobs, err := w.Jobs.FailAbandoned(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Println("Worker: reaper failed:", err)
+				}
+				continue
+			}
+			for _, job := range jobs {
+				w.setSessionStatus(job, job.Status, "")
+				log.Printf("Worker: job=%s %s after its worker's lease expired", job.JobID, job.Status)
+			}
+		}
+	}
+}
+
+// purgeUploadsLoop deletes uploads that expired unfinished, with the
+// objects and chunks they left in storage.
+func (w *Worker) purgeUploadsLoop(ctx context.Context) {
+	ticker := time.NewTicker(uploadPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := w.Uploads.PurgeExpiredUploads(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Println("Worker: upload purge failed:", err)
+				}
+				continue
+			}
+			if n > 0 {
+				log.Printf("Worker: purged %d expired uploads", n)
+			}
+		}
+	}
+}
+
+func (w *Worker) setSessionStatus(job *models.ExportJob, status, outputURL string) {
+	if err := w.Sessions.UpdateExportStatus(job.SessionID, job.UserID, service.WorkspaceOf(job.WorkspaceID), status, outputURL); err != nil {
+		log.Printf("Worker: session=%s status update failed: %v", job.SessionID, err)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,58 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Comments Migration
+-- Timecoded review comments and threads on sessions
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: Reviewers pin feedback to a time range (and optionally a clip) on
+--          a session's timeline. Comments are rows beside the timeline JSON,
+--          so saves never overwrite them; a save that removes a referenced
+--          clip sets clip_deleted instead.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_comments (
+    comment_id    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id    UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+
+    -- NULL = top-level comment; replies are one level deep and copy the
+    -- thread's start / end / clip_id
+    parent_id     UUID REFERENCES editor_comments(comment_id) ON DELETE CASCADE,
+
+    start_time    DOUBLE PRECISION NOT NULL CHECK (start_time >= 0),
+    end_time      DOUBLE PRECISION NOT NULL CHECK (end_time >= start_time),
+    clip_id       TEXT,
+    clip_deleted  BOOLEAN NOT NULL DEFAULT FALSE,
+
+    body          TEXT NOT NULL,
+    mentions      UUID[] NOT NULL DEFAULT '{}',
+
+    -- Account holders set author_id; share link reviewers set author_name
+    author_id     UUID,
+    author_name   TEXT NOT NULL DEFAULT '',
+    share_id      UUID REFERENCES editor_share_links(share_id) ON DELETE SET NULL,
+
+    resolved_at   TIMESTAMPTZ,
+    resolved_by   UUID,
+
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Threads of a session in timeline order
+CREATE INDEX IF NOT EXISTS idx_editor_comments_session
+    ON editor_comments(session_id, start_time, created_at)
+    WHERE parent_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_editor_comments_parent
+    ON editor_comments(parent_id, created_at)
+    WHERE parent_id IS NOT NULL;
+
+-- Flagging on save only looks at comments that reference a clip
+CREATE INDEX IF NOT EXISTS idx_editor_comments_clip
+    ON editor_comments(session_id, clip_id)
+    WHERE clip_id IS NOT NULL;
+
+-- ?mentioned=<user>
+CREATE INDEX IF NOT EXISTS idx_editor_comments_mentions
+    ON editor_comments USING GIN (mentions);
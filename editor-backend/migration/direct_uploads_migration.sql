@@ -0,0 +1,44 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Direct Uploads Migration
+-- Presigned direct-to-storage upload slots
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: POST /api/v1/uploads validates a file's name, size and type and
+--          hands out a presigned PUT URL; the client uploads straight to S3
+--          (or the signed local endpoint) and calls
+--          POST /api/v1/uploads/{upload_id}/complete, which checks the
+--          stored object and records it in editor_assets. Media bytes never
+--          pass through the API pods.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_uploads (
+    upload_id     UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id  UUID REFERENCES workspaces(workspace_id),
+    user_id       UUID NOT NULL,
+
+    -- Storage key the presigned URL writes to
+    storage_key   TEXT NOT NULL,
+    filename      TEXT NOT NULL DEFAULT '',
+    content_type  VARCHAR(100) NOT NULL DEFAULT '',
+    size_bytes    BIGINT NOT NULL,
+
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending'
+                  CHECK (status IN ('pending', 'completed', 'failed')),
+    -- Set on completion
+    asset_id      UUID REFERENCES editor_assets(asset_id),
+
+    -- When the presigned URL stops working
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_editor_uploads_user
+    ON editor_uploads(user_id, created_at DESC);
+
+-- Abandoned slots, for cleanup
+CREATE INDEX IF NOT EXISTS idx_editor_uploads_pending
+    ON editor_uploads(expires_at)
+    WHERE status = 'pending';
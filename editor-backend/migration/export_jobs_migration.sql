@@ -0,0 +1,53 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Export Jobs Migration
+-- Durable render queue consumed by cmd/worker
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: POST /sessions/{id}/export enqueues a job and returns 202;
+--          workers claim jobs with SELECT ... FOR UPDATE SKIP LOCKED
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- Requires: export_render_migration.sql
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    job_id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id       UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+    user_id          UUID NOT NULL,
+
+    -- "queued" | "running" | "completed" | "failed"
+    status           VARCHAR(20) NOT NULL DEFAULT 'queued',
+
+    -- Retry bookkeeping — attempts is bumped on every claim
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    max_attempts     INTEGER NOT NULL DEFAULT 3,
+    run_after        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    -- Lease: a running job whose lease expired is reclaimable by another worker
+    leased_by        TEXT,
+    lease_expires_at TIMESTAMP WITH TIME ZONE,
+
+    last_error       TEXT NOT NULL DEFAULT '',
+    output_url       TEXT NOT NULL DEFAULT '',
+
+    created_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at       TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    started_at       TIMESTAMP WITH TIME ZONE,
+    finished_at      TIMESTAMP WITH TIME ZONE
+);
+
+-- ============================================================================
+-- INDEXES
+-- ============================================================================
+
+-- Claim loop: next runnable job (queued and due, or running with expired lease)
+CREATE INDEX IF NOT EXISTS idx_export_jobs_claimable
+    ON export_jobs(run_after) WHERE status IN ('queued', 'running');
+
+-- At most ONE active export per session — double-clicking Export reuses the job
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_active_session
+    ON export_jobs(session_id) WHERE status IN ('queued', 'running');
+
+-- Export history per session
+CREATE INDEX IF NOT EXISTS idx_export_jobs_session
+    ON export_jobs(session_id, created_at DESC);
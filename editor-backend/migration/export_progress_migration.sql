@@ -0,0 +1,28 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Export Progress Migration
+-- Progress, ETA and cancellation for export jobs
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: GET /exports/{job_id} reports progress parsed from ffmpeg -progress;
+--          DELETE /exports/{job_id} cancels a queued or running render
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- Requires: export_jobs_migration.sql
+-- ============================================================================
+
+-- 0–100, written by the worker while ffmpeg runs
+ALTER TABLE export_jobs
+    ADD COLUMN IF NOT EXISTS progress_percent REAL NOT NULL DEFAULT 0;
+
+-- Estimated seconds remaining for the current render (NULL until known)
+ALTER TABLE export_jobs
+    ADD COLUMN IF NOT EXISTS eta_seconds INTEGER;
+
+-- Set by DELETE /exports/{job_id}; the worker polls it and kills ffmpeg.
+-- status gains the terminal value "cancelled".
+ALTER TABLE export_jobs
+    ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Latest export job per session — lets the UI find the job to poll
+ALTER TABLE editor_sessions
+    ADD COLUMN IF NOT EXISTS export_job_id UUID;
@@ -0,0 +1,14 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Export Render Migration
+-- Stores where the rendered MP4 of a session ended up
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: POST /sessions/{id}/export renders via FFmpeg and uploads the result
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- Requires: editor_integration_migration.sql
+-- ============================================================================
+
+-- Storage URL of the last successful render (LocalStorage or S3)
+ALTER TABLE editor_sessions
+    ADD COLUMN IF NOT EXISTS export_url TEXT DEFAULT '';
@@ -0,0 +1,23 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Export Snapshot Migration
+-- Freezes what an export job renders at the moment it is enqueued
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: POST /sessions/{id}/export copies the session's timeline and
+--          version into the job row. The worker renders that copy, so an
+--          edit saved between clicking Export and a worker claiming the job
+--          doesn't change (or, with EXPORT_REQUIRE_APPROVAL, sneak into)
+--          the render.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- Requires: export_jobs_migration.sql
+-- ============================================================================
+
+-- editor_sessions.timeline as of enqueue. NULL on jobs queued before this
+-- migration — those render the session as it is when claimed.
+ALTER TABLE export_jobs
+    ADD COLUMN IF NOT EXISTS timeline JSONB;
+
+-- editor_sessions.version the snapshot was taken at
+ALTER TABLE export_jobs
+    ADD COLUMN IF NOT EXISTS version INTEGER;
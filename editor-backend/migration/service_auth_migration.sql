@@ -0,0 +1,25 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Service Auth Migration
+-- Replay protection for signed Repurposer / Content Hub requests
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: Each signed service request carries a single-use nonce. Nonces are
+--          stored here (not in pod memory) so a request replayed against a
+--          different API pod is still rejected.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS service_request_nonces (
+    service     VARCHAR(50) NOT NULL,   -- "repurposer" | "content_hub"
+    nonce       VARCHAR(128) NOT NULL,
+
+    -- After this the timestamp check rejects the request anyway, so the
+    -- nonce no longer needs remembering; the API sweeps expired rows
+    expires_at  TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (service, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_request_nonces_expires
+    ON service_request_nonces(expires_at);
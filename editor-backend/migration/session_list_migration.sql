@@ -0,0 +1,17 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Session Listing Migration
+-- Index for GET /api/v1/sessions ("My Projects")
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: The listing pages through a user's sessions by
+--          (updated_at, session_id) — keyset pagination needs an index in
+--          exactly that order to avoid sorting every session per page.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_editor_sessions_user_updated
+    ON editor_sessions(user_id, updated_at DESC, session_id DESC);
+
+-- Filtered listings (source_module, export_status) use the existing
+-- idx_editor_sessions_source_module / idx_editor_sessions_export_status
@@ -0,0 +1,30 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Session Members Migration
+-- Shares a session with other users by role
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: editor_sessions.user_id stays the owner. Everyone else with access
+--          is a row here with one role: editor, commenter or viewer.
+--          Ownership transfer swaps the owner into this table as an editor.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_session_members (
+    session_id  UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+    user_id     UUID NOT NULL,
+
+    -- "owner" is never stored — it is editor_sessions.user_id
+    role        VARCHAR(20) NOT NULL
+                CHECK (role IN ('editor', 'commenter', 'viewer')),
+
+    invited_by  UUID,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (session_id, user_id)
+);
+
+-- "Shared with me" in GET /sessions
+CREATE INDEX IF NOT EXISTS idx_editor_session_members_user
+    ON editor_session_members(user_id);
@@ -0,0 +1,26 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Collaborative Operations Migration
+-- Log of server-ordered edit operations for real-time collaboration
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: Ops sent over GET /api/v1/sessions/{id}/live (WebSocket) are
+--          applied to editor_sessions.timeline one at a time, each producing
+--          the next session version. This log lets a client that reconnects
+--          replay the ops it missed instead of reloading the whole timeline.
+--          Only the newest EDITOR_OPERATION_RETENTION versions are kept.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_session_ops (
+    session_id    UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+    -- Session version this op produced — the server-assigned order
+    version       INTEGER NOT NULL,
+    user_id       UUID NOT NULL,
+    -- Sender's own ID for the op, echoed back so it can match the ack
+    client_op_id  TEXT NOT NULL DEFAULT '',
+    op            JSONB NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (session_id, version)
+);
@@ -0,0 +1,46 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Session Approval Workflow Migration
+-- Turns editor_sessions.status into an approval state machine
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: draft → in_review → changes_requested → approved → exported.
+--          Every transition is recorded in editor_session_transitions so
+--          clients can see who signed off on which version, and when.
+--          With EXPORT_REQUIRE_APPROVAL=true only approved (or already
+--          exported) sessions can be exported.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+-- Nothing set status before this — every existing session starts as a draft
+UPDATE editor_sessions
+SET status = 'draft'
+WHERE status IS NULL
+   OR status NOT IN ('draft', 'in_review', 'changes_requested', 'approved', 'exported');
+
+ALTER TABLE editor_sessions
+    ALTER COLUMN status SET DEFAULT 'draft';
+
+-- Review queues: "everything waiting for my sign-off"
+CREATE INDEX IF NOT EXISTS idx_editor_sessions_status
+    ON editor_sessions(status, updated_at DESC)
+    WHERE status IN ('in_review', 'changes_requested', 'approved');
+
+CREATE TABLE IF NOT EXISTS editor_session_transitions (
+    transition_id  BIGSERIAL PRIMARY KEY,
+    session_id     UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+
+    from_status    VARCHAR(50) NOT NULL,
+    to_status      VARCHAR(50) NOT NULL,
+
+    -- NULL = made by the system (export completed)
+    user_id        UUID,
+    -- Timeline version at the time — an approval covers exactly this version
+    version        INTEGER NOT NULL,
+    note           TEXT NOT NULL DEFAULT '',
+
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_editor_session_transitions_session
+    ON editor_session_transitions(session_id, transition_id DESC);
@@ -0,0 +1,39 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Session Versions Migration
+-- Every saved timeline is kept so users can browse, diff and restore
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: editor_sessions.version was only a counter — earlier timelines
+--          were overwritten. Each save now also lands here.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_session_versions (
+    session_id  UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    timeline    JSONB NOT NULL,
+
+    -- Who saved it (acting user, not necessarily the session owner)
+    user_id     UUID NOT NULL,
+
+    -- Optional human label ("Sent to client", "restored from v12").
+    -- Labelled versions are exempt from retention pruning.
+    label       VARCHAR(255) NOT NULL DEFAULT '',
+
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+
+    PRIMARY KEY (session_id, version)
+);
+
+-- Retention pruning: oldest unlabelled versions per session
+CREATE INDEX IF NOT EXISTS idx_editor_session_versions_prune
+    ON editor_session_versions(session_id, version) WHERE label = '';
+
+-- ============================================================================
+-- NOTES
+-- ============================================================================
+-- • Retention is applied on every save: unlabelled versions older than the
+--   newest EDITOR_VERSION_RETENTION (default 100) are deleted.
+-- • Restoring a version writes a NEW version — history is never rewritten.
+-- ============================================================================
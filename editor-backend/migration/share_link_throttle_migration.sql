@@ -0,0 +1,20 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Share Link Throttling Migration
+-- Lock password-protected share links after repeated wrong passwords
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: GET /api/v1/share/{token} is public. Each wrong password costs a
+--          PBKDF2 hash and an access-log row; after EDITOR_SHARE_MAX_FAILURES
+--          of them the link is locked until locked_until and answers 429
+--          without doing either. Run after share_links_migration.sql.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+-- Wrong passwords since the last success or lock
+ALTER TABLE editor_share_links
+    ADD COLUMN IF NOT EXISTS failed_attempts INT NOT NULL DEFAULT 0;
+
+-- NULL or in the past = open
+ALTER TABLE editor_share_links
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
@@ -0,0 +1,47 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Share Links Migration
+-- Public read-only review links for people without an account
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: GET /api/v1/share/{token} serves a session's timeline to anyone
+--          holding the token. Only sha256(token) is stored, so a database
+--          leak does not leak working links. Every access is logged.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_share_links (
+    share_id       UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id     UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+    token_hash     BYTEA NOT NULL UNIQUE,
+
+    permission     VARCHAR(20) NOT NULL DEFAULT 'view'
+                   CHECK (permission IN ('view', 'comment')),
+
+    -- "pbkdf2-sha256$<iterations>$<salt>$<hash>", NULL = no password
+    password_hash  TEXT,
+    expires_at     TIMESTAMPTZ,
+
+    created_by     UUID NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_editor_share_links_session
+    ON editor_share_links(session_id, created_at DESC);
+
+-- One row per GET /share/{token} that matched a link, including refusals
+CREATE TABLE IF NOT EXISTS editor_share_access_log (
+    access_id    BIGSERIAL PRIMARY KEY,
+    share_id     UUID NOT NULL REFERENCES editor_share_links(share_id) ON DELETE CASCADE,
+    session_id   UUID NOT NULL,
+
+    -- "ok" | "expired" | "revoked" | "password_required" | "wrong_password"
+    outcome      VARCHAR(30) NOT NULL,
+    ip           TEXT NOT NULL DEFAULT '',
+    user_agent   TEXT NOT NULL DEFAULT '',
+    accessed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_editor_share_access_log_share
+    ON editor_share_access_log(share_id, accessed_at DESC);
@@ -0,0 +1,31 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Resumable Uploads Migration
+-- tus 1.0 resumable uploads on top of editor_uploads
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: /api/v1/tus/{upload_id} accepts a file in chunks (PATCH) and
+--          resumes after a dropped connection from the last stored byte
+--          (HEAD → Upload-Offset). Every stored chunk is a part object in
+--          storage; the last chunk joins them into the final file and
+--          records the asset. Run after direct_uploads_migration.sql.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+-- 'presigned' (POST /uploads) | 'tus' (POST /tus)
+ALTER TABLE editor_uploads
+    ADD COLUMN IF NOT EXISTS protocol VARCHAR(20) NOT NULL DEFAULT 'presigned';
+
+-- Bytes received so far (tus); the upload is complete at size_bytes
+ALTER TABLE editor_uploads
+    ADD COLUMN IF NOT EXISTS upload_offset BIGINT NOT NULL DEFAULT 0;
+
+-- Chunks received for an unfinished tus upload, in order of part_offset
+CREATE TABLE IF NOT EXISTS editor_upload_parts (
+    upload_id    UUID NOT NULL REFERENCES editor_uploads(upload_id) ON DELETE CASCADE,
+    part_offset  BIGINT NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    storage_key  TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (upload_id, part_offset)
+);
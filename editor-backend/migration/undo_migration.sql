@@ -0,0 +1,31 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Undo / Redo Migration
+-- Server-side undo and redo stacks per session
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: Every timeline write (save, edit operation, restore) pushes the
+--          timeline it replaced onto the session's undo stack, so
+--          POST /sessions/{id}/undo works after a reload and from any device.
+--          Undo moves the current timeline onto the redo stack; any other
+--          write clears it. Each stack keeps the newest EDITOR_UNDO_DEPTH
+--          entries.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS editor_session_undo (
+    entry_id    BIGSERIAL PRIMARY KEY,
+    session_id  UUID NOT NULL REFERENCES editor_sessions(session_id) ON DELETE CASCADE,
+    -- 'undo' | 'redo'
+    stack       TEXT NOT NULL CHECK (stack IN ('undo', 'redo')),
+    -- Version whose timeline this entry holds
+    version     INTEGER NOT NULL,
+    timeline    JSONB NOT NULL,
+    -- Who made the write this entry reverses
+    user_id     UUID NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Top of each stack: newest entry_id first
+CREATE INDEX IF NOT EXISTS idx_editor_session_undo_stack
+    ON editor_session_undo (session_id, stack, entry_id DESC);
@@ -0,0 +1,65 @@
+-- ============================================================================
+-- UNIFIED EDITOR - Workspaces Migration
+-- Agency / organization tenancy for sessions and uploads
+-- ============================================================================
+-- Run on: incubrix PostgreSQL (same DB as all services)
+-- Purpose: A workspace is a shared project space. Sessions, export jobs and
+--          uploaded assets carry the workspace they were created in;
+--          workspace_id NULL means a personal (pre-workspace) row, so
+--          existing data keeps working untouched.
+--
+-- SAFE TO RUN MULTIPLE TIMES (all statements use IF NOT EXISTS)
+-- Requires: export_jobs_migration.sql
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    workspace_id  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name          VARCHAR(200) NOT NULL,
+    created_by    UUID NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id  UUID NOT NULL REFERENCES workspaces(workspace_id) ON DELETE CASCADE,
+    user_id       UUID NOT NULL,
+    role          VARCHAR(20) NOT NULL
+                  CHECK (role IN ('admin', 'member', 'viewer')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+-- "Which workspaces am I in" (GET /workspaces)
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user
+    ON workspace_members(user_id);
+
+-- Sessions and export jobs belong to at most one workspace
+ALTER TABLE editor_sessions
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces(workspace_id);
+
+ALTER TABLE export_jobs
+    ADD COLUMN IF NOT EXISTS workspace_id UUID;
+
+-- Workspace listing pages on the same keyset as GET /sessions
+CREATE INDEX IF NOT EXISTS idx_editor_sessions_workspace_updated
+    ON editor_sessions(workspace_id, updated_at DESC, session_id DESC)
+    WHERE workspace_id IS NOT NULL;
+
+-- Uploaded media — previously only a file on disk, now listable per workspace
+CREATE TABLE IF NOT EXISTS editor_assets (
+    asset_id      UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id  UUID REFERENCES workspaces(workspace_id),
+    user_id       UUID NOT NULL,
+    file_url      TEXT NOT NULL,
+    filename      TEXT NOT NULL DEFAULT '',
+    content_type  VARCHAR(100) NOT NULL DEFAULT '',
+    size_bytes    BIGINT NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_editor_assets_workspace
+    ON editor_assets(workspace_id, created_at DESC, asset_id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_editor_assets_user
+    ON editor_assets(user_id, created_at DESC);
@@ -33,13 +33,25 @@ export async function createSession(contentId) {
 }
 
 // saveSession: persists the current timeline state.
-export async function saveSession(sessionId, timeline) {
+// Pass the version you loaded — if another tab saved since, the backend answers
+// 409 and the thrown error carries { conflict: true, currentVersion, timeline }.
+// Resolves to { status: "saved", version } with the new version.
+export async function saveSession(sessionId, timeline, version) {
   const res = await fetch(`${BASE_URL}/sessions/${sessionId}`, {
     method: "PUT",
     headers: { "Content-Type": "application/json","X-User-ID": DEV_USER_ID },
-    body: JSON.stringify({ timeline }),
+    body: JSON.stringify({ timeline, version }),
   })
 
+  if (res.status === 409) {
+    const body = await res.json()
+    const err = new Error(body.error || "session was modified by another save")
+    err.conflict = true
+    err.currentVersion = body.current_version
+    err.timeline = body.timeline
+    throw err
+  }
+
   if (!res.ok) {
     const err = await res.json().catch(() => ({ error: "unknown error" }))
     throw new Error(err.error || `saveSession failed: ${res.status}`)
@@ -87,4 +99,24 @@ export async function uploadFile(file) {
   }
 
   return res.json() // { file_url: "..." }
-}
\ No newline at end of file
+}
+// subscribeSessionEvents: opens the live event stream for a session.
+// onEvent receives { type, session_id, data, at } for every pushed event:
+//   ready | session.saved | repurposer.update | export.status | export.progress | resync
+// EventSource reconnects on its own; returns a function that closes the stream.
+export function subscribeSessionEvents(sessionId, onEvent) {
+  const source = new EventSource(`${BASE_URL}/sessions/${sessionId}/events`)
+  const types = ["ready", "session.saved", "repurposer.update", "export.status", "export.progress", "resync"]
+
+  for (const type of types) {
+    source.addEventListener(type, (e) => {
+      try {
+        onEvent(JSON.parse(e.data))
+      } catch (err) {
+        console.warn("subscribeSessionEvents: bad event payload", err)
+      }
+    })
+  }
+
+  return () => source.close()
+}